@@ -7,13 +7,18 @@ import (
 	"fmt"
 	"log/slog"
 	"math/big"
+	"net/http"
 	"os"
 	"os/exec"
 	"strings"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/samber/lo"
+	"github.com/spf13/pflag"
 
+	"sum/internal/settings"
 	"sum/internal/utils"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -34,14 +39,61 @@ type process struct {
 	runSeparately bool
 }
 
-const (
+// Benchmark shape, set by --operators, --sign-requests and --message-size.
+var (
 	operatorsCount       = 3
 	numberOfSignRequests = 1000
 	sizeOfMessageBytes   = 320
 )
 
+// keyTag is the relay key tag the sidecars get keys for and sign with, set by
+// --key-tag like the node's.
+var keyTag uint8 = 15
+
+// relayGRPC configures the connections to the relays under test.
+var relayGRPC = utils.DefaultGRPCOptions()
+
 func main() {
-	slog.SetLogLoggerLevel(slog.LevelDebug)
+	loader := settings.New(pflag.CommandLine, "BENCHMARK")
+	relayGRPC.RegisterFlags(pflag.CommandLine, "relay-", "relay API")
+	metricsListen := pflag.String("metrics-listen", "", "Address to serve Prometheus metrics of the relay calls on, e.g. :9103 (disabled when empty)")
+	pflag.IntVar(&operatorsCount, "operators", operatorsCount, "Number of relay sidecars to start")
+	pflag.IntVar(&numberOfSignRequests, "sign-requests", numberOfSignRequests, "Number of messages to sign")
+	pflag.IntVar(&sizeOfMessageBytes, "message-size", sizeOfMessageBytes, "Size in bytes of every signed message")
+	pflag.Uint8Var(&keyTag, "key-tag", keyTag, "Relay key tag the sidecars get keys for and sign messages with")
+	logLevel := pflag.String("log-level", "debug", "Log level (debug,info,warn,error)")
+	loader.Validate = func() error {
+		if operatorsCount < 1 || numberOfSignRequests < 1 || sizeOfMessageBytes < 1 {
+			return errors.New("--operators, --sign-requests and --message-size must be positive")
+		}
+		_, err := settings.ParseLogLevel(*logLevel)
+		return err
+	}
+	pflag.Parse()
+	if err := loader.Load(); err != nil {
+		slog.Error("Invalid configuration", "error", err)
+		os.Exit(1)
+	}
+	if loader.PrintRequested() {
+		if err := loader.Print(os.Stdout); err != nil {
+			panic(err)
+		}
+		return
+	}
+	level, _ := settings.ParseLogLevel(*logLevel)
+	slog.SetLogLoggerLevel(level)
+
+	registry := prometheus.NewRegistry()
+	relayGRPC.Metrics = utils.NewGRPCClientMetrics(registry, "benchmark")
+	if *metricsListen != "" {
+		go func() {
+			server := &http.Server{Addr: *metricsListen, Handler: promhttp.HandlerFor(registry, promhttp.HandlerOpts{}), ReadHeaderTimeout: 5 * time.Second}
+			slog.Info("Serving metrics", "addr", *metricsListen)
+			if err := server.ListenAndServe(); err != nil {
+				slog.Error("Metrics server failed", "error", err)
+			}
+		}()
+	}
 
 	ctx := context.Background()
 
@@ -154,7 +206,7 @@ func runProcesses(ctx context.Context, runSeparately bool) (processes, error) {
 		"--aggregator", "true",
 		"--committer", "true",
 	}
-	secretKeyTemplate := "symb/0/15/%s,evm/1/31337/0xac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80"
+	secretKeyTemplate := "symb/0/%d/%s,evm/1/31337/0xac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80"
 	firstKey := uint64(1e18)
 	firstPort := 8080
 	firstData := 0
@@ -162,7 +214,7 @@ func runProcesses(ctx context.Context, runSeparately bool) (processes, error) {
 	var prs processes
 
 	for i := 0; i < operatorsCount; i++ {
-		secretKey := fmt.Sprintf(secretKeyTemplate, common.Bytes2Hex(new(big.Int).SetUint64(firstKey+uint64(i)).Bytes()))
+		secretKey := fmt.Sprintf(secretKeyTemplate, keyTag, common.Bytes2Hex(new(big.Int).SetUint64(firstKey+uint64(i)).Bytes()))
 		apiAddr := fmt.Sprintf(":%d", firstPort+i)
 		args := append(commonArgs, "--secret-keys", secretKey, "--http-listen", apiAddr, "--storage-dir", fmt.Sprintf(".data/%03d", firstData+i))
 		if i == 0 {
@@ -187,7 +239,7 @@ func runProcesses(ctx context.Context, runSeparately bool) (processes, error) {
 			}
 		}
 
-		conn, err := utils.GetGRPCConnection("localhost" + apiAddr + "/api/v1")
+		conn, err := utils.GetGRPCConnection("localhost"+apiAddr+"/api/v1", relayGRPC)
 		if err != nil {
 			return nil, errors.Errorf("failed to create relay client: %w", err)
 		}
@@ -267,7 +319,7 @@ func (prs processes) sendMessageToAllRelays(ctx context.Context, epoch uint64) (
 
 func sendSignMessageRequest(ctx context.Context, pr process, message []byte, epoch uint64) (string, error) {
 	resp, err := pr.relayClient.SignMessage(ctx, &v1.SignMessageRequest{
-		KeyTag:        15,
+		KeyTag:        uint32(keyTag),
 		Message:       message,
 		RequiredEpoch: &epoch,
 	})
@@ -0,0 +1,307 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"sum/internal/flights"
+)
+
+func (s *flightServer) adminRoutes(r chi.Router) {
+	r.Use(s.requireAdmin)
+	r.Get("/clock", s.handleGetClock)
+	r.Post("/clock/set", s.handleSetClock)
+	r.Post("/clock/advance", s.handleAdvanceClock)
+	r.Get("/faults", s.handleGetFaults)
+	r.Put("/faults", s.handleSetFaults)
+	r.Delete("/faults", s.handleClearFaults)
+	r.Get("/snapshot", s.handleGetSnapshot)
+	r.Post("/snapshot", s.handleRestoreSnapshot)
+	r.Get("/usage", s.handleGetUsage)
+	r.Route("/generator", func(r chi.Router) {
+		r.Use(s.requireGenerator)
+		r.Get("/", s.handleGeneratorStatus)
+		r.Post("/pause", s.handleGeneratorPause(true))
+		r.Post("/resume", s.handleGeneratorPause(false))
+		r.Patch("/rules", s.handleGeneratorRules)
+		r.Post("/airlines/{airlineId}/flights/{flightId}/delay", s.handleForceStatus(flights.StatusDelayed))
+		r.Post("/airlines/{airlineId}/flights/{flightId}/depart", s.handleForceStatus(flights.StatusDeparted))
+		r.Post("/airlines/{airlineId}/ground-stop", s.handleGroundStop)
+	})
+}
+
+type clockResponse struct {
+	Now     int64 `json:"now"`
+	Mock    bool  `json:"mock"`
+	Running bool  `json:"running"`
+}
+
+func (s *flightServer) clockState() clockResponse {
+	if s.clock == nil {
+		return clockResponse{Now: s.store.Clock().Now().Unix(), Running: true}
+	}
+	return clockResponse{Now: s.clock.Now().Unix(), Mock: true, Running: s.clock.Running()}
+}
+
+func (s *flightServer) handleGetClock(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.clockState())
+}
+
+type setClockRequest struct {
+	Timestamp int64 `json:"timestamp"`
+}
+
+func (s *flightServer) handleSetClock(w http.ResponseWriter, r *http.Request) {
+	if s.clock == nil {
+		respondProblem(w, r, http.StatusConflict, flights.CodeFeatureDisabled, "mock clock is disabled")
+		return
+	}
+	var body setClockRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		respondProblem(w, r, http.StatusBadRequest, flights.CodeInvalidRequest, "invalid JSON body")
+		return
+	}
+	if body.Timestamp <= 0 {
+		respondValidation(w, r, requiredField("timestamp"))
+		return
+	}
+	if _, err := s.clock.Set(time.Unix(body.Timestamp, 0)); err != nil {
+		respondStoreError(w, r, err)
+		return
+	}
+	s.clockChanged()
+	writeJSON(w, http.StatusOK, s.clockState())
+}
+
+// advanceClockRequest mirrors evm_increaseTime, which takes whole seconds.
+type advanceClockRequest struct {
+	Seconds int64 `json:"seconds"`
+}
+
+func (s *flightServer) handleAdvanceClock(w http.ResponseWriter, r *http.Request) {
+	if s.clock == nil {
+		respondProblem(w, r, http.StatusConflict, flights.CodeFeatureDisabled, "mock clock is disabled")
+		return
+	}
+	var body advanceClockRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		respondProblem(w, r, http.StatusBadRequest, flights.CodeInvalidRequest, "invalid JSON body")
+		return
+	}
+	if _, err := s.clock.Advance(time.Duration(body.Seconds) * time.Second); err != nil {
+		respondStoreError(w, r, err)
+		return
+	}
+	s.clockChanged()
+	writeJSON(w, http.StatusOK, s.clockState())
+}
+
+func (s *flightServer) clockChanged() {
+	slog.Info("mock clock moved", "now", s.clock.Now().Unix())
+	if s.onClockChange != nil {
+		s.onClockChange()
+	}
+}
+
+func (s *flightServer) handleGetFaults(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{"faults": s.faults.config()})
+}
+
+func (s *flightServer) handleSetFaults(w http.ResponseWriter, r *http.Request) {
+	var body faultConfig
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		respondProblem(w, r, http.StatusBadRequest, flights.CodeInvalidRequest, "invalid JSON body")
+		return
+	}
+	if err := body.validate(); err != nil {
+		respondProblem(w, r, http.StatusBadRequest, flights.CodeInvalidRequest, err.Error())
+		return
+	}
+	s.faults.setConfig(body)
+	writeJSON(w, http.StatusOK, map[string]any{"faults": body})
+}
+
+func (s *flightServer) handleClearFaults(w http.ResponseWriter, r *http.Request) {
+	s.faults.setConfig(faultConfig{})
+	writeJSON(w, http.StatusOK, map[string]any{"faults": faultConfig{}})
+}
+
+func (s *flightServer) handleGetSnapshot(w http.ResponseWriter, r *http.Request) {
+	snap := s.store.Snapshot()
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="flights-snapshot-%d.json"`, snap.TakenAt))
+	writeJSON(w, http.StatusOK, snap)
+}
+
+// handleRestoreSnapshot loads a snapshot body into the store, replacing its
+// contents unless ?mode=merge is given.
+func (s *flightServer) handleRestoreSnapshot(w http.ResponseWriter, r *http.Request) {
+	mode := flights.RestoreReplace
+	if m := r.URL.Query().Get("mode"); m != "" {
+		mode = flights.RestoreMode(m)
+	}
+	snap, err := flights.ReadSnapshot(http.MaxBytesReader(w, r.Body, maxSnapshotBytes))
+	if err == nil {
+		err = s.store.Restore(snap, mode)
+	}
+	if err != nil {
+		respondStoreError(w, r, err)
+		return
+	}
+	slog.Info("Restored snapshot", "mode", mode, "takenAt", snap.TakenAt, "airlines", len(snap.Airlines), "flights", len(snap.Flights))
+	writeJSON(w, http.StatusOK, map[string]any{"mode": mode, "takenAt": snap.TakenAt, "airlines": len(snap.Airlines), "flights": len(snap.Flights)})
+}
+
+func (s *flightServer) handleGetUsage(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{"usage": s.limiter.usage()})
+}
+
+func (s *flightServer) requireGenerator(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.generator == nil {
+			respondProblem(w, r, http.StatusConflict, flights.CodeFeatureDisabled, "flight generator is disabled")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *flightServer) handleGeneratorStatus(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{"generator": s.generator.status()})
+}
+
+// pauseRequest selects which generator loops to pause or resume; an empty body selects both.
+type pauseRequest struct {
+	Create  bool `json:"create"`
+	Advance bool `json:"advance"`
+}
+
+func (s *flightServer) handleGeneratorPause(paused bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var body pauseRequest
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				respondProblem(w, r, http.StatusBadRequest, flights.CodeInvalidRequest, "invalid JSON body")
+				return
+			}
+		}
+		if !body.Create && !body.Advance {
+			body.Create, body.Advance = true, true
+		}
+		s.generator.setPaused(body.Create, body.Advance, paused)
+		writeJSON(w, http.StatusOK, map[string]any{"generator": s.generator.status()})
+	}
+}
+
+// generatorRulesRequest is a partial update of the generator rules. A null
+// per-airline probability removes that airline's override.
+type generatorRulesRequest struct {
+	CreateInterval          *string             `json:"createInterval"`
+	UpdateInterval          *string             `json:"updateInterval"`
+	DelayProbability        *float64            `json:"delayProbability"`
+	AirlineDelayProbability map[string]*float64 `json:"airlineDelayProbability"`
+}
+
+func (s *flightServer) handleGeneratorRules(w http.ResponseWriter, r *http.Request) {
+	var body generatorRulesRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		respondProblem(w, r, http.StatusBadRequest, flights.CodeInvalidRequest, "invalid JSON body")
+		return
+	}
+	rules := s.generator.currentRules()
+	for _, field := range []struct {
+		name  string
+		value *string
+		dst   *time.Duration
+	}{
+		{"createInterval", body.CreateInterval, &rules.CreateInterval},
+		{"updateInterval", body.UpdateInterval, &rules.UpdateInterval},
+	} {
+		if field.value == nil {
+			continue
+		}
+		d, err := time.ParseDuration(*field.value)
+		if err != nil || d <= 0 {
+			respondValidation(w, r, flights.FieldError{Field: field.name, Code: flights.CodeInvalidValue, Message: "must be a positive duration"})
+			return
+		}
+		*field.dst = d
+	}
+	if body.DelayProbability != nil {
+		rules.DelayProbability = *body.DelayProbability
+	}
+	if body.AirlineDelayProbability != nil {
+		overrides := make(map[string]float64, len(rules.AirlineDelayProbability))
+		for airlineID, p := range rules.AirlineDelayProbability {
+			overrides[airlineID] = p
+		}
+		for airlineID, p := range body.AirlineDelayProbability {
+			if p == nil {
+				delete(overrides, airlineID)
+				continue
+			}
+			overrides[airlineID] = *p
+		}
+		rules.AirlineDelayProbability = overrides
+	}
+	if err := s.generator.setRules(rules); err != nil {
+		respondProblem(w, r, http.StatusBadRequest, flights.CodeInvalidRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"generator": s.generator.status()})
+}
+
+func (s *flightServer) handleForceStatus(status flights.Status) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		updated, err := s.generator.forceStatus(r.Context(), chi.URLParam(r, "airlineId"), chi.URLParam(r, "flightId"), status)
+		if err != nil {
+			respondStoreError(w, r, err)
+			return
+		}
+		w.Header().Set("ETag", flightETag(updated))
+		writeJSON(w, http.StatusOK, flights.FlightResponse{Flight: updated})
+	}
+}
+
+// groundStopRequest defines the departure window of a ground stop. From defaults
+// to now and To to From plus DurationSeconds (one hour when unset).
+type groundStopRequest struct {
+	From            int64 `json:"from"`
+	To              int64 `json:"to"`
+	DurationSeconds int64 `json:"durationSeconds"`
+}
+
+func (s *flightServer) handleGroundStop(w http.ResponseWriter, r *http.Request) {
+	var body groundStopRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			respondProblem(w, r, http.StatusBadRequest, flights.CodeInvalidRequest, "invalid JSON body")
+			return
+		}
+	}
+	stop := groundStop{From: body.From, To: body.To}
+	if stop.From == 0 {
+		stop.From = s.store.Clock().Now().Unix()
+	}
+	if stop.To == 0 {
+		duration := body.DurationSeconds
+		if duration <= 0 {
+			duration = int64(time.Hour.Seconds())
+		}
+		stop.To = stop.From + duration
+	}
+	if stop.To < stop.From {
+		respondProblem(w, r, http.StatusBadRequest, flights.CodeInvalidValue, "ground stop must end after it starts")
+		return
+	}
+	delayed, err := s.generator.groundStop(r.Context(), chi.URLParam(r, "airlineId"), stop)
+	if err != nil {
+		respondStoreError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"groundStop": stop, "delayed": delayed})
+}
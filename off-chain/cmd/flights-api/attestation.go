@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"gopkg.in/yaml.v3"
+
+	"sum/internal/flights"
+)
+
+// loadAirlineKeys reads a YAML/JSON map of airlineId to hex private key.
+func loadAirlineKeys(path string) (flights.KeyRing, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read airline keys: %w", err)
+	}
+	var raw map[string]string
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parse airline keys: %w", err)
+	}
+	keyRing := make(flights.KeyRing, len(raw))
+	for airlineID, hexKey := range raw {
+		key, err := crypto.HexToECDSA(strings.TrimPrefix(strings.TrimSpace(hexKey), "0x"))
+		if err != nil {
+			return nil, fmt.Errorf("airline %s key: %w", airlineID, err)
+		}
+		keyRing[airlineID] = key
+	}
+	return keyRing, nil
+}
+
+// assignAirlineSigners registers the key ring's addresses as airline signers. An
+// airline that already names a different signer is a configuration error.
+func assignAirlineSigners(airlines []flights.Airline, keyRing flights.KeyRing) error {
+	for i, airline := range airlines {
+		address, ok := keyRing.Address(airline.AirlineID)
+		if !ok {
+			continue
+		}
+		if airline.Signer != "" && common.HexToAddress(airline.Signer) != address {
+			return fmt.Errorf("airline %s: key for %s does not match configured signer %s", airline.AirlineID, address.Hex(), airline.Signer)
+		}
+		airlines[i].Signer = address.Hex()
+	}
+	return nil
+}
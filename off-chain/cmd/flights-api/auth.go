@@ -0,0 +1,327 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"gopkg.in/yaml.v3"
+
+	"sum/internal/flights"
+)
+
+const (
+	keyTypeStatic = "static"
+	keyTypeHMAC   = "hmac"
+
+	// maxSignatureSkew bounds how far an HMAC timestamp may drift from wall time.
+	maxSignatureSkew = 5 * time.Minute
+	// maxSignedBodyBytes bounds the body of an HMAC-signed request, which is read
+	// whole to check the signature. Snapshot restores are the largest signed bodies.
+	maxSignedBodyBytes = maxSnapshotBytes
+)
+
+// authConfig is the --auth-file format. Keys without airlines or admin rights
+// must be read-only.
+type authConfig struct {
+	AnonymousReads bool           `yaml:"anonymousReads"`
+	Keys           []apiKeyConfig `yaml:"keys"`
+}
+
+type apiKeyConfig struct {
+	ID string `yaml:"id"`
+	// Secret is the bearer token for static keys and the signing secret for HMAC keys.
+	Secret    string `yaml:"secret"`
+	SecretEnv string `yaml:"secretEnv"`
+	Type      string `yaml:"type"`
+	ReadOnly  bool   `yaml:"readOnly"`
+	// Airlines lists the airlines whose flights the key may write; "*" allows all.
+	Airlines []string `yaml:"airlines"`
+	Admin    bool     `yaml:"admin"`
+	// RateLimits overrides --rate-limit for this key, e.g. {reads: 100/s}.
+	RateLimits map[string]string `yaml:"rateLimits"`
+}
+
+// principal is the authenticated caller of a request.
+type principal struct {
+	keyID       string
+	readOnly    bool
+	admin       bool
+	allAirlines bool
+	airlines    map[string]bool
+	limits      rateLimits
+}
+
+func (p *principal) canWrite(airlineID string) bool {
+	if p.readOnly {
+		return false
+	}
+	return p.admin || p.allAirlines || p.airlines[airlineID]
+}
+
+type hmacKey struct {
+	secret    []byte
+	principal *principal
+}
+
+// authenticator verifies static API keys and HMAC-signed requests.
+type authenticator struct {
+	anonymousReads bool
+	// static is keyed by the SHA-256 of the secret so lookups do not compare secrets directly.
+	static map[[sha256.Size]byte]*principal
+	hmac   map[string]hmacKey
+	now    func() time.Time
+	// maxBodyBytes bounds signed bodies; larger ones are rejected unverified.
+	maxBodyBytes int64
+
+	mu   sync.Mutex
+	seen map[string]int64 // signature -> timestamp, to reject replays within the skew window
+}
+
+func loadAuthConfig(path string) (authConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return authConfig{}, fmt.Errorf("read auth file: %w", err)
+	}
+	var cfg authConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return authConfig{}, fmt.Errorf("parse auth file: %w", err)
+	}
+	return cfg, nil
+}
+
+func newAuthenticator(cfg authConfig) (*authenticator, error) {
+	a := &authenticator{
+		anonymousReads: cfg.AnonymousReads,
+		static:         make(map[[sha256.Size]byte]*principal),
+		hmac:           make(map[string]hmacKey),
+		now:            time.Now,
+		maxBodyBytes:   maxSignedBodyBytes,
+		seen:           make(map[string]int64),
+	}
+	ids := make(map[string]bool, len(cfg.Keys))
+	for i, key := range cfg.Keys {
+		if key.ID == "" {
+			return nil, fmt.Errorf("keys[%d]: id is required", i)
+		}
+		if ids[key.ID] {
+			return nil, fmt.Errorf("keys[%d]: duplicate id %q", i, key.ID)
+		}
+		ids[key.ID] = true
+		secret := key.Secret
+		if key.SecretEnv != "" {
+			secret = os.Getenv(key.SecretEnv)
+		}
+		if secret == "" {
+			return nil, fmt.Errorf("key %s: secret is required", key.ID)
+		}
+		if key.ReadOnly && (key.Admin || len(key.Airlines) > 0) {
+			return nil, fmt.Errorf("key %s: read-only keys cannot have airlines or admin rights", key.ID)
+		}
+		if !key.ReadOnly && !key.Admin && len(key.Airlines) == 0 {
+			return nil, fmt.Errorf("key %s: set readOnly, airlines or admin", key.ID)
+		}
+		p := &principal{keyID: key.ID, readOnly: key.ReadOnly, admin: key.Admin, airlines: make(map[string]bool), limits: make(rateLimits)}
+		for class, value := range key.RateLimits {
+			if err := p.limits.set(class, value); err != nil {
+				return nil, fmt.Errorf("key %s: %w", key.ID, err)
+			}
+		}
+		for _, airlineID := range key.Airlines {
+			if airlineID == "*" {
+				p.allAirlines = true
+				continue
+			}
+			p.airlines[airlineID] = true
+		}
+		switch key.Type {
+		case "", keyTypeStatic:
+			a.static[sha256.Sum256([]byte(secret))] = p
+		case keyTypeHMAC:
+			a.hmac[key.ID] = hmacKey{secret: []byte(secret), principal: p}
+		default:
+			return nil, fmt.Errorf("key %s: unknown type %q", key.ID, key.Type)
+		}
+	}
+	return a, nil
+}
+
+type principalKey struct{}
+
+func principalFrom(ctx context.Context) *principal {
+	p, _ := ctx.Value(principalKey{}).(*principal)
+	return p
+}
+
+// middleware authenticates every request but /healthz and /openapi.json. Read-only keys and
+// anonymous readers are limited to GET and HEAD; per-airline and admin checks
+// happen in requireAirlineWrite and requireAdmin once the route is known.
+func (a *authenticator) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/healthz" || r.URL.Path == "/openapi.json" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		read := r.Method == http.MethodGet || r.Method == http.MethodHead
+		p, err := a.authenticate(w, r)
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			respondTooLarge(w, r, tooLarge)
+			return
+		}
+		if err != nil {
+			respondUnauthenticated(w, r, err.Error())
+			return
+		}
+		if p == nil {
+			if !read || !a.anonymousReads {
+				respondUnauthenticated(w, r, "missing credentials")
+				return
+			}
+		} else if p.readOnly && !read {
+			respondForbidden(w, r, fmt.Sprintf("key %s is read-only", p.keyID))
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), principalKey{}, p)))
+	})
+}
+
+// authenticate returns the caller's principal, or nil when the request carries
+// no credentials at all.
+func (a *authenticator) authenticate(w http.ResponseWriter, r *http.Request) (*principal, error) {
+	if keyID := r.Header.Get(flights.HeaderKeyID); keyID != "" {
+		return a.authenticateHMAC(w, r, keyID)
+	}
+	token := r.Header.Get("X-API-Key")
+	if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		token = strings.TrimSpace(bearer)
+	}
+	return a.authenticateToken(token)
+}
+
+// authenticateToken looks up a static API key; an empty token yields no principal.
+func (a *authenticator) authenticateToken(token string) (*principal, error) {
+	if token == "" {
+		return nil, nil
+	}
+	p, ok := a.static[sha256.Sum256([]byte(token))]
+	if !ok {
+		return nil, fmt.Errorf("invalid API key")
+	}
+	return p, nil
+}
+
+// authenticateHMAC verifies a signed request over its whole body. A body over
+// maxBodyBytes fails with *http.MaxBytesError before the signature is checked.
+func (a *authenticator) authenticateHMAC(w http.ResponseWriter, r *http.Request, keyID string) (*principal, error) {
+	key, ok := a.hmac[keyID]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", keyID)
+	}
+	ts, err := strconv.ParseInt(r.Header.Get(flights.HeaderTimestamp), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s header", flights.HeaderTimestamp)
+	}
+	now := a.now()
+	if d := now.Sub(time.Unix(ts, 0)); d > maxSignatureSkew || d < -maxSignatureSkew {
+		return nil, fmt.Errorf("request timestamp outside the allowed %s skew", maxSignatureSkew)
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, a.maxBodyBytes))
+	if err != nil {
+		return nil, fmt.Errorf("read body: %w", err)
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	signature := strings.ToLower(r.Header.Get(flights.HeaderSignature))
+	expected := flights.RequestSignature(key.secret, r.Method, r.URL.RequestURI(), ts, body)
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return nil, fmt.Errorf("invalid request signature")
+	}
+	// Reads are safe to repeat; replaying a write could re-trigger a transition.
+	if r.Method != http.MethodGet && r.Method != http.MethodHead && !a.markSeen(signature, ts, now) {
+		return nil, fmt.Errorf("replayed request signature")
+	}
+	return key.principal, nil
+}
+
+// markSeen records a signature and reports whether it was new. Entries older
+// than the skew window are dropped since their timestamps are rejected anyway.
+func (a *authenticator) markSeen(signature string, ts int64, now time.Time) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	cutoff := now.Add(-maxSignatureSkew).Unix()
+	for sig, seenTs := range a.seen {
+		if seenTs < cutoff {
+			delete(a.seen, sig)
+		}
+	}
+	if _, ok := a.seen[signature]; ok {
+		return false
+	}
+	a.seen[signature] = ts
+	return true
+}
+
+// requireAirlineWrite allows the request when the caller may write flights of
+// the airline in the route.
+func (s *flightServer) requireAirlineWrite(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.authorizeAirline(w, r, chi.URLParam(r, "airlineId")) {
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *flightServer) requireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.auth != nil {
+			if p := principalFrom(r.Context()); p == nil || !p.admin {
+				respondForbidden(w, r, "admin key required")
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// authorizeAirline writes a 403 and returns false when the caller may not write
+// flights of airlineID.
+func (s *flightServer) authorizeAirline(w http.ResponseWriter, r *http.Request, airlineID string) bool {
+	if s.auth == nil {
+		return true
+	}
+	p := principalFrom(r.Context())
+	if p == nil || !p.canWrite(airlineID) {
+		keyID := "anonymous"
+		if p != nil {
+			keyID = p.keyID
+		}
+		respondForbidden(w, r, fmt.Sprintf("key %s may not write flights of airline %s", keyID, airlineID))
+		return false
+	}
+	return true
+}
+
+func respondUnauthenticated(w http.ResponseWriter, r *http.Request, message string) {
+	slog.Warn("rejected unauthenticated request", "requestId", middleware.GetReqID(r.Context()), "path", r.URL.Path, "reason", message)
+	w.Header().Set("WWW-Authenticate", `Bearer realm="flights-api"`)
+	respondProblem(w, r, http.StatusUnauthorized, flights.CodeUnauthenticated, message)
+}
+
+func respondForbidden(w http.ResponseWriter, r *http.Request, message string) {
+	slog.Warn("rejected unauthorized request", "requestId", middleware.GetReqID(r.Context()), "path", r.URL.Path, "reason", message)
+	respondProblem(w, r, http.StatusForbidden, flights.CodeForbidden, message)
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"sum/internal/flights"
+)
+
+func newAuthServer(t *testing.T, cfg authConfig) http.Handler {
+	t.Helper()
+	start := time.Unix(1_700_000_000, 0)
+	srv := newFlightServer(flights.NewStoreWithClock(flights.NewMockClock(start, false), seedAirlines(), seedFlights(start)))
+	auth, err := newAuthenticator(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.auth = auth
+	return srv.routes()
+}
+
+func TestAuthScopesWritesToAirlines(t *testing.T) {
+	handler := newAuthServer(t, authConfig{Keys: []apiKeyConfig{
+		{ID: "oracle", Secret: "read-secret", ReadOnly: true},
+		{ID: "alpha-ops", Secret: "alpha-secret", Airlines: []string{"ALPHA"}},
+		{ID: "ops", Secret: "admin-secret", Admin: true},
+	}})
+	do := func(method, path, key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(`{}`))
+		if key != "" {
+			req.Header.Set("Authorization", "Bearer "+key)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	cases := []struct {
+		method, path, key string
+		want              int
+	}{
+		{http.MethodGet, "/healthz", "", http.StatusOK},
+		{http.MethodGet, "/airlines", "", http.StatusUnauthorized},
+		{http.MethodGet, "/airlines", "wrong", http.StatusUnauthorized},
+		{http.MethodGet, "/airlines", "read-secret", http.StatusOK},
+		{http.MethodPost, "/airlines/ALPHA/flights/ALPHA-001/delay", "read-secret", http.StatusForbidden},
+		{http.MethodPost, "/airlines/BETA/flights/BETA-001/delay", "alpha-secret", http.StatusForbidden},
+		{http.MethodPost, "/airlines/ALPHA/flights/ALPHA-001/delay", "alpha-secret", http.StatusOK},
+		{http.MethodGet, "/admin/clock", "alpha-secret", http.StatusForbidden},
+		{http.MethodGet, "/admin/clock", "admin-secret", http.StatusOK},
+	}
+	for _, tc := range cases {
+		rec := do(tc.method, tc.path, tc.key)
+		if rec.Code != tc.want {
+			t.Fatalf("%s %s with %q: expected %d, got %d: %s", tc.method, tc.path, tc.key, tc.want, rec.Code, rec.Body.String())
+		}
+		if rec.Code == http.StatusUnauthorized || rec.Code == http.StatusForbidden {
+			var body struct {
+				Code string `json:"code"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || body.Code == "" {
+				t.Fatalf("expected structured auth error, got %s", rec.Body.String())
+			}
+		}
+	}
+}
+
+func TestAuthVerifiesHMACSignatures(t *testing.T) {
+	handler := newAuthServer(t, authConfig{Keys: []apiKeyConfig{
+		{ID: "beta-signer", Secret: "hmac-secret", Type: keyTypeHMAC, Airlines: []string{"BETA"}},
+	}})
+	body := []byte(`{"flightId":"BETA-100","departureTimestamp":1700003600}`)
+	send := func(signedBody []byte, at time.Time) int {
+		req := httptest.NewRequest(http.MethodPost, "/airlines/BETA/flights", bytes.NewReader(body))
+		flights.SignRequest(req, "beta-signer", []byte("hmac-secret"), signedBody, at)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	now := time.Now()
+	if code := send([]byte(`{"flightId":"BETA-666"}`), now); code != http.StatusUnauthorized {
+		t.Fatalf("expected tampered body to be rejected, got %d", code)
+	}
+	if code := send(body, now.Add(-time.Hour)); code != http.StatusUnauthorized {
+		t.Fatalf("expected stale timestamp to be rejected, got %d", code)
+	}
+	if code := send(body, now); code != http.StatusCreated {
+		t.Fatalf("expected signed request to succeed, got %d", code)
+	}
+	if code := send(body, now); code != http.StatusUnauthorized {
+		t.Fatalf("expected replayed request to be rejected, got %d", code)
+	}
+}
+
+func TestAuthRejectsOversizedSignedBodies(t *testing.T) {
+	start := time.Unix(1_700_000_000, 0)
+	srv := newFlightServer(flights.NewStoreWithClock(flights.NewMockClock(start, false), seedAirlines(), seedFlights(start)))
+	auth, err := newAuthenticator(authConfig{Keys: []apiKeyConfig{
+		{ID: "beta-signer", Secret: "hmac-secret", Type: keyTypeHMAC, Airlines: []string{"BETA"}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := []byte(`{"flightId":"BETA-100","departureTimestamp":1700003600}`)
+	auth.maxBodyBytes = int64(len(body) - 1)
+	srv.auth = auth
+	handler := srv.routes()
+
+	// The signature covers the whole body, so a truncated read must not verify it.
+	req := httptest.NewRequest(http.MethodPost, "/airlines/BETA/flights", bytes.NewReader(body))
+	flights.SignRequest(req, "beta-signer", []byte("hmac-secret"), body[:len(body)-1], time.Now())
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var problem flights.Problem
+	if err := json.Unmarshal(rec.Body.Bytes(), &problem); err != nil || problem.Code != flights.CodePayloadTooLarge {
+		t.Fatalf("expected a payload_too_large problem, got %s", rec.Body.String())
+	}
+	if _, err := srv.store.GetFlight("BETA", "BETA-100"); err == nil {
+		t.Fatal("expected the oversized request not to reach the handler")
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"sum/internal/flights"
+)
+
+// flightETag is the strong validator of a single flight, derived from its version.
+func flightETag(f flights.Flight) string {
+	return fmt.Sprintf(`"v%d"`, f.Version)
+}
+
+// parseIfMatch returns the flight version required by an If-Match header: 0 when
+// the header is absent or "*", an error when it names no flight version.
+func parseIfMatch(r *http.Request) (int64, error) {
+	value := strings.TrimSpace(r.Header.Get("If-Match"))
+	if value == "" || value == "*" {
+		return 0, nil
+	}
+	tag, ok := strings.CutPrefix(strings.Trim(value, `"`), "v")
+	version, err := strconv.ParseInt(tag, 10, 64)
+	if !ok || err != nil || version <= 0 || strings.HasPrefix(value, "W/") {
+		return 0, fmt.Errorf("%w: If-Match must be a flight ETag such as \"v3\"", flights.ErrVersionMismatch)
+	}
+	return version, nil
+}
+
+// etagMatches implements the weak comparison used by If-None-Match.
+func etagMatches(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// writeJSONConditional writes a 200 with the given ETag, or a bodyless 304 when
+// the client already holds that representation.
+func writeJSONConditional(w http.ResponseWriter, r *http.Request, etag string, payload any) {
+	w.Header().Set("ETag", etag)
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	writeJSON(w, http.StatusOK, payload)
+}
+
+// writeJSONHashed is writeJSONConditional for collections, whose ETag is the
+// hash of the encoded body so that pollers get a 304 until anything changes.
+func writeJSONHashed(w http.ResponseWriter, r *http.Request, payload any) {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(payload); err != nil {
+		slog.Error("failed to encode response", "error", err)
+		respondProblem(w, r, http.StatusInternalServerError, flights.CodeInternal, "internal server error")
+		return
+	}
+	sum := sha256.Sum256(buf.Bytes())
+	etag := `"` + hex.EncodeToString(sum[:12]) + `"`
+	w.Header().Set("ETag", etag)
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(buf.Bytes())
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+
+	"sum/internal/flights"
+)
+
+func newTestHandler() http.Handler {
+	start := time.Unix(1_700_000_000, 0)
+	store := flights.NewStoreWithClock(flights.NewMockClock(start, false), seedAirlines(), seedFlights(start))
+	return newFlightServer(store).routes()
+}
+
+func serve(handler http.Handler, method, path, body string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestIdempotencyKeyReplaysCreate(t *testing.T) {
+	handler := newTestHandler()
+	body := `{"flightId":"ALPHA-100","departureTimestamp":1700003600}`
+	key := map[string]string{idempotencyHeader: "retry-1"}
+
+	first := serve(handler, http.MethodPost, "/airlines/ALPHA/flights", body, key)
+	if first.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", first.Code, first.Body.String())
+	}
+	retry := serve(handler, http.MethodPost, "/airlines/ALPHA/flights", body, key)
+	if retry.Code != http.StatusCreated || retry.Body.String() != first.Body.String() || retry.Header().Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("expected replayed 201, got %d: %s", retry.Code, retry.Body.String())
+	}
+	if rec := serve(handler, http.MethodPost, "/airlines/ALPHA/flights", body, nil); rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409 without the key, got %d", rec.Code)
+	}
+	if rec := serve(handler, http.MethodPost, "/airlines/ALPHA/flights", `{"flightId":"ALPHA-101","departureTimestamp":1700003600}`, key); rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422 for a reused key, got %d", rec.Code)
+	}
+}
+
+func TestFlightETagsAndPreconditions(t *testing.T) {
+	handler := newTestHandler()
+
+	get := serve(handler, http.MethodGet, "/airlines/ALPHA/flights/ALPHA-001", "", nil)
+	etag := get.Header().Get("ETag")
+	if get.Code != http.StatusOK || etag != `"v1"` {
+		t.Fatalf("expected 200 with ETag v1, got %d %q", get.Code, etag)
+	}
+	if rec := serve(handler, http.MethodGet, "/airlines/ALPHA/flights/ALPHA-001", "", map[string]string{"If-None-Match": etag}); rec.Code != http.StatusNotModified {
+		t.Fatalf("expected 304, got %d", rec.Code)
+	}
+	list := serve(handler, http.MethodGet, "/airlines/ALPHA/flights", "", nil)
+	if rec := serve(handler, http.MethodGet, "/airlines/ALPHA/flights", "", map[string]string{"If-None-Match": list.Header().Get("ETag")}); rec.Code != http.StatusNotModified {
+		t.Fatalf("expected 304 for unchanged list, got %d", rec.Code)
+	}
+
+	delay := serve(handler, http.MethodPost, "/airlines/ALPHA/flights/ALPHA-001/delay", "", map[string]string{"If-Match": etag})
+	if delay.Code != http.StatusOK || delay.Header().Get("ETag") != `"v2"` {
+		t.Fatalf("expected conditional delay to succeed with v2, got %d %q: %s", delay.Code, delay.Header().Get("ETag"), delay.Body.String())
+	}
+	if rec := serve(handler, http.MethodPost, "/airlines/ALPHA/flights/ALPHA-001/depart", "", map[string]string{"If-Match": etag}); rec.Code != http.StatusPreconditionFailed {
+		t.Fatalf("expected 412 for a stale ETag, got %d", rec.Code)
+	}
+	if rec := serve(handler, http.MethodGet, "/airlines/ALPHA/flights", "", map[string]string{"If-None-Match": list.Header().Get("ETag")}); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 after the list changed, got %d", rec.Code)
+	}
+}
+
+func TestIdempotencyKeyIsReleasedWhenTheHandlerPanics(t *testing.T) {
+	cache := newIdempotencyCache(time.Hour)
+	panics := true
+	handler := middleware.Recoverer(cache.middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if panics {
+			panic("store exploded")
+		}
+		w.WriteHeader(http.StatusCreated)
+	})))
+	key := map[string]string{idempotencyHeader: "retry-1"}
+
+	if rec := serve(handler, http.MethodPost, "/airlines/ALPHA/flights", `{}`, key); rec.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500 from the panic, got %d", rec.Code)
+	}
+	panics = false
+	if rec := serve(handler, http.MethodPost, "/airlines/ALPHA/flights", `{}`, key); rec.Code != http.StatusCreated {
+		t.Fatalf("expected the retry to run instead of 409, got %d", rec.Code)
+	}
+}
+
+func TestIdempotencyKeyRejectsOversizedBodies(t *testing.T) {
+	cache := newIdempotencyCache(time.Hour)
+	cache.maxBodyBytes = 8
+	called := false
+	handler := cache.middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.WriteHeader(http.StatusCreated)
+	}))
+	key := map[string]string{idempotencyHeader: "retry-1"}
+
+	rec := serve(handler, http.MethodPost, "/flights/import", `{"flightId":"ALPHA-100"}`, key)
+	if rec.Code != http.StatusRequestEntityTooLarge || called {
+		t.Fatalf("expected 413 before the handler ran, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := serve(handler, http.MethodPost, "/flights/import", `{}`, key); rec.Code != http.StatusCreated {
+		t.Fatalf("expected the rejected body not to claim the key, got %d", rec.Code)
+	}
+}
+
+func TestIdempotencyEntriesExpireInOrder(t *testing.T) {
+	cache := newIdempotencyCache(time.Hour)
+	now := time.Unix(1_700_000_000, 0)
+	cache.now = func() time.Time { return now }
+	handler := cache.middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+	post := func(key string) *httptest.ResponseRecorder {
+		return serve(handler, http.MethodPost, "/airlines/ALPHA/flights", `{}`, map[string]string{idempotencyHeader: key})
+	}
+
+	post("first")
+	now = now.Add(30 * time.Minute)
+	post("second")
+	now = now.Add(45 * time.Minute)
+	if rec := post("second"); rec.Header().Get("Idempotent-Replayed") != "true" {
+		t.Fatal("expected the unexpired key to be replayed")
+	}
+	if _, ok := cache.entries["|first"]; ok || cache.expiry.Len() != 1 {
+		t.Fatalf("expected only the expired entry to be dropped, got %d entries", len(cache.entries))
+	}
+	if rec := post("first"); rec.Header().Get("Idempotent-Replayed") != "" {
+		t.Fatal("expected the expired key to run again")
+	}
+}
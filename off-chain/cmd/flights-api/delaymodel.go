@@ -0,0 +1,396 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"sum/internal/flights"
+)
+
+const (
+	delayModelFixed       = "fixed"
+	delayModelStatistical = "statistical"
+
+	distributionFixed       = "fixed"
+	distributionExponential = "exponential"
+	distributionLogNormal   = "lognormal"
+	distributionEmpirical   = "empirical"
+
+	// defaultOnTimeThreshold follows the usual industry definition of an on-time departure.
+	defaultOnTimeThreshold = 15 * time.Minute
+)
+
+// delayModel decides, when a flight reaches its departure time, whether it is
+// delayed and for how long.
+type delayModel interface {
+	decide(flight flights.Flight, rng *rand.Rand) (bool, time.Duration)
+}
+
+// delayModelRules configures the delay model used by the generator.
+type delayModelRules struct {
+	// Type is "fixed" (default, a per-airline coin flip) or "statistical".
+	Type              string             `yaml:"type"`
+	OnTimeRate        *float64           `yaml:"onTimeRate"`
+	AirlineOnTimeRate map[string]float64 `yaml:"airlineOnTimeRate"`
+	// HourOfDay and DayOfWeek multiply the delay rate for departures in that
+	// hour (0-23) or weekday ("monday".."sunday") of Timezone.
+	HourOfDay map[int]float64        `yaml:"hourOfDay"`
+	DayOfWeek map[string]float64     `yaml:"dayOfWeek"`
+	Timezone  string                 `yaml:"timezone"`
+	Duration  delayDurationRules     `yaml:"duration"`
+	Calibrate *delayCalibrationRules `yaml:"calibration"`
+}
+
+// delayDurationRules describes how long a delay lasts. Sampled durations are
+// multiplied by Scale so that real-world delays can be compressed for demos.
+type delayDurationRules struct {
+	Distribution string        `yaml:"distribution"`
+	Mean         time.Duration `yaml:"mean"`
+	Median       time.Duration `yaml:"median"`
+	Sigma        float64       `yaml:"sigma"`
+	Min          time.Duration `yaml:"min"`
+	Max          time.Duration `yaml:"max"`
+	Scale        float64       `yaml:"scale"`
+}
+
+// delayCalibrationRules points to a CSV of historical departures with the
+// columns airline, scheduled_departure (RFC 3339) and delay_minutes.
+type delayCalibrationRules struct {
+	CSV             string            `yaml:"csv"`
+	AirlineMap      map[string]string `yaml:"airlineMap"`
+	OnTimeThreshold time.Duration     `yaml:"onTimeThreshold"`
+}
+
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+func (r *delayModelRules) validate() error {
+	if r == nil {
+		return nil
+	}
+	switch r.Type {
+	case "", delayModelFixed, delayModelStatistical:
+	default:
+		return fmt.Errorf("unknown type %q", r.Type)
+	}
+	if r.OnTimeRate != nil && (*r.OnTimeRate < 0 || *r.OnTimeRate > 1) {
+		return fmt.Errorf("onTimeRate must be within [0, 1], got %v", *r.OnTimeRate)
+	}
+	for airlineID, rate := range r.AirlineOnTimeRate {
+		if rate < 0 || rate > 1 {
+			return fmt.Errorf("airlineOnTimeRate[%s] must be within [0, 1], got %v", airlineID, rate)
+		}
+	}
+	for hour, factor := range r.HourOfDay {
+		if hour < 0 || hour > 23 || factor < 0 {
+			return fmt.Errorf("hourOfDay[%d]: hour must be within [0, 23] and factor non-negative", hour)
+		}
+	}
+	for day, factor := range r.DayOfWeek {
+		if _, ok := weekdays[strings.ToLower(day)]; !ok || factor < 0 {
+			return fmt.Errorf("dayOfWeek[%s]: unknown weekday or negative factor", day)
+		}
+	}
+	if r.Timezone != "" {
+		if _, err := time.LoadLocation(r.Timezone); err != nil {
+			return fmt.Errorf("timezone: %w", err)
+		}
+	}
+	switch r.Duration.Distribution {
+	case "", distributionFixed, distributionEmpirical:
+	case distributionExponential:
+		if r.Duration.Mean <= 0 {
+			return fmt.Errorf("duration.mean is required for the exponential distribution")
+		}
+	case distributionLogNormal:
+		if r.Duration.Median <= 0 || r.Duration.Sigma <= 0 {
+			return fmt.Errorf("duration.median and duration.sigma are required for the lognormal distribution")
+		}
+	default:
+		return fmt.Errorf("unknown duration distribution %q", r.Duration.Distribution)
+	}
+	if r.Duration.Min < 0 || r.Duration.Max < 0 || r.Duration.Scale < 0 {
+		return fmt.Errorf("duration min, max and scale must not be negative")
+	}
+	if r.Calibrate != nil && r.Calibrate.CSV == "" {
+		return fmt.Errorf("calibration.csv is required")
+	}
+	return nil
+}
+
+// newDelayModel builds the delay model described by the generator rules.
+func newDelayModel(rules generatorRules) (delayModel, error) {
+	if rules.delayModelType() == delayModelFixed {
+		return fixedRateModel{rules: rules}, nil
+	}
+	return newStatisticalModel(rules)
+}
+
+// fixedRateModel delays flights with the configured per-airline probability for
+// the fixed DelayDuration.
+type fixedRateModel struct {
+	rules generatorRules
+}
+
+func (m fixedRateModel) decide(flight flights.Flight, rng *rand.Rand) (bool, time.Duration) {
+	return rng.Float64() < m.rules.delayProbabilityFor(flight.AirlineID), m.rules.DelayDuration
+}
+
+// statisticalModel derives the delay rate from per-airline on-time rates
+// adjusted by time-of-day and day-of-week factors and samples delay durations
+// from a distribution.
+type statisticalModel struct {
+	rules    generatorRules
+	model    delayModelRules
+	location *time.Location
+	samples  []time.Duration
+}
+
+func newStatisticalModel(rules generatorRules) (*statisticalModel, error) {
+	model := *rules.DelayModel
+	location := time.UTC
+	if model.Timezone != "" {
+		loc, err := time.LoadLocation(model.Timezone)
+		if err != nil {
+			return nil, err
+		}
+		location = loc
+	}
+	m := &statisticalModel{rules: rules, model: model, location: location}
+	if model.Calibrate != nil {
+		stats, err := loadDelayCalibration(*model.Calibrate, location)
+		if err != nil {
+			return nil, fmt.Errorf("calibrate delay model: %w", err)
+		}
+		m.applyCalibration(stats)
+	}
+	if m.model.Duration.Distribution == distributionEmpirical && len(m.samples) == 0 {
+		return nil, errors.New("empirical duration distribution requires calibration data with delayed departures")
+	}
+	return m, nil
+}
+
+// applyCalibration fills in everything the configuration leaves unset.
+func (m *statisticalModel) applyCalibration(stats delayCalibration) {
+	if m.model.OnTimeRate == nil {
+		rate := stats.onTimeRate
+		m.model.OnTimeRate = &rate
+	}
+	airlineRates := make(map[string]float64, len(stats.airlineOnTimeRate)+len(m.model.AirlineOnTimeRate))
+	for airlineID, rate := range stats.airlineOnTimeRate {
+		airlineRates[airlineID] = rate
+	}
+	for airlineID, rate := range m.model.AirlineOnTimeRate {
+		airlineRates[airlineID] = rate
+	}
+	m.model.AirlineOnTimeRate = airlineRates
+	if m.model.HourOfDay == nil {
+		m.model.HourOfDay = stats.hourOfDay
+	}
+	if m.model.DayOfWeek == nil {
+		m.model.DayOfWeek = stats.dayOfWeek
+	}
+	m.samples = stats.delays
+	if m.model.Duration.Distribution == "" && len(m.samples) > 0 {
+		m.model.Duration.Distribution = distributionEmpirical
+	}
+}
+
+// delayRate returns the probability that the flight departs late.
+func (m *statisticalModel) delayRate(flight flights.Flight) float64 {
+	var rate float64
+	if p, ok := m.rules.AirlineDelayProbability[flight.AirlineID]; ok {
+		rate = p
+	} else if onTime, ok := m.model.AirlineOnTimeRate[flight.AirlineID]; ok {
+		rate = 1 - onTime
+	} else if m.model.OnTimeRate != nil {
+		rate = 1 - *m.model.OnTimeRate
+	} else {
+		rate = m.rules.DelayProbability
+	}
+	departure := time.Unix(flight.DepartureTimestamp, 0).In(m.location)
+	if factor, ok := m.model.HourOfDay[departure.Hour()]; ok {
+		rate *= factor
+	}
+	for day, factor := range m.model.DayOfWeek {
+		if weekdays[strings.ToLower(day)] == departure.Weekday() {
+			rate *= factor
+		}
+	}
+	return math.Min(math.Max(rate, 0), 1)
+}
+
+func (m *statisticalModel) decide(flight flights.Flight, rng *rand.Rand) (bool, time.Duration) {
+	if rng.Float64() >= m.delayRate(flight) {
+		return false, 0
+	}
+	return true, m.sampleDuration(rng)
+}
+
+func (m *statisticalModel) sampleDuration(rng *rand.Rand) time.Duration {
+	d := m.model.Duration
+	var sampled time.Duration
+	switch d.Distribution {
+	case distributionExponential:
+		sampled = time.Duration(rng.ExpFloat64() * float64(d.Mean))
+	case distributionLogNormal:
+		sampled = time.Duration(float64(d.Median) * math.Exp(d.Sigma*rng.NormFloat64()))
+	case distributionEmpirical:
+		sampled = m.samples[rng.Intn(len(m.samples))]
+	default:
+		sampled = m.rules.DelayDuration
+	}
+	if d.Scale > 0 {
+		sampled = time.Duration(float64(sampled) * d.Scale)
+	}
+	if d.Min > 0 && sampled < d.Min {
+		sampled = d.Min
+	}
+	if d.Max > 0 && sampled > d.Max {
+		sampled = d.Max
+	}
+	// Keep at least a second so the flight is observably delayed.
+	return max(sampled, time.Second)
+}
+
+// delayCalibration summarises historical on-time performance.
+type delayCalibration struct {
+	onTimeRate        float64
+	airlineOnTimeRate map[string]float64
+	hourOfDay         map[int]float64
+	dayOfWeek         map[string]float64
+	delays            []time.Duration
+}
+
+func loadDelayCalibration(rules delayCalibrationRules, location *time.Location) (delayCalibration, error) {
+	file, err := os.Open(rules.CSV)
+	if err != nil {
+		return delayCalibration{}, err
+	}
+	defer file.Close()
+	threshold := rules.OnTimeThreshold
+	if threshold <= 0 {
+		threshold = defaultOnTimeThreshold
+	}
+	return parseDelayCalibration(file, rules.AirlineMap, threshold, location)
+}
+
+type delayCounter struct {
+	total, late int
+}
+
+func counterFor[K comparable](counters map[K]*delayCounter, key K) *delayCounter {
+	if counters[key] == nil {
+		counters[key] = &delayCounter{}
+	}
+	return counters[key]
+}
+
+func (c delayCounter) lateRate() float64 {
+	if c.total == 0 {
+		return 0
+	}
+	return float64(c.late) / float64(c.total)
+}
+
+func parseDelayCalibration(r io.Reader, airlineMap map[string]string, threshold time.Duration, location *time.Location) (delayCalibration, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return delayCalibration{}, fmt.Errorf("read header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"airline", "scheduled_departure", "delay_minutes"} {
+		if _, ok := columns[name]; !ok {
+			return delayCalibration{}, fmt.Errorf("missing column %q", name)
+		}
+	}
+
+	var overall delayCounter
+	airlines := make(map[string]*delayCounter)
+	hours := make(map[int]*delayCounter)
+	days := make(map[time.Weekday]*delayCounter)
+	var delays []time.Duration
+
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return delayCalibration{}, fmt.Errorf("line %d: %w", line, err)
+		}
+		airline := strings.TrimSpace(record[columns["airline"]])
+		if mapped, ok := airlineMap[airline]; ok {
+			airline = mapped
+		}
+		departure, err := time.Parse(time.RFC3339, strings.TrimSpace(record[columns["scheduled_departure"]]))
+		if err != nil {
+			return delayCalibration{}, fmt.Errorf("line %d: scheduled_departure: %w", line, err)
+		}
+		minutes, err := strconv.ParseFloat(strings.TrimSpace(record[columns["delay_minutes"]]), 64)
+		if err != nil {
+			return delayCalibration{}, fmt.Errorf("line %d: delay_minutes: %w", line, err)
+		}
+		delay := time.Duration(minutes * float64(time.Minute))
+		late := delay > threshold
+		local := departure.In(location)
+
+		if late {
+			delays = append(delays, delay)
+		}
+		for _, c := range []*delayCounter{&overall, counterFor(airlines, airline), counterFor(hours, local.Hour()), counterFor(days, local.Weekday())} {
+			c.total++
+			if late {
+				c.late++
+			}
+		}
+	}
+	if overall.total == 0 {
+		return delayCalibration{}, errors.New("no departures in calibration data")
+	}
+
+	baseRate := overall.lateRate()
+	stats := delayCalibration{
+		onTimeRate:        1 - baseRate,
+		airlineOnTimeRate: make(map[string]float64, len(airlines)),
+		hourOfDay:         make(map[int]float64, len(hours)),
+		dayOfWeek:         make(map[string]float64, len(days)),
+		delays:            delays,
+	}
+	for airline, c := range airlines {
+		stats.airlineOnTimeRate[airline] = 1 - c.lateRate()
+	}
+	if baseRate > 0 {
+		for hour, c := range hours {
+			stats.hourOfDay[hour] = c.lateRate() / baseRate
+		}
+		for name, weekday := range weekdays {
+			if c, ok := days[weekday]; ok {
+				stats.dayOfWeek[name] = c.lateRate() / baseRate
+			}
+		}
+	}
+	sort.Slice(stats.delays, func(i, j int) bool { return stats.delays[i] < stats.delays[j] })
+	return stats, nil
+}
@@ -0,0 +1,398 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+
+	"sum/internal/flights"
+)
+
+const (
+	faultLatency      = "latency"
+	faultServerError  = "server-error"
+	faultThrottle     = "throttle"
+	faultTruncate     = "truncate"
+	faultMalformed    = "malformed"
+	faultStale        = "stale"
+	faultFlap         = "flap"
+	faultHeader       = "X-Injected-Fault"
+	faultClientHeader = "X-Client-ID"
+
+	// snapshotDepth is how many previous responses per path are kept for stale replies.
+	snapshotDepth = 10
+)
+
+// faultConfig describes which faults are injected into API responses. Rates are
+// probabilities in [0, 1] rolled independently for every request, except that
+// a body is either truncated or malformed, so those two rates share one roll.
+type faultConfig struct {
+	LatencyMs     int64    `json:"latencyMs"`
+	JitterMs      int64    `json:"jitterMs"`
+	ErrorRate     float64  `json:"errorRate"`
+	ThrottleRate  float64  `json:"throttleRate"`
+	TruncateRate  float64  `json:"truncateRate"`
+	MalformedRate float64  `json:"malformedRate"`
+	StaleRate     float64  `json:"staleRate"`
+	FlapRate      float64  `json:"flapRate"`
+	PerClient     bool     `json:"perClient"`
+	Routes        []string `json:"routes,omitempty"`
+}
+
+func (c faultConfig) validate() error {
+	if c.LatencyMs < 0 || c.JitterMs < 0 {
+		return fmt.Errorf("latencyMs and jitterMs must not be negative")
+	}
+	rates := map[string]float64{
+		"errorRate":     c.ErrorRate,
+		"throttleRate":  c.ThrottleRate,
+		"truncateRate":  c.TruncateRate,
+		"malformedRate": c.MalformedRate,
+		"staleRate":     c.StaleRate,
+		"flapRate":      c.FlapRate,
+	}
+	for name, rate := range rates {
+		if rate < 0 || rate > 1 {
+			return fmt.Errorf("%s must be within [0, 1], got %v", name, rate)
+		}
+	}
+	if c.TruncateRate+c.MalformedRate > 1 {
+		return fmt.Errorf("truncateRate and malformedRate exclude each other and must not add up to more than 1, got %v", c.TruncateRate+c.MalformedRate)
+	}
+	return nil
+}
+
+func (c faultConfig) enabled() bool {
+	return c.LatencyMs > 0 || c.JitterMs > 0 || c.ErrorRate > 0 || c.ThrottleRate > 0 ||
+		c.TruncateRate > 0 || c.MalformedRate > 0 || c.StaleRate > 0 || c.FlapRate > 0
+}
+
+func (c faultConfig) appliesTo(path string) bool {
+	if strings.HasPrefix(path, "/admin") {
+		return false
+	}
+	if len(c.Routes) == 0 {
+		return true
+	}
+	for _, prefix := range c.Routes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseFaultSpec parses the --faults flag, a comma separated list such as
+// "latency=200ms,jitter=50ms,error=0.1,throttle=0.05,per-client=true,routes=/airlines|/healthz".
+func parseFaultSpec(spec string) (faultConfig, error) {
+	var cfg faultConfig
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return cfg, nil
+	}
+	for _, part := range strings.Split(spec, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return cfg, fmt.Errorf("fault %q: expected key=value", part)
+		}
+		var err error
+		switch key {
+		case "latency", "jitter":
+			var d time.Duration
+			d, err = time.ParseDuration(value)
+			if key == "latency" {
+				cfg.LatencyMs = d.Milliseconds()
+			} else {
+				cfg.JitterMs = d.Milliseconds()
+			}
+		case "error":
+			cfg.ErrorRate, err = strconv.ParseFloat(value, 64)
+		case "throttle":
+			cfg.ThrottleRate, err = strconv.ParseFloat(value, 64)
+		case "truncate":
+			cfg.TruncateRate, err = strconv.ParseFloat(value, 64)
+		case "malformed":
+			cfg.MalformedRate, err = strconv.ParseFloat(value, 64)
+		case "stale":
+			cfg.StaleRate, err = strconv.ParseFloat(value, 64)
+		case "flap":
+			cfg.FlapRate, err = strconv.ParseFloat(value, 64)
+		case "per-client":
+			cfg.PerClient, err = strconv.ParseBool(value)
+		case "routes":
+			cfg.Routes = strings.Split(value, "|")
+		default:
+			return cfg, fmt.Errorf("unknown fault %q", key)
+		}
+		if err != nil {
+			return cfg, fmt.Errorf("fault %q: %w", key, err)
+		}
+	}
+	return cfg, cfg.validate()
+}
+
+// faultInjector is a middleware that degrades responses according to its config.
+type faultInjector struct {
+	mu        sync.Mutex
+	cfg       faultConfig
+	seed      int64
+	rand      *rand.Rand
+	clients   map[string]*rand.Rand
+	snapshots map[string][]responseSnapshot
+}
+
+func newFaultInjector(cfg faultConfig, seed int64) *faultInjector {
+	return &faultInjector{
+		cfg:       cfg,
+		seed:      seed,
+		rand:      rand.New(rand.NewSource(seed)),
+		clients:   make(map[string]*rand.Rand),
+		snapshots: make(map[string][]responseSnapshot),
+	}
+}
+
+func (f *faultInjector) config() faultConfig {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.cfg
+}
+
+func (f *faultInjector) setConfig(cfg faultConfig) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.cfg = cfg
+	slog.Info("fault injection updated", "config", cfg)
+}
+
+// roller returns a function drawing random numbers for the request. With
+// per-client faults every client gets its own deterministic stream so that
+// different clients observe different answers.
+func (f *faultInjector) roller(client string, perClient bool) func() float64 {
+	return func() float64 {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if !perClient {
+			return f.rand.Float64()
+		}
+		rng, ok := f.clients[client]
+		if !ok {
+			h := fnv.New64a()
+			_, _ = h.Write([]byte(client))
+			rng = rand.New(rand.NewSource(f.seed ^ int64(h.Sum64())))
+			f.clients[client] = rng
+		}
+		return rng.Float64()
+	}
+}
+
+func (f *faultInjector) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cfg := f.config()
+		if !cfg.enabled() || !cfg.appliesTo(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		client := clientKey(r)
+		roll := f.roller(client, cfg.PerClient)
+		logFault := func(kind string, attrs ...any) {
+			args := []any{"fault", kind, "method", r.Method, "path", r.URL.Path, "client", client, "requestId", middleware.GetReqID(r.Context())}
+			slog.Warn("injected fault", append(args, attrs...)...)
+		}
+		var injected []string
+
+		if delay := time.Duration(cfg.LatencyMs) * time.Millisecond; delay > 0 || cfg.JitterMs > 0 {
+			if cfg.JitterMs > 0 {
+				delay += time.Duration(roll() * float64(time.Duration(cfg.JitterMs)*time.Millisecond))
+			}
+			logFault(faultLatency, "delay", delay)
+			injected = append(injected, faultLatency)
+			select {
+			case <-time.After(delay):
+			case <-r.Context().Done():
+				return
+			}
+		}
+
+		if roll() < cfg.ErrorRate {
+			codes := []int{http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable}
+			code := codes[int(roll()*float64(len(codes)))%len(codes)]
+			logFault(faultServerError, "status", code)
+			w.Header().Set(faultHeader, strings.Join(append(injected, faultServerError), ","))
+			respondProblem(w, r, code, flights.CodeInternal, "injected server error")
+			return
+		}
+		if roll() < cfg.ThrottleRate {
+			logFault(faultThrottle)
+			w.Header().Set(faultHeader, strings.Join(append(injected, faultThrottle), ","))
+			w.Header().Set("Retry-After", "1")
+			respondProblem(w, r, http.StatusTooManyRequests, flights.CodeRateLimited, "injected rate limit")
+			return
+		}
+
+		rec := &responseRecorder{header: make(http.Header), status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		body := rec.body.Bytes()
+
+		if r.Method == http.MethodGet && rec.status == http.StatusOK {
+			// A stale body goes out with its own ETag, so a client revalidating
+			// with it gets the current body next time instead of a 304.
+			current := responseSnapshot{body: body, etag: rec.header.Get("ETag")}
+			if stale, ok := f.staleSnapshot(r.URL.Path, current, roll() < cfg.StaleRate, roll); ok {
+				logFault(faultStale)
+				injected = append(injected, faultStale)
+				body = stale.body
+				setETag(rec.header, stale.etag)
+			}
+			if roll() < cfg.FlapRate {
+				if flapped, ok := flapStatuses(body, roll); ok {
+					logFault(faultFlap)
+					injected = append(injected, faultFlap)
+					body = flapped
+					// No ETag names the flapped body; it must not be cached.
+					rec.header.Del("ETag")
+				}
+			}
+		}
+		// A body is either truncated or malformed, so a single roll picks between
+		// them and each happens at its configured rate.
+		switch corrupt := roll(); {
+		case corrupt < cfg.TruncateRate && len(body) > 1:
+			logFault(faultTruncate, "bytes", len(body)/2)
+			injected = append(injected, faultTruncate)
+			body = body[:len(body)/2]
+		case corrupt >= cfg.TruncateRate && corrupt < cfg.TruncateRate+cfg.MalformedRate && len(body) > 0:
+			logFault(faultMalformed)
+			injected = append(injected, faultMalformed)
+			body = append(bytes.TrimRight(body, "}\n"), []byte(`,"injected":}`)...)
+		}
+
+		for key, values := range rec.header {
+			w.Header()[key] = values
+		}
+		w.Header().Del("Content-Length")
+		if len(injected) > 0 {
+			w.Header().Set(faultHeader, strings.Join(injected, ","))
+		}
+		w.WriteHeader(rec.status)
+		_, _ = w.Write(body)
+	})
+}
+
+// responseSnapshot is a recorded 200 body with the ETag it was served under.
+type responseSnapshot struct {
+	body []byte
+	etag string
+}
+
+// staleSnapshot records current as the latest response for path and, when
+// serve is set, returns an older recorded response instead.
+func (f *faultInjector) staleSnapshot(path string, current responseSnapshot, serve bool, roll func() float64) (responseSnapshot, bool) {
+	f.mu.Lock()
+	history := f.snapshots[path]
+	older := history
+	history = append(history, responseSnapshot{body: bytes.Clone(current.body), etag: current.etag})
+	if len(history) > snapshotDepth {
+		history = history[len(history)-snapshotDepth:]
+	}
+	f.snapshots[path] = history
+	f.mu.Unlock()
+
+	if !serve || len(older) == 0 {
+		return responseSnapshot{}, false
+	}
+	return older[int(roll()*float64(len(older)))%len(older)], true
+}
+
+func setETag(header http.Header, etag string) {
+	if etag == "" {
+		header.Del("ETag")
+		return
+	}
+	header.Set("ETag", etag)
+}
+
+// flapStatuses rewrites the status of some flights in a flights/flight payload.
+func flapStatuses(body []byte, roll func() float64) ([]byte, bool) {
+	var payload map[string]json.RawMessage
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, false
+	}
+	statuses := []flights.Status{flights.StatusScheduled, flights.StatusDelayed, flights.StatusDeparted}
+	flip := func(f *flights.Flight) {
+		next := statuses[int(roll()*float64(len(statuses)))%len(statuses)]
+		if next == f.Status {
+			next = statuses[(int(roll()*float64(len(statuses)))+1)%len(statuses)]
+		}
+		f.Status = next
+	}
+	changed := false
+	if raw, ok := payload["flights"]; ok {
+		var list []flights.Flight
+		if err := json.Unmarshal(raw, &list); err != nil || len(list) == 0 {
+			return nil, false
+		}
+		for i := range list {
+			if roll() < 0.5 {
+				flip(&list[i])
+				changed = true
+			}
+		}
+		if !changed {
+			flip(&list[0])
+			changed = true
+		}
+		payload["flights"], _ = json.Marshal(list)
+	}
+	if raw, ok := payload["flight"]; ok {
+		var single flights.Flight
+		if err := json.Unmarshal(raw, &single); err != nil {
+			return nil, false
+		}
+		flip(&single)
+		changed = true
+		payload["flight"], _ = json.Marshal(single)
+	}
+	if !changed {
+		return nil, false
+	}
+	out, err := json.Marshal(payload)
+	if err != nil {
+		return nil, false
+	}
+	return append(out, '\n'), true
+}
+
+// clientKey identifies the caller, preferring an explicit client header over the
+// address set by middleware.RealIP.
+func clientKey(r *http.Request) string {
+	if id := strings.TrimSpace(r.Header.Get(faultClientHeader)); id != "" {
+		return id
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+type responseRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) Header() http.Header { return r.header }
+
+func (r *responseRecorder) WriteHeader(status int) { r.status = status }
+
+func (r *responseRecorder) Write(b []byte) (int, error) { return r.body.Write(b) }
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"sum/internal/flights"
+)
+
+func TestParseFaultSpec(t *testing.T) {
+	cfg, err := parseFaultSpec("latency=200ms,jitter=50ms,error=0.1,throttle=0.05,per-client=true,routes=/airlines|/healthz")
+	if err != nil {
+		t.Fatalf("parse spec: %v", err)
+	}
+	if cfg.LatencyMs != 200 || cfg.JitterMs != 50 || cfg.ErrorRate != 0.1 || cfg.ThrottleRate != 0.05 || !cfg.PerClient {
+		t.Fatalf("unexpected config %+v", cfg)
+	}
+	if len(cfg.Routes) != 2 || !cfg.appliesTo("/airlines/ALPHA/flights") || cfg.appliesTo("/admin/faults") {
+		t.Fatalf("unexpected routes %+v", cfg.Routes)
+	}
+	if _, err := parseFaultSpec("error=1.5"); err == nil {
+		t.Fatalf("expected out of range rate to be rejected")
+	}
+	if _, err := parseFaultSpec("bogus=1"); err == nil {
+		t.Fatalf("expected unknown fault to be rejected")
+	}
+}
+
+func newFaultTestServer(t *testing.T, cfg faultConfig) http.Handler {
+	t.Helper()
+	start := time.Unix(1_700_000_000, 0)
+	store := flights.NewStoreWithClock(flights.NewMockClock(start, false), seedAirlines(), seedFlights(start))
+	srv := newFlightServer(store)
+	srv.faults = newFaultInjector(cfg, 1)
+	return srv.routes()
+}
+
+func TestFaultInjectorThrottlesAndSkipsAdmin(t *testing.T) {
+	handler := newFaultTestServer(t, faultConfig{ThrottleRate: 1})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/airlines", nil))
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", rec.Code)
+	}
+	if rec.Header().Get(faultHeader) != faultThrottle || rec.Header().Get("Retry-After") == "" {
+		t.Fatalf("expected throttle headers, got %v", rec.Header())
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/faults", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected admin routes to bypass faults, got %d", rec.Code)
+	}
+}
+
+func TestFaultInjectorFlapsAndCorruptsBodies(t *testing.T) {
+	handler := newFaultTestServer(t, faultConfig{FlapRate: 1})
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/airlines/ALPHA/flights", nil))
+	var body struct {
+		Flights []flights.Flight `json:"flights"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode flapped body: %v", err)
+	}
+	flapped := false
+	for _, f := range body.Flights {
+		if f.Status != flights.StatusScheduled {
+			flapped = true
+		}
+	}
+	if !flapped {
+		t.Fatalf("expected at least one flapped status, got %+v", body.Flights)
+	}
+
+	handler = newFaultTestServer(t, faultConfig{MalformedRate: 1})
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/airlines", nil))
+	if json.Valid(rec.Body.Bytes()) {
+		t.Fatalf("expected malformed JSON, got %s", rec.Body.String())
+	}
+}
+
+func TestFaultInjectorCorruptsAtTheConfiguredRates(t *testing.T) {
+	// Truncation and malformed JSON share one roll, so rates adding up to 1
+	// corrupt every body and neither rate is diluted by the other.
+	handler := newFaultTestServer(t, faultConfig{TruncateRate: 0.5, MalformedRate: 0.5})
+	counts := map[string]int{}
+	for range 200 {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/airlines", nil))
+		counts[rec.Header().Get(faultHeader)]++
+	}
+	if counts[faultTruncate]+counts[faultMalformed] != 200 || counts[faultMalformed] < 70 || counts[faultTruncate] < 70 {
+		t.Fatalf("expected every body truncated or malformed about evenly, got %v", counts)
+	}
+	if _, err := parseFaultSpec("truncate=0.6,malformed=0.6"); err == nil {
+		t.Fatal("expected truncate and malformed rates above 1 in total to be rejected")
+	}
+}
+
+func TestFaultInjectorServesStaleBodiesWithTheirOwnETag(t *testing.T) {
+	version := 0
+	handler := newFaultInjector(faultConfig{StaleRate: 1, MalformedRate: 1}, 1).middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		version++
+		w.Header().Set("ETag", fmt.Sprintf(`"v%d"`, version))
+		fmt.Fprintf(w, `{"version":%d}`, version)
+	}))
+
+	serve(handler, http.MethodGet, "/airlines", "", nil)
+	rec := serve(handler, http.MethodGet, "/airlines", "", nil)
+	if rec.Header().Get(faultHeader) != faultStale+","+faultMalformed || !strings.HasPrefix(rec.Body.String(), `{"version":1`) {
+		t.Fatalf("expected the first body served stale, got %q (%s)", rec.Body.String(), rec.Header().Get(faultHeader))
+	}
+	if etag := rec.Header().Get("ETag"); etag != `"v1"` {
+		t.Fatalf("expected the stale body's own ETag, got %s", etag)
+	}
+
+	rec = serve(handler, http.MethodDelete, "/airlines/ALPHA", "", nil)
+	if rec.Code != http.StatusNoContent || rec.Body.Len() != 0 || rec.Header().Get(faultHeader) != "" {
+		t.Fatalf("expected an empty body left alone, got %q (%s)", rec.Body.String(), rec.Header().Get(faultHeader))
+	}
+}
@@ -0,0 +1,563 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"sum/internal/flights"
+)
+
+// generatorRules configures the stochastic flight generator.
+type generatorRules struct {
+	CreateInterval   time.Duration `yaml:"createInterval"`
+	UpdateInterval   time.Duration `yaml:"updateInterval"`
+	DelayProbability float64       `yaml:"delayProbability"`
+	MaxScheduled     int           `yaml:"maxScheduled"`
+	MinLeadTime      time.Duration `yaml:"minLeadTime"`
+	MaxLeadTime      time.Duration `yaml:"maxLeadTime"`
+	DepartureGap     time.Duration `yaml:"departureGap"`
+	DelayDuration    time.Duration `yaml:"delayDuration"`
+	// AirlineDelayProbability overrides DelayProbability for individual airlines.
+	AirlineDelayProbability map[string]float64 `yaml:"airlineDelayProbability"`
+	// Rotation enables aircraft assignment and delay propagation along rotations.
+	Rotation *rotationRules `yaml:"rotation"`
+	// DelayModel replaces the per-airline coin flip with a statistical model.
+	DelayModel *delayModelRules `yaml:"delayModel"`
+}
+
+func defaultGeneratorRules() generatorRules {
+	return generatorRules{
+		CreateInterval:   45 * time.Second,
+		UpdateInterval:   15 * time.Second,
+		DelayProbability: 0.4,
+		MaxScheduled:     3,
+		MinLeadTime:      1 * time.Minute,
+		MaxLeadTime:      4 * time.Minute,
+		DepartureGap:     1 * time.Minute,
+		DelayDuration:    1 * time.Minute,
+	}
+}
+
+// UnmarshalYAML starts from the default rules so that omitted fields keep their
+// defaults, including those where zero is a valid value such as DelayProbability.
+func (r *generatorRules) UnmarshalYAML(value *yaml.Node) error {
+	type plain generatorRules
+	rules := plain(defaultGeneratorRules())
+	if err := value.Decode(&rules); err != nil {
+		return err
+	}
+	*r = generatorRules(rules)
+	return nil
+}
+
+// withDefaults fills zero fields from the default rules.
+func (r generatorRules) withDefaults() generatorRules {
+	def := defaultGeneratorRules()
+	if r.CreateInterval <= 0 {
+		r.CreateInterval = def.CreateInterval
+	}
+	if r.UpdateInterval <= 0 {
+		r.UpdateInterval = def.UpdateInterval
+	}
+	if r.MaxScheduled <= 0 {
+		r.MaxScheduled = def.MaxScheduled
+	}
+	if r.MinLeadTime <= 0 {
+		r.MinLeadTime = def.MinLeadTime
+	}
+	if r.MaxLeadTime < r.MinLeadTime {
+		r.MaxLeadTime = max(def.MaxLeadTime, r.MinLeadTime)
+	}
+	if r.DepartureGap <= 0 {
+		r.DepartureGap = def.DepartureGap
+	}
+	if r.DelayDuration <= 0 {
+		r.DelayDuration = def.DelayDuration
+	}
+	return r
+}
+
+func (r generatorRules) validate() error {
+	if r.DelayProbability < 0 || r.DelayProbability > 1 {
+		return fmt.Errorf("delayProbability must be within [0, 1], got %v", r.DelayProbability)
+	}
+	for airlineID, p := range r.AirlineDelayProbability {
+		if p < 0 || p > 1 {
+			return fmt.Errorf("airlineDelayProbability[%s] must be within [0, 1], got %v", airlineID, p)
+		}
+	}
+	if err := r.Rotation.validate(); err != nil {
+		return fmt.Errorf("rotation: %w", err)
+	}
+	if err := r.DelayModel.validate(); err != nil {
+		return fmt.Errorf("delayModel: %w", err)
+	}
+	return nil
+}
+
+func (r generatorRules) delayModelType() string {
+	if r.DelayModel == nil || r.DelayModel.Type == "" {
+		return delayModelFixed
+	}
+	return r.DelayModel.Type
+}
+
+func (r generatorRules) delayProbabilityFor(airlineID string) float64 {
+	if p, ok := r.AirlineDelayProbability[airlineID]; ok {
+		return p
+	}
+	return r.DelayProbability
+}
+
+// groundStop delays every scheduled flight of an airline departing within [From, To].
+type groundStop struct {
+	From int64 `json:"from"`
+	To   int64 `json:"to"`
+}
+
+func (gs groundStop) covers(departure int64) bool {
+	return departure >= gs.From && departure <= gs.To
+}
+
+type flightGenerator struct {
+	store *flights.Store
+	clock flights.Clock
+	rand  *rand.Rand
+
+	// mu guards the runtime-tunable state below.
+	mu            sync.Mutex
+	rules         generatorRules
+	model         delayModel
+	delayedUntil  map[string]int64
+	counters      map[string]int
+	createPaused  bool
+	advancePaused bool
+	groundStops   map[string][]groundStop
+
+	// passMu serialises generator passes triggered by tickers and admin calls.
+	passMu sync.Mutex
+	// reconfigure wakes the ticker loop after the intervals changed.
+	reconfigure chan struct{}
+}
+
+func newFlightGenerator(store *flights.Store, rules generatorRules, seed int64) (*flightGenerator, error) {
+	rules = rules.withDefaults()
+	model, err := newDelayModel(rules)
+	if err != nil {
+		return nil, err
+	}
+	gen := &flightGenerator{
+		store:        store,
+		clock:        store.Clock(),
+		rand:         rand.New(rand.NewSource(seed)),
+		rules:        rules,
+		model:        model,
+		delayedUntil: make(map[string]int64),
+		counters:     make(map[string]int),
+		groundStops:  make(map[string][]groundStop),
+		reconfigure:  make(chan struct{}, 1),
+	}
+	gen.bootstrapCounters()
+	return gen, nil
+}
+
+func (g *flightGenerator) start(ctx context.Context) {
+	rules := g.currentRules()
+	createTicker := time.NewTicker(rules.CreateInterval)
+	updateTicker := time.NewTicker(rules.UpdateInterval)
+	go func() {
+		defer createTicker.Stop()
+		defer updateTicker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-g.reconfigure:
+				rules := g.currentRules()
+				createTicker.Reset(rules.CreateInterval)
+				updateTicker.Reset(rules.UpdateInterval)
+			case <-createTicker.C:
+				if !g.isPaused(true) {
+					g.maybeCreateFlights()
+				}
+			case <-updateTicker.C:
+				g.advanceFlights()
+			}
+		}
+	}()
+}
+
+func (g *flightGenerator) currentRules() generatorRules {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.rules
+}
+
+func (g *flightGenerator) currentModel() delayModel {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.model
+}
+
+// setRules replaces the generator rules and applies new intervals to the running loop.
+func (g *flightGenerator) setRules(rules generatorRules) error {
+	rules = rules.withDefaults()
+	if err := rules.validate(); err != nil {
+		return err
+	}
+	model, err := newDelayModel(rules)
+	if err != nil {
+		return err
+	}
+	g.mu.Lock()
+	g.rules = rules
+	g.model = model
+	g.mu.Unlock()
+	select {
+	case g.reconfigure <- struct{}{}:
+	default:
+	}
+	slog.Info("generator rules updated", "createInterval", rules.CreateInterval, "updateInterval", rules.UpdateInterval, "delayProbability", rules.DelayProbability, "airlineDelayProbability", rules.AirlineDelayProbability)
+	return nil
+}
+
+func (g *flightGenerator) isPaused(create bool) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if create {
+		return g.createPaused
+	}
+	return g.advancePaused
+}
+
+// setPaused pauses or resumes auto-creation and/or auto-advancement.
+func (g *flightGenerator) setPaused(create, advance, paused bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if create {
+		g.createPaused = paused
+	}
+	if advance {
+		g.advancePaused = paused
+	}
+	slog.Info("generator pause state changed", "createPaused", g.createPaused, "advancePaused", g.advancePaused)
+}
+
+// generatorStatus is the admin view of the generator state.
+type generatorStatus struct {
+	CreatePaused            bool                    `json:"createPaused"`
+	AdvancePaused           bool                    `json:"advancePaused"`
+	CreateInterval          string                  `json:"createInterval"`
+	UpdateInterval          string                  `json:"updateInterval"`
+	DelayProbability        float64                 `json:"delayProbability"`
+	DelayModel              string                  `json:"delayModel"`
+	AirlineDelayProbability map[string]float64      `json:"airlineDelayProbability"`
+	GroundStops             map[string][]groundStop `json:"groundStops"`
+}
+
+func (g *flightGenerator) status() generatorStatus {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	stops := make(map[string][]groundStop, len(g.groundStops))
+	for airlineID, list := range g.groundStops {
+		stops[airlineID] = append([]groundStop(nil), list...)
+	}
+	overrides := make(map[string]float64, len(g.rules.AirlineDelayProbability))
+	for airlineID, p := range g.rules.AirlineDelayProbability {
+		overrides[airlineID] = p
+	}
+	return generatorStatus{
+		CreatePaused:            g.createPaused,
+		AdvancePaused:           g.advancePaused,
+		CreateInterval:          g.rules.CreateInterval.String(),
+		UpdateInterval:          g.rules.UpdateInterval.String(),
+		DelayProbability:        g.rules.DelayProbability,
+		DelayModel:              g.rules.delayModelType(),
+		AirlineDelayProbability: overrides,
+		GroundStops:             stops,
+	}
+}
+
+// forceStatus moves a flight to status immediately, regardless of its departure time.
+func (g *flightGenerator) forceStatus(ctx context.Context, airlineID, flightID string, status flights.Status) (flights.Flight, error) {
+	g.passMu.Lock()
+	defer g.passMu.Unlock()
+	updated, err := g.setStatus(ctx, airlineID, flightID, status)
+	if err != nil {
+		return flights.Flight{}, err
+	}
+	// A sampled delay belongs to the delay it was drawn for; a forced status
+	// starts over.
+	g.clearDelayedUntil(airlineID, flightID)
+	slog.Info("forced flight status", "airline", airlineID, "flight", flightID, "status", status)
+	return updated, nil
+}
+
+// groundStop delays every scheduled flight of the airline departing within the
+// window and keeps the window active so flights reaching it later are delayed too.
+func (g *flightGenerator) groundStop(ctx context.Context, airlineID string, stop groundStop) ([]flights.Flight, error) {
+	g.passMu.Lock()
+	defer g.passMu.Unlock()
+	flightsList, err := g.store.ListFlights(airlineID)
+	if err != nil {
+		return nil, err
+	}
+	g.mu.Lock()
+	g.groundStops[airlineID] = append(g.groundStops[airlineID], stop)
+	g.mu.Unlock()
+
+	delayed := make([]flights.Flight, 0)
+	for _, f := range flightsList {
+		if f.Status != flights.StatusScheduled || !stop.covers(f.DepartureTimestamp) {
+			continue
+		}
+		updated, err := g.setStatus(ctx, airlineID, f.FlightID, flights.StatusDelayed)
+		if err != nil {
+			continue
+		}
+		delayed = append(delayed, updated)
+	}
+	slog.Info("ground stop issued", "airline", airlineID, "from", stop.From, "to", stop.To, "delayed", len(delayed))
+	return delayed, nil
+}
+
+// groundStopped reports whether an active ground stop covers the departure.
+func (g *flightGenerator) groundStopped(airlineID string, departure, now int64) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	active := g.groundStops[airlineID][:0]
+	covered := false
+	for _, stop := range g.groundStops[airlineID] {
+		if stop.To < now {
+			continue
+		}
+		active = append(active, stop)
+		covered = covered || stop.covers(departure)
+	}
+	g.groundStops[airlineID] = active
+	return covered
+}
+
+func (g *flightGenerator) bootstrapCounters() {
+	airlines := g.store.ListAirlines()
+	for _, airline := range airlines {
+		flightsList, err := g.store.ListFlights(airline.AirlineID)
+		if err != nil {
+			continue
+		}
+		maxNum := 0
+		for _, f := range flightsList {
+			if n := parseFlightNumber(f.FlightID); n > maxNum {
+				maxNum = n
+			}
+		}
+		g.counters[airline.AirlineID] = maxNum
+	}
+}
+
+func (g *flightGenerator) maybeCreateFlights() {
+	g.passMu.Lock()
+	defer g.passMu.Unlock()
+	rules := g.currentRules()
+	airlines := g.store.ListAirlines()
+	now := g.clock.Now().Unix()
+	for _, airline := range airlines {
+		flightsList, err := g.store.ListFlights(airline.AirlineID)
+		if err != nil {
+			continue
+		}
+		scheduled := 0
+		for _, f := range flightsList {
+			if f.Status == flights.StatusScheduled && f.DepartureTimestamp > now {
+				scheduled++
+			}
+		}
+		if scheduled >= rules.MaxScheduled {
+			continue
+		}
+		flightID := g.nextFlightID(airline.AirlineID)
+		departure := now + int64(g.leadTime(rules).Seconds())
+		latest := now
+		for _, f := range flightsList {
+			if f.DepartureTimestamp > latest {
+				latest = f.DepartureTimestamp
+			}
+		}
+		minGap := int64(rules.DepartureGap.Seconds())
+		if latest >= departure {
+			departure = latest + minGap
+		}
+		var aircraft string
+		if rules.Rotation.enabled() {
+			aircraft, departure = rules.Rotation.assignAircraft(airline.AirlineID, flightsList, departure)
+		}
+		_, err = traceStatusChange(context.Background(), airline.AirlineID, flightID, flights.StatusScheduled, func(traceParent string) (flights.Flight, error) {
+			return g.store.CreateFlight(airline.AirlineID, flights.Flight{
+				AirlineID:          airline.AirlineID,
+				FlightID:           flightID,
+				DepartureTimestamp: departure,
+				Status:             flights.StatusScheduled,
+				AircraftID:         aircraft,
+				TraceParent:        traceParent,
+			})
+		})
+		if err != nil {
+			continue
+		}
+		slog.Info("auto-created flight", "airline", airline.AirlineID, "flight", flightID, "departure", departure, "aircraft", aircraft)
+	}
+}
+
+// leadTime picks how far ahead of now a generated flight departs, in whole minutes.
+func (g *flightGenerator) leadTime(rules generatorRules) time.Duration {
+	minMinutes := int(rules.MinLeadTime / time.Minute)
+	maxMinutes := int(rules.MaxLeadTime / time.Minute)
+	if maxMinutes <= minMinutes {
+		return rules.MinLeadTime
+	}
+	return time.Duration(g.rand.Intn(maxMinutes-minMinutes+1)+minMinutes) * time.Minute
+}
+
+// advanceFlights applies the transitions that are due, on the update ticker
+// and whenever the mock clock moves. It does nothing while advancing is paused.
+func (g *flightGenerator) advanceFlights() {
+	if g.isPaused(false) {
+		return
+	}
+	g.passMu.Lock()
+	defer g.passMu.Unlock()
+	rules := g.currentRules()
+	model := g.currentModel()
+	airlines := g.store.ListAirlines()
+	now := g.clock.Now().Unix()
+	// stillDelayed collects the flights left DELAYED by this pass; sampled
+	// delays of every other flight are dropped at the end.
+	stillDelayed := make(map[string]bool)
+	defer g.pruneDelayedUntil(stillDelayed)
+	for _, airline := range airlines {
+		flightsList, err := g.store.ListFlights(airline.AirlineID)
+		if err != nil {
+			continue
+		}
+		for _, f := range flightsList {
+			key := delayKey(f.AirlineID, f.FlightID)
+			switch f.Status {
+			case flights.StatusScheduled:
+				if now < f.DepartureTimestamp {
+					continue
+				}
+				if ready, inbound := rules.Rotation.aircraftReady(f, flightsList, now); !ready {
+					slog.Info("propagating inbound delay", "airline", airline.AirlineID, "flight", f.FlightID, "aircraft", f.AircraftID, "inbound", inbound)
+					g.updateStatus(airline.AirlineID, f.FlightID, flights.StatusDelayed)
+					continue
+				}
+				if g.groundStopped(airline.AirlineID, f.DepartureTimestamp, now) {
+					g.updateStatus(airline.AirlineID, f.FlightID, flights.StatusDelayed)
+					continue
+				}
+				if delayed, duration := model.decide(f, g.rand); delayed {
+					g.setDelayedUntil(f, f.DepartureTimestamp+int64(duration.Seconds()))
+					stillDelayed[key] = true
+					g.updateStatus(airline.AirlineID, f.FlightID, flights.StatusDelayed)
+				} else {
+					g.updateStatus(airline.AirlineID, f.FlightID, flights.StatusDeparted)
+				}
+			case flights.StatusDelayed:
+				stillDelayed[key] = true
+				if now < g.departAfterDelay(f, rules) || g.groundStopped(airline.AirlineID, f.DepartureTimestamp, now) {
+					continue
+				}
+				if ready, _ := rules.Rotation.aircraftReady(f, flightsList, now); !ready {
+					continue
+				}
+				if g.updateStatus(airline.AirlineID, f.FlightID, flights.StatusDeparted) {
+					delete(stillDelayed, key)
+				}
+			}
+		}
+	}
+}
+
+// departAfterDelay returns when a delayed flight may depart: the sampled delay
+// when the delay model chose one, DelayDuration after departure otherwise.
+func (g *flightGenerator) departAfterDelay(f flights.Flight, rules generatorRules) int64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if until, ok := g.delayedUntil[delayKey(f.AirlineID, f.FlightID)]; ok {
+		return until
+	}
+	return f.DepartureTimestamp + int64(rules.DelayDuration.Seconds())
+}
+
+func (g *flightGenerator) setDelayedUntil(f flights.Flight, until int64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.delayedUntil[delayKey(f.AirlineID, f.FlightID)] = until
+}
+
+func (g *flightGenerator) clearDelayedUntil(airlineID, flightID string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.delayedUntil, delayKey(airlineID, flightID))
+}
+
+// pruneDelayedUntil drops the sampled delays of flights that left DELAYED,
+// including through the airline or admin API, so the map stays bounded by the
+// delayed flights.
+func (g *flightGenerator) pruneDelayedUntil(stillDelayed map[string]bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for key := range g.delayedUntil {
+		if !stillDelayed[key] {
+			delete(g.delayedUntil, key)
+		}
+	}
+}
+
+func delayKey(airlineID, flightID string) string {
+	return airlineID + "|" + flightID
+}
+
+// updateStatus applies an automatic transition and reports whether it took.
+func (g *flightGenerator) updateStatus(airlineID, flightID string, status flights.Status) bool {
+	if _, err := g.setStatus(context.Background(), airlineID, flightID, status); err != nil {
+		return false
+	}
+	slog.Info("auto-updated flight", "airline", airlineID, "flight", flightID, "status", status)
+	return true
+}
+
+// setStatus changes a flight's status in a traced status change.
+func (g *flightGenerator) setStatus(ctx context.Context, airlineID, flightID string, status flights.Status) (flights.Flight, error) {
+	return traceStatusChange(ctx, airlineID, flightID, status, func(traceParent string) (flights.Flight, error) {
+		return g.store.ApplyStatusUpdate(airlineID, flightID, flights.StatusUpdate{Status: status, TraceParent: traceParent})
+	})
+}
+
+func (g *flightGenerator) nextFlightID(airlineID string) string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	n := g.counters[airlineID] + 1
+	g.counters[airlineID] = n
+	return fmt.Sprintf("%s-%03d", strings.ToUpper(airlineID), n)
+}
+
+func parseFlightNumber(id string) int {
+	idx := strings.LastIndex(id, "-")
+	if idx == -1 || idx == len(id)-1 {
+		return 0
+	}
+	n, err := strconv.Atoi(id[idx+1:])
+	if err != nil {
+		return 0
+	}
+	return n
+}
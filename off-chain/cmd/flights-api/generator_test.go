@@ -0,0 +1,271 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"sum/internal/flights"
+)
+
+func TestGroundStopDelaysScheduledFlightsAndHoldsDepartures(t *testing.T) {
+	start := time.Unix(1_700_000_000, 0)
+	clock := flights.NewMockClock(start, false)
+	store := flights.NewStoreWithClock(clock, seedAirlines(), seedFlights(start))
+	srv := newFlightServer(store)
+	srv.generator = mustGenerator(t, store, defaultGeneratorRules(), 1)
+	handler := srv.routes()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/admin/generator/airlines/ALPHA/ground-stop", strings.NewReader(`{"durationSeconds": 600}`)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	alpha, _ := store.ListFlights("ALPHA")
+	for _, f := range alpha {
+		if f.Status != flights.StatusDelayed {
+			t.Fatalf("expected %s to be delayed by the ground stop, got %s", f.FlightID, f.Status)
+		}
+	}
+
+	// Past the delay duration but still inside the window: flights must stay on the ground.
+	if _, err := clock.Advance(5 * time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	srv.generator.advanceFlights()
+	if f, _ := store.GetFlight("ALPHA", "ALPHA-001"); f.Status != flights.StatusDelayed {
+		t.Fatalf("expected ALPHA-001 held by ground stop, got %s", f.Status)
+	}
+
+	if _, err := clock.Advance(10 * time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	srv.generator.advanceFlights()
+	if f, _ := store.GetFlight("ALPHA", "ALPHA-001"); f.Status != flights.StatusDeparted {
+		t.Fatalf("expected ALPHA-001 departed after the ground stop, got %s", f.Status)
+	}
+}
+
+func TestPausedGeneratorIgnoresClockMoves(t *testing.T) {
+	start := time.Unix(1_700_000_000, 0)
+	clock := flights.NewMockClock(start, false)
+	store := flights.NewStoreWithClock(clock, seedAirlines(), seedFlights(start))
+	srv := newFlightServer(store)
+	srv.clock = clock
+	srv.generator = mustGenerator(t, store, defaultGeneratorRules(), 1)
+	srv.onClockChange = srv.generator.advanceFlights
+	handler := srv.routes()
+	before := mustListFlights(t, store, "ALPHA")
+
+	if rec := serve(handler, http.MethodPost, "/admin/generator/pause", `{"advance": true}`, nil); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := serve(handler, http.MethodPost, "/admin/clock/advance", `{"seconds": 3600}`, nil); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	for i, f := range mustListFlights(t, store, "ALPHA") {
+		if f.Status != before[i].Status {
+			t.Fatalf("expected %s to stay %s while paused, got %s", f.FlightID, before[i].Status, f.Status)
+		}
+	}
+
+	serve(handler, http.MethodPost, "/admin/generator/resume", `{"advance": true}`, nil)
+	serve(handler, http.MethodPost, "/admin/clock/advance", `{"seconds": 1}`, nil)
+	if f, _ := store.GetFlight("ALPHA", "ALPHA-001"); f.Status == flights.StatusScheduled {
+		t.Fatal("expected the resumed generator to apply the due transitions")
+	}
+}
+
+func TestGeneratorRulesPatchAppliesAirlineOverride(t *testing.T) {
+	start := time.Unix(1_700_000_000, 0)
+	store := flights.NewStoreWithClock(flights.NewMockClock(start, false), seedAirlines(), seedFlights(start))
+	srv := newFlightServer(store)
+	handler := srv.routes()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/generator", nil))
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409 without a generator, got %d", rec.Code)
+	}
+
+	srv.generator = mustGenerator(t, store, defaultGeneratorRules(), 1)
+	rec = httptest.NewRecorder()
+	body := `{"updateInterval": "5s", "airlineDelayProbability": {"BETA": 1}}`
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPatch, "/admin/generator/rules", strings.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	rules := srv.generator.currentRules()
+	if rules.UpdateInterval != 5*time.Second || rules.delayProbabilityFor("BETA") != 1 || rules.delayProbabilityFor("ALPHA") != 0.4 {
+		t.Fatalf("unexpected rules %+v", rules)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPatch, "/admin/generator/rules", strings.NewReader(`{"delayProbability": 2}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected invalid probability to be rejected, got %d", rec.Code)
+	}
+}
+
+func TestSeededGeneratorIsReproducible(t *testing.T) {
+	run := func() []flights.Flight {
+		start := time.Unix(1_700_000_000, 0)
+		clock := flights.NewMockClock(start, false)
+		store := flights.NewStoreWithClock(clock, seedAirlines(), seedFlights(start))
+		gen := mustGenerator(t, store, defaultGeneratorRules(), 42)
+		for range 5 {
+			gen.maybeCreateFlights()
+			if _, err := clock.Advance(2 * time.Minute); err != nil {
+				t.Fatal(err)
+			}
+			gen.advanceFlights()
+		}
+		var all []flights.Flight
+		for _, airline := range store.ListAirlines() {
+			list, err := store.ListFlights(airline.AirlineID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			all = append(all, list...)
+		}
+		return all
+	}
+
+	first, second := run(), run()
+	if len(first) != len(second) {
+		t.Fatalf("expected identical runs, got %d and %d flights", len(first), len(second))
+	}
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("run mismatch at %d: %+v vs %+v", i, first[i], second[i])
+		}
+	}
+}
+
+func TestRotationPropagatesInboundDelay(t *testing.T) {
+	start := time.Unix(1_700_000_000, 0)
+	clock := flights.NewMockClock(start, false)
+	dep := func(d time.Duration) int64 { return start.Add(d).Unix() }
+	store := flights.NewStoreWithClock(clock,
+		[]flights.Airline{{AirlineID: "ALPHA", Name: "Alpha Air"}},
+		[]flights.Flight{
+			{AirlineID: "ALPHA", FlightID: "ALPHA-001", DepartureTimestamp: dep(time.Minute), AircraftID: "ALPHA-AC01"},
+			{AirlineID: "ALPHA", FlightID: "ALPHA-002", DepartureTimestamp: dep(4 * time.Minute), AircraftID: "ALPHA-AC01"},
+			{AirlineID: "ALPHA", FlightID: "ALPHA-003", DepartureTimestamp: dep(4 * time.Minute), AircraftID: "ALPHA-AC02"},
+		},
+	)
+	rules := defaultGeneratorRules()
+	rules.DelayProbability = 0
+	rules.Rotation = &rotationRules{AircraftPerAirline: 2, LegDuration: 2 * time.Minute, Turnaround: time.Minute}
+	gen := mustGenerator(t, store, rules, 1)
+
+	if _, err := gen.forceStatus(context.Background(), "ALPHA", "ALPHA-001", flights.StatusDelayed); err != nil {
+		t.Fatal(err)
+	}
+	// The inbound leg departs two minutes late, so its aircraft is not back for the
+	// next leg at 4m, while the other tail departs on time.
+	if _, err := clock.Advance(3 * time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	gen.advanceFlights()
+	if _, err := clock.Advance(time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	gen.advanceFlights()
+
+	if f, _ := store.GetFlight("ALPHA", "ALPHA-002"); f.Status != flights.StatusDelayed {
+		t.Fatalf("expected ALPHA-002 delayed by its inbound leg, got %s", f.Status)
+	}
+	if f, _ := store.GetFlight("ALPHA", "ALPHA-003"); f.Status != flights.StatusDeparted {
+		t.Fatalf("expected ALPHA-003 on time, got %s", f.Status)
+	}
+
+	aircraft, departure := rules.Rotation.assignAircraft("ALPHA", mustListFlights(t, store, "ALPHA"), dep(5*time.Minute))
+	if aircraft != "ALPHA-AC01" || departure != dep(7*time.Minute) {
+		t.Fatalf("expected next rotation on ALPHA-AC01 at +7m, got %s at %d", aircraft, departure-start.Unix())
+	}
+}
+
+func mustListFlights(t *testing.T, store *flights.Store, airlineID string) []flights.Flight {
+	t.Helper()
+	list, err := store.ListFlights(airlineID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return list
+}
+
+func mustGenerator(t *testing.T, store *flights.Store, rules generatorRules, seed int64) *flightGenerator {
+	t.Helper()
+	gen, err := newFlightGenerator(store, rules, seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return gen
+}
+
+func TestDelayCalibrationDerivesRatesAndFactors(t *testing.T) {
+	csvData := `airline,scheduled_departure,delay_minutes
+AA,2024-03-01T08:00:00Z,0
+AA,2024-03-01T08:30:00Z,5
+AA,2024-03-01T18:00:00Z,60
+AA,2024-03-01T18:30:00Z,90
+BW,2024-03-02T08:00:00Z,0
+BW,2024-03-02T18:00:00Z,30
+`
+	stats, err := parseDelayCalibration(strings.NewReader(csvData), map[string]string{"AA": "ALPHA", "BW": "BETA"}, defaultOnTimeThreshold, time.UTC)
+	if err != nil {
+		t.Fatalf("parse calibration: %v", err)
+	}
+	if stats.onTimeRate != 0.5 || stats.airlineOnTimeRate["ALPHA"] != 0.5 || stats.airlineOnTimeRate["BETA"] != 0.5 {
+		t.Fatalf("unexpected on-time rates %+v", stats)
+	}
+	if stats.hourOfDay[8] != 0 || stats.hourOfDay[18] != 2 {
+		t.Fatalf("unexpected hour factors %+v", stats.hourOfDay)
+	}
+	if len(stats.delays) != 3 || stats.delays[0] != 30*time.Minute {
+		t.Fatalf("unexpected delay samples %+v", stats.delays)
+	}
+
+	model := &statisticalModel{rules: defaultGeneratorRules(), location: time.UTC}
+	model.applyCalibration(stats)
+	evening := flights.Flight{AirlineID: "ALPHA", DepartureTimestamp: time.Date(2024, 3, 4, 18, 0, 0, 0, time.UTC).Unix()}
+	morning := flights.Flight{AirlineID: "ALPHA", DepartureTimestamp: time.Date(2024, 3, 4, 8, 0, 0, 0, time.UTC).Unix()}
+	if model.delayRate(evening) != 1 || model.delayRate(morning) != 0 {
+		t.Fatalf("expected evening departures always late and morning ones on time, got %v and %v", model.delayRate(evening), model.delayRate(morning))
+	}
+}
+
+func TestSampledDelaysAreDroppedWhenFlightsLeaveDelayed(t *testing.T) {
+	start := time.Unix(1_700_000_000, 0)
+	clock := flights.NewMockClock(start, false)
+	store := flights.NewStoreWithClock(clock, seedAirlines(), seedFlights(start))
+	rules := defaultGeneratorRules()
+	rules.DelayProbability, rules.DelayDuration = 1, 24*time.Hour
+	gen := mustGenerator(t, store, rules, 1)
+	if _, err := clock.Advance(5 * time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	gen.advanceFlights()
+	if len(gen.delayedUntil) != 4 {
+		t.Fatalf("expected a sampled delay per flight, got %v", gen.delayedUntil)
+	}
+
+	// The airline departs one flight itself, and QA forces another.
+	if _, err := store.UpdateStatus("ALPHA", "ALPHA-001", flights.StatusDeparted); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := gen.forceStatus(context.Background(), "BETA", "BETA-451", flights.StatusDeparted); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := gen.delayedUntil[delayKey("BETA", "BETA-451")]; ok {
+		t.Fatal("expected the forced flight's sampled delay to be dropped right away")
+	}
+	gen.advanceFlights()
+	if len(gen.delayedUntil) != 2 {
+		t.Fatalf("expected only the delayed flights to keep a sampled delay, got %v", gen.delayedUntil)
+	}
+}
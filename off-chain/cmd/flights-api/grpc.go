@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"sum/internal/flights"
+	"sum/internal/flights/flightspb"
+)
+
+// watchBuffer is how many changes a WatchFlights stream may lag behind before
+// it is aborted.
+const watchBuffer = 256
+
+// grpcFlightsServer serves FlightsService from the same store as the REST API.
+// Static API keys are accepted as "authorization: Bearer <key>" or "x-api-key"
+// metadata; HMAC keys are REST-only.
+type grpcFlightsServer struct {
+	flightspb.UnimplementedFlightsServiceServer
+	store *flights.Store
+	auth  *authenticator
+}
+
+// newGRPCServer builds the gRPC server; limiter may be nil.
+func newGRPCServer(store *flights.Store, auth *authenticator, limiter *rateLimiter) *grpc.Server {
+	opts := []grpc.ServerOption{grpc.ChainUnaryInterceptor(traceUnary), grpc.ChainStreamInterceptor(traceStream)}
+	if limiter != nil {
+		opts = append(opts, grpc.ChainUnaryInterceptor(limiter.unaryInterceptor(auth)), grpc.ChainStreamInterceptor(limiter.streamInterceptor(auth)))
+	}
+	server := grpc.NewServer(opts...)
+	flightspb.RegisterFlightsServiceServer(server, &grpcFlightsServer{store: store, auth: auth})
+	return server
+}
+
+// authorize mirrors the REST rules: reads need a key unless anonymous reads are
+// allowed, writes need a key that may write the airline's flights.
+func (s *grpcFlightsServer) authorize(ctx context.Context, airlineID string, write bool) error {
+	if s.auth == nil {
+		return nil
+	}
+	p, err := s.auth.authenticateToken(metadataToken(ctx))
+	if err != nil {
+		return flightspb.Error(flights.CodeUnauthenticated, err.Error())
+	}
+	switch {
+	case p == nil && (write || !s.auth.anonymousReads):
+		return flightspb.Error(flights.CodeUnauthenticated, "missing credentials")
+	case write && !p.canWrite(airlineID):
+		return flightspb.Error(flights.CodeForbidden, fmt.Sprintf("key %s may not write flights of airline %s", p.keyID, airlineID))
+	}
+	return nil
+}
+
+func first(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// grpcStoreError is the gRPC counterpart of respondStoreError.
+func grpcStoreError(method string, err error) error {
+	code := flights.CodeOf(err)
+	if code == flights.CodeInternal {
+		slog.Error("gRPC request failed", "method", method, "error", err)
+		return flightspb.Error(code, "internal server error")
+	}
+	return flightspb.Error(code, err.Error())
+}
+
+func (s *grpcFlightsServer) ListAirlines(ctx context.Context, _ *flightspb.ListAirlinesRequest) (*flightspb.ListAirlinesResponse, error) {
+	if err := s.authorize(ctx, "", false); err != nil {
+		return nil, err
+	}
+	resp := &flightspb.ListAirlinesResponse{}
+	for _, airline := range s.store.ListAirlines() {
+		resp.Airlines = append(resp.Airlines, flightspb.FromAirline(airline))
+	}
+	return resp, nil
+}
+
+func (s *grpcFlightsServer) ListFlights(ctx context.Context, req *flightspb.ListFlightsRequest) (*flightspb.ListFlightsResponse, error) {
+	if err := s.authorize(ctx, "", false); err != nil {
+		return nil, err
+	}
+	items, err := s.store.ListFlights(req.GetAirlineId())
+	if err != nil {
+		return nil, grpcStoreError("ListFlights", err)
+	}
+	resp := &flightspb.ListFlightsResponse{}
+	for _, flight := range items {
+		resp.Flights = append(resp.Flights, flightspb.FromFlight(flight))
+	}
+	return resp, nil
+}
+
+func (s *grpcFlightsServer) GetFlight(ctx context.Context, req *flightspb.GetFlightRequest) (*flightspb.GetFlightResponse, error) {
+	if err := s.authorize(ctx, "", false); err != nil {
+		return nil, err
+	}
+	flight, err := s.store.GetFlight(req.GetAirlineId(), req.GetFlightId())
+	if err != nil {
+		return nil, grpcStoreError("GetFlight", err)
+	}
+	return &flightspb.GetFlightResponse{Flight: flightspb.FromFlight(flight)}, nil
+}
+
+func (s *grpcFlightsServer) CreateFlight(ctx context.Context, req *flightspb.CreateFlightRequest) (*flightspb.CreateFlightResponse, error) {
+	if err := s.authorize(ctx, req.GetAirlineId(), true); err != nil {
+		return nil, err
+	}
+	body := flights.CreateFlightRequest{
+		FlightID:           req.GetFlightId(),
+		DepartureTimestamp: req.GetDepartureTimestamp(),
+		AircraftID:         req.GetAircraftId(),
+		UpdatedAt:          req.GetUpdatedAt(),
+		Signature:          req.GetSignature(),
+	}
+	if invalid := validateCreateFlight(body); len(invalid) > 0 {
+		messages := make([]string, 0, len(invalid))
+		for _, fe := range invalid {
+			messages = append(messages, fe.Field+" "+fe.Message)
+		}
+		return nil, flightspb.Error(flights.CodeValidationFailed, strings.Join(messages, "; "))
+	}
+	created, err := traceStatusChange(ctx, req.GetAirlineId(), body.FlightID, flights.StatusScheduled, func(traceParent string) (flights.Flight, error) {
+		flight := newFlight(req.GetAirlineId(), body)
+		flight.TraceParent = traceParent
+		return s.store.CreateFlight(req.GetAirlineId(), flight)
+	})
+	if err != nil {
+		return nil, grpcStoreError("CreateFlight", err)
+	}
+	return &flightspb.CreateFlightResponse{Flight: flightspb.FromFlight(created)}, nil
+}
+
+func (s *grpcFlightsServer) UpdateStatus(ctx context.Context, req *flightspb.UpdateStatusRequest) (*flightspb.UpdateStatusResponse, error) {
+	if err := s.authorize(ctx, req.GetAirlineId(), true); err != nil {
+		return nil, err
+	}
+	status := flightspb.ToStatus(req.GetStatus())
+	updated, err := traceStatusChange(ctx, req.GetAirlineId(), req.GetFlightId(), status, func(traceParent string) (flights.Flight, error) {
+		return s.store.ApplyStatusUpdate(req.GetAirlineId(), req.GetFlightId(), flights.StatusUpdate{
+			Status:      status,
+			UpdatedAt:   req.GetUpdatedAt(),
+			Signature:   req.GetSignature(),
+			IfVersion:   req.GetIfVersion(),
+			TraceParent: traceParent,
+		})
+	})
+	if err != nil {
+		return nil, grpcStoreError("UpdateStatus", err)
+	}
+	return &flightspb.UpdateStatusResponse{Flight: flightspb.FromFlight(updated)}, nil
+}
+
+// WatchFlights subscribes before listing so no change between the initial
+// flights and the stream is lost; a flight may therefore be sent twice.
+func (s *grpcFlightsServer) WatchFlights(req *flightspb.WatchFlightsRequest, stream grpc.ServerStreamingServer[flightspb.WatchFlightsResponse]) error {
+	ctx := stream.Context()
+	if err := s.authorize(ctx, "", false); err != nil {
+		return err
+	}
+	changes, cancel := s.store.Watch(watchBuffer)
+	defer cancel()
+
+	airlineID := req.GetAirlineId()
+	if req.GetSendInitial() {
+		if err := s.sendFlights(stream, airlineID); err != nil {
+			return err
+		}
+	} else if airlineID != "" {
+		if _, err := s.store.ListFlights(airlineID); err != nil {
+			return grpcStoreError("WatchFlights", err)
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case flight, ok := <-changes:
+			if !ok {
+				return status.Error(codes.Aborted, "watch fell behind or the store was restored; list flights and watch again")
+			}
+			if airlineID != "" && flight.AirlineID != airlineID {
+				continue
+			}
+			if err := stream.Send(&flightspb.WatchFlightsResponse{Flight: flightspb.FromFlight(flight)}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// sendFlights streams the current flights of airlineID, or of every airline when empty.
+func (s *grpcFlightsServer) sendFlights(stream grpc.ServerStreamingServer[flightspb.WatchFlightsResponse], airlineID string) error {
+	airlineIDs := []string{airlineID}
+	if airlineID == "" {
+		airlineIDs = airlineIDs[:0]
+		for _, airline := range s.store.ListAirlines() {
+			airlineIDs = append(airlineIDs, airline.AirlineID)
+		}
+	}
+	for _, id := range airlineIDs {
+		items, err := s.store.ListFlights(id)
+		if err != nil {
+			return grpcStoreError("WatchFlights", err)
+		}
+		for _, flight := range items {
+			if err := stream.Send(&flightspb.WatchFlightsResponse{Flight: flightspb.FromFlight(flight)}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+
+	"sum/internal/flights"
+	"sum/internal/flights/flightspb"
+)
+
+func newGRPCClient(t *testing.T, store *flights.Store, auth *authenticator) *flightspb.Client {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	server := newGRPCServer(store, auth, nil)
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return flightspb.NewClient(conn)
+}
+
+func TestGRPCServesTheStore(t *testing.T) {
+	start := time.Unix(1_700_000_000, 0)
+	store := flights.NewStoreWithClock(flights.NewMockClock(start, false), seedAirlines(), seedFlights(start))
+	client := newGRPCClient(t, store, nil)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	airlines, err := client.ListAirlines(ctx)
+	if err != nil || len(airlines) != 3 {
+		t.Fatalf("list airlines: %v %v", airlines, err)
+	}
+	if _, err := client.ListFlights(ctx, "NOPE"); !errors.Is(err, flights.ErrAirlineNotFound) {
+		t.Fatalf("expected airline not found, got %v", err)
+	}
+
+	events := make(chan flights.Flight, 8)
+	watchCtx, stopWatch := context.WithCancel(ctx)
+	defer stopWatch()
+	go client.WatchFlights(watchCtx, "ALPHA", true, func(f flights.Flight) error {
+		events <- f
+		return nil
+	})
+	for range 2 {
+		if f := <-events; f.AirlineID != "ALPHA" {
+			t.Fatalf("expected the initial ALPHA flights, got %+v", f)
+		}
+	}
+
+	created, err := client.CreateFlight(ctx, "ALPHA", flights.CreateFlightRequest{FlightID: "ALPHA-100", DepartureTimestamp: 1_700_003_600})
+	if err != nil || created.Status != flights.StatusScheduled {
+		t.Fatalf("create flight: %+v %v", created, err)
+	}
+	if _, err := client.CreateFlight(ctx, "BETA", flights.CreateFlightRequest{FlightID: "BETA-100", DepartureTimestamp: 1_700_003_600}); err != nil {
+		t.Fatalf("create flight: %v", err)
+	}
+	if _, err := client.UpdateStatus(ctx, "ALPHA", "ALPHA-100", flights.StatusUpdate{Status: flights.StatusDelayed, IfVersion: created.Version}); err != nil {
+		t.Fatalf("delay flight: %v", err)
+	}
+	if _, err := client.UpdateStatus(ctx, "ALPHA", "ALPHA-100", flights.StatusUpdate{Status: flights.StatusDeparted, IfVersion: created.Version}); !errors.Is(err, flights.ErrVersionMismatch) {
+		t.Fatalf("expected a version mismatch, got %v", err)
+	}
+	for _, want := range []flights.Status{flights.StatusScheduled, flights.StatusDelayed} {
+		select {
+		case f := <-events:
+			if f.FlightID != "ALPHA-100" || f.Status != want {
+				t.Fatalf("expected ALPHA-100 %s, got %+v", want, f)
+			}
+		case <-ctx.Done():
+			t.Fatalf("timed out waiting for %s", want)
+		}
+	}
+}
+
+func TestGRPCEnforcesAPIKeys(t *testing.T) {
+	start := time.Unix(1_700_000_000, 0)
+	store := flights.NewStoreWithClock(flights.NewMockClock(start, false), seedAirlines(), seedFlights(start))
+	auth, err := newAuthenticator(authConfig{AnonymousReads: true, Keys: []apiKeyConfig{
+		{ID: "alpha-ops", Secret: "alpha-secret", Airlines: []string{"ALPHA"}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := newGRPCClient(t, store, auth)
+	ctx := context.Background()
+	update := flights.StatusUpdate{Status: flights.StatusDelayed}
+
+	if _, err := client.ListAirlines(ctx); err != nil {
+		t.Fatalf("anonymous read: %v", err)
+	}
+	var problem *flights.Problem
+	if _, err := client.UpdateStatus(ctx, "ALPHA", "ALPHA-001", update); !errors.As(err, &problem) || problem.Code != flights.CodeUnauthenticated {
+		t.Fatalf("expected unauthenticated, got %v", err)
+	}
+	client.APIKey = "alpha-secret"
+	if _, err := client.UpdateStatus(ctx, "BETA", "BETA-451", update); !errors.As(err, &problem) || problem.Code != flights.CodeForbidden {
+		t.Fatalf("expected forbidden, got %v", err)
+	}
+	if _, err := client.UpdateStatus(ctx, "ALPHA", "ALPHA-001", update); err != nil {
+		t.Fatalf("scoped write: %v", err)
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"sum/internal/flights"
+)
+
+const (
+	idempotencyHeader = "Idempotency-Key"
+	// maxIdempotencyKeyLength keeps keys to the size of a UUID-ish token.
+	maxIdempotencyKeyLength = 255
+	defaultIdempotencyTTL   = 24 * time.Hour
+	// maxIdempotentBodyBytes bounds keyed POST bodies, which are buffered to be
+	// fingerprinted. It fits a bulk import; send larger restores without a key.
+	maxIdempotentBodyBytes = maxImportBytes
+)
+
+// idempotencyCache replays the stored response of a POST retried with the same
+// Idempotency-Key, so a client that timed out can learn whether its call went
+// through. Keys are scoped to the caller's API key when authentication is on.
+type idempotencyCache struct {
+	ttl          time.Duration
+	now          func() time.Time
+	maxBodyBytes int64
+
+	mu      sync.Mutex
+	entries map[string]*idempotencyEntry
+	// expiry holds finished entries oldest first. The TTL is fixed, so they
+	// expire in that order and begin only looks at the front.
+	expiry *list.List
+}
+
+type idempotencyEntry struct {
+	key         string
+	fingerprint string
+	expires     time.Time
+	// done is false while the first request is still being handled.
+	done   bool
+	status int
+	header http.Header
+	body   []byte
+}
+
+func newIdempotencyCache(ttl time.Duration) *idempotencyCache {
+	return &idempotencyCache{ttl: ttl, now: time.Now, maxBodyBytes: maxIdempotentBodyBytes, entries: make(map[string]*idempotencyEntry), expiry: list.New()}
+}
+
+func (c *idempotencyCache) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyHeader)
+		if r.Method != http.MethodPost || key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			respondProblem(w, r, http.StatusBadRequest, flights.CodeInvalidRequest, "Idempotency-Key is too long")
+			return
+		}
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, c.maxBodyBytes))
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			respondTooLarge(w, r, tooLarge)
+			return
+		}
+		if err != nil {
+			respondProblem(w, r, http.StatusBadRequest, flights.CodeInvalidRequest, "failed to read request body")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		scope := ""
+		if p := principalFrom(r.Context()); p != nil {
+			scope = p.keyID
+		}
+		sum := sha256.Sum256(append([]byte(r.Method+" "+r.URL.RequestURI()+"\n"), body...))
+		fingerprint := hex.EncodeToString(sum[:])
+
+		entry, replay, status := c.begin(scope+"|"+key, fingerprint)
+		switch {
+		case status != 0:
+			if status == http.StatusConflict {
+				respondProblem(w, r, status, flights.CodeIdempotencyInProgress, "a request with this Idempotency-Key is still in progress")
+				return
+			}
+			respondProblem(w, r, status, flights.CodeIdempotencyKeyReused, "Idempotency-Key is already used for a different request")
+			return
+		case replay:
+			for name, values := range entry.header {
+				w.Header()[name] = values
+			}
+			w.Header().Set("Idempotent-Replayed", "true")
+			w.WriteHeader(entry.status)
+			_, _ = w.Write(entry.body)
+			return
+		}
+
+		rec := &responseRecorder{header: make(http.Header), status: http.StatusOK}
+		finished := false
+		defer func() {
+			if !finished {
+				// The handler panicked; free the key so the client can retry
+				// instead of getting 409 until it expires.
+				c.release(scope+"|"+key, entry)
+			}
+		}()
+		next.ServeHTTP(rec, r)
+		c.finish(scope+"|"+key, entry, rec)
+		finished = true
+		for name, values := range rec.header {
+			w.Header()[name] = values
+		}
+		w.WriteHeader(rec.status)
+		_, _ = w.Write(rec.body.Bytes())
+	})
+}
+
+// begin claims a key. It returns the stored entry and whether to replay it, or a
+// non-zero status when the key is in flight (409) or was used for a different
+// request (422).
+func (c *idempotencyCache) begin(key, fingerprint string) (*idempotencyEntry, bool, int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.dropExpired(c.now())
+	if entry, ok := c.entries[key]; ok {
+		switch {
+		case entry.fingerprint != fingerprint:
+			return nil, false, http.StatusUnprocessableEntity
+		case !entry.done:
+			return nil, false, http.StatusConflict
+		}
+		return entry, true, 0
+	}
+	entry := &idempotencyEntry{key: key, fingerprint: fingerprint}
+	c.entries[key] = entry
+	return entry, false, 0
+}
+
+// finish stores the response for replay. Server errors are not stored so the
+// client can retry them with the same key.
+func (c *idempotencyCache) finish(key string, entry *idempotencyEntry, rec *responseRecorder) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if rec.status >= http.StatusInternalServerError {
+		delete(c.entries, key)
+		return
+	}
+	entry.done = true
+	entry.expires = c.now().Add(c.ttl)
+	entry.status = rec.status
+	entry.header = rec.header.Clone()
+	entry.body = bytes.Clone(rec.body.Bytes())
+	c.expiry.PushBack(entry)
+}
+
+// dropExpired forgets the finished entries whose TTL has passed.
+func (c *idempotencyCache) dropExpired(now time.Time) {
+	for front := c.expiry.Front(); front != nil; front = c.expiry.Front() {
+		entry := front.Value.(*idempotencyEntry)
+		if !now.After(entry.expires) {
+			return
+		}
+		c.expiry.Remove(front)
+		if c.entries[entry.key] == entry {
+			delete(c.entries, entry.key)
+		}
+	}
+}
+
+// release drops the claim begin made when the request did not complete.
+func (c *idempotencyCache) release(key string, entry *idempotencyEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries[key] == entry {
+		delete(c.entries, key)
+	}
+}
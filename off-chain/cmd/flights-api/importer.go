@@ -0,0 +1,339 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"sum/internal/flights"
+)
+
+const (
+	formatNDJSON = "ndjson"
+	formatCSV    = "csv"
+
+	// maxImportBytes bounds bulk import bodies; a day's timetable is far smaller.
+	maxImportBytes = 16 << 20
+	// maxSnapshotBytes bounds snapshot restores, which also carry flight history.
+	maxSnapshotBytes = 64 << 20
+)
+
+// seedFile holds absolute seed data for the store.
+type seedFile struct {
+	Airlines []scenarioAirline `yaml:"airlines"`
+	Flights  []seedFlight      `yaml:"flights"`
+}
+
+type seedFlight struct {
+	AirlineID          string         `yaml:"airlineId"`
+	FlightID           string         `yaml:"flightId"`
+	DepartureTimestamp int64          `yaml:"departureTimestamp"`
+	Status             flights.Status `yaml:"status"`
+	AircraftID         string         `yaml:"aircraftId"`
+}
+
+// loadSeedFile reads airlines and flights from a JSON, YAML or CSV file. CSV
+// files list one flight per row; airlines are derived from the airlineId,
+// airlineName and airlineCode columns.
+func loadSeedFile(path string) ([]flights.Airline, []flights.Flight, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("open seed file: %w", err)
+	}
+	defer file.Close()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		rows, err := decodeCSVRows(file)
+		if err != nil {
+			return nil, nil, err
+		}
+		var airlines []flights.Airline
+		known := make(map[string]bool)
+		flightsList := make([]flights.Flight, 0, len(rows))
+		for _, row := range rows {
+			if row.err != nil {
+				return nil, nil, fmt.Errorf("row %d: %w", row.line, row.err)
+			}
+			if !known[row.flight.AirlineID] {
+				known[row.flight.AirlineID] = true
+				name := row.airlineName
+				if name == "" {
+					name = row.flight.AirlineID
+				}
+				airlines = append(airlines, flights.Airline{AirlineID: row.flight.AirlineID, Name: name, Code: row.airlineCode})
+			}
+			flightsList = append(flightsList, row.flight)
+		}
+		return airlines, flightsList, nil
+	case ".json", ".yaml", ".yml":
+		var seed seedFile
+		if err := yaml.NewDecoder(file).Decode(&seed); err != nil {
+			return nil, nil, fmt.Errorf("parse seed file: %w", err)
+		}
+		airlines := make([]flights.Airline, 0, len(seed.Airlines))
+		for _, airline := range seed.Airlines {
+			airlines = append(airlines, flights.Airline{AirlineID: airline.AirlineID, Name: airline.Name, Code: airline.Code, Signer: airline.Signer})
+		}
+		flightsList := make([]flights.Flight, 0, len(seed.Flights))
+		for _, f := range seed.Flights {
+			flightsList = append(flightsList, flights.Flight{
+				AirlineID:          f.AirlineID,
+				FlightID:           f.FlightID,
+				DepartureTimestamp: f.DepartureTimestamp,
+				Status:             f.Status,
+				AircraftID:         f.AircraftID,
+			})
+		}
+		return airlines, flightsList, nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported seed file extension %q", filepath.Ext(path))
+	}
+}
+
+func loadSnapshot(path string) (flights.Snapshot, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return flights.Snapshot{}, fmt.Errorf("open snapshot: %w", err)
+	}
+	defer file.Close()
+	snap, err := flights.ReadSnapshot(file)
+	if err != nil {
+		return flights.Snapshot{}, fmt.Errorf("read snapshot %s: %w", path, err)
+	}
+	return snap, nil
+}
+
+// importRow is a decoded schedule row, or the reason it could not be decoded.
+type importRow struct {
+	line        int
+	flight      flights.Flight
+	airlineName string
+	airlineCode string
+	err         error
+}
+
+func decodeNDJSONRows(r io.Reader) ([]importRow, error) {
+	var rows []importRow
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		row := importRow{line: line}
+		decoder := json.NewDecoder(strings.NewReader(text))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&row.flight); err != nil {
+			row.err = fmt.Errorf("invalid JSON: %w", err)
+		} else {
+			row.err = validateImportedFlight(row.flight)
+		}
+		rows = append(rows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read NDJSON: %w", err)
+	}
+	return rows, nil
+}
+
+// decodeCSVRows parses a CSV schedule with a header row. Required columns are
+// airlineId, flightId and departureTimestamp (unix seconds or RFC 3339); status,
+// aircraftId, airlineName and airlineCode are optional.
+func decodeCSVRows(r io.Reader) ([]importRow, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read CSV header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.TrimSpace(name)] = i
+	}
+	for _, name := range []string{"airlineId", "flightId", "departureTimestamp"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("CSV header is missing column %q", name)
+		}
+	}
+	field := func(record []string, name string) string {
+		idx, ok := columns[name]
+		if !ok || idx >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[idx])
+	}
+
+	var rows []importRow
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		row := importRow{line: line}
+		if err != nil {
+			row.err = err
+			rows = append(rows, row)
+			continue
+		}
+		row.flight = flights.Flight{
+			AirlineID:  field(record, "airlineId"),
+			FlightID:   field(record, "flightId"),
+			Status:     flights.Status(strings.ToUpper(field(record, "status"))),
+			AircraftID: field(record, "aircraftId"),
+		}
+		row.airlineName = field(record, "airlineName")
+		row.airlineCode = field(record, "airlineCode")
+		row.flight.DepartureTimestamp, row.err = parseTimestamp(field(record, "departureTimestamp"))
+		if row.err == nil {
+			row.err = validateImportedFlight(row.flight)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func parseTimestamp(value string) (int64, error) {
+	if ts, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return ts, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return 0, fmt.Errorf("departureTimestamp must be unix seconds or RFC 3339, got %q", value)
+	}
+	return t.Unix(), nil
+}
+
+// validateImportedFlight applies the request-level checks of handleCreateFlight;
+// store-level validation happens in flights.Store.ImportFlights.
+func validateImportedFlight(flight flights.Flight) error {
+	if flight.AirlineID == "" || flight.FlightID == "" || flight.DepartureTimestamp <= 0 {
+		return errors.New("airlineId, flightId and departureTimestamp are required")
+	}
+	return nil
+}
+
+// rejectedRow is a failing row of an import, reported as a field error on
+// rows[n] (1-based, in input order).
+type rejectedRow struct {
+	row int
+	err flights.FieldError
+}
+
+func newRejectedRow(row, line int, airlineID, flightID string, err error) rejectedRow {
+	code := flights.CodeOf(err)
+	if code == flights.CodeInternal {
+		// Decoding errors have no sentinel; they are still the client's fault.
+		code = flights.CodeInvalidValue
+	}
+	return rejectedRow{row: row, err: flights.FieldError{
+		Field:   fmt.Sprintf("rows[%d]", row),
+		Code:    code,
+		Message: fmt.Sprintf("line %d (%s/%s): %v", line, airlineID, flightID, err),
+	}}
+}
+
+// handleImportFlights bulk-creates flights for any number of airlines from an
+// NDJSON (application/x-ndjson) or CSV (text/csv) body. The import is
+// all-or-nothing: if any row fails, nothing is stored and every failing row is
+// reported.
+func (s *flightServer) handleImportFlights(w http.ResponseWriter, r *http.Request) {
+	format := formatNDJSON
+	if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err == nil && mediaType == "text/csv" {
+		format = formatCSV
+	}
+	body := http.MaxBytesReader(w, r.Body, maxImportBytes)
+	var (
+		rows []importRow
+		err  error
+	)
+	if format == formatCSV {
+		rows, err = decodeCSVRows(body)
+	} else {
+		rows, err = decodeNDJSONRows(body)
+	}
+	if err != nil {
+		respondProblem(w, r, http.StatusBadRequest, flights.CodeInvalidRequest, err.Error())
+		return
+	}
+	if len(rows) == 0 {
+		respondProblem(w, r, http.StatusBadRequest, flights.CodeInvalidRequest, "no rows to import")
+		return
+	}
+
+	authorized := make(map[string]bool)
+	for _, row := range rows {
+		airlineID := row.flight.AirlineID
+		if row.err != nil || authorized[airlineID] {
+			continue
+		}
+		if !s.authorizeAirline(w, r, airlineID) {
+			return
+		}
+		authorized[airlineID] = true
+	}
+
+	// Rows that decoded cleanly still go through the store so that every failing
+	// row is reported in one response; the store is only written when all pass.
+	var rejected []rejectedRow
+	items := make([]flights.Flight, 0, len(rows))
+	indexes := make([]int, 0, len(rows))
+	for i, row := range rows {
+		if row.err != nil {
+			rejected = append(rejected, newRejectedRow(i+1, row.line, row.flight.AirlineID, row.flight.FlightID, row.err))
+			continue
+		}
+		items = append(items, row.flight)
+		indexes = append(indexes, i)
+	}
+	if len(rejected) == 0 {
+		created, err := s.store.ImportFlights(items)
+		if err == nil {
+			writeJSON(w, http.StatusCreated, flights.ImportResponse{Imported: len(created), Flights: created})
+			return
+		}
+		if !s.appendImportErrors(w, r, err, rows, indexes, &rejected) {
+			return
+		}
+	} else if len(items) > 0 {
+		if err := s.store.CheckImport(items); err != nil && !s.appendImportErrors(w, r, err, rows, indexes, &rejected) {
+			return
+		}
+	}
+	sort.Slice(rejected, func(i, j int) bool { return rejected[i].row < rejected[j].row })
+	fieldErrors := make([]flights.FieldError, 0, len(rejected))
+	for _, row := range rejected {
+		fieldErrors = append(fieldErrors, row.err)
+	}
+	respondProblem(w, r, http.StatusUnprocessableEntity, flights.CodeImportRejected, fmt.Sprintf("%d of %d rows were rejected; nothing was imported", len(rejected), len(rows)), fieldErrors...)
+}
+
+// appendImportErrors maps store row errors back to request rows. It writes the
+// response itself and returns false when err is not an import error.
+func (s *flightServer) appendImportErrors(w http.ResponseWriter, r *http.Request, err error, rows []importRow, indexes []int, rejected *[]rejectedRow) bool {
+	var importErr *flights.ImportError
+	if !errors.As(err, &importErr) {
+		respondStoreError(w, r, err)
+		return false
+	}
+	for _, rowErr := range importErr.Rows {
+		idx := indexes[rowErr.Row-1]
+		*rejected = append(*rejected, newRejectedRow(idx+1, rows[idx].line, rowErr.AirlineID, rowErr.FlightID, rowErr.Err))
+	}
+	return true
+}
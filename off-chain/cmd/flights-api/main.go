@@ -5,14 +5,12 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"log/slog"
-	"math/rand"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
-	"strconv"
-	"strings"
-	"sync"
 	"syscall"
 	"time"
 
@@ -21,13 +19,55 @@ import (
 	"github.com/spf13/cobra"
 
 	"sum/internal/flights"
+	"sum/internal/settings"
+	"sum/internal/tracing"
 )
 
 type config struct {
-	listenAddr string
+	listenAddr   string
+	grpcAddr     string
+	scenarioPath string
+	seed         int64
+	mockClock    bool
+	clockStart   int64
+	clockFrozen  bool
+	faults       string
+	seedFile     string
+	snapshot     string
+	snapshotMode string
+	authFile     string
+	airlineKeys  string
+	idemTTL      time.Duration
+	rateLimit    string
+	logLevel     string
+	tracing      tracing.Options
 }
 
-var cfg config
+var (
+	cfg config
+	// loader layers the config file and environment under the flags.
+	loader *settings.Loader
+)
+
+// validate checks settings the flag types cannot.
+func (c *config) validate() error {
+	if _, err := settings.ParseLogLevel(c.logLevel); err != nil {
+		return fmt.Errorf("--log-level: %w", err)
+	}
+	if mode := flights.RestoreMode(c.snapshotMode); mode != flights.RestoreReplace && mode != flights.RestoreMerge {
+		return fmt.Errorf("--snapshot-mode must be %s or %s, got %q", flights.RestoreReplace, flights.RestoreMerge, c.snapshotMode)
+	}
+	if _, err := parseFaultSpec(c.faults); err != nil {
+		return fmt.Errorf("parse --faults: %w", err)
+	}
+	if _, err := parseRateLimitSpec(c.rateLimit); err != nil {
+		return fmt.Errorf("parse --rate-limit: %w", err)
+	}
+	if c.tracing.SampleRatio < 0 || c.tracing.SampleRatio > 1 {
+		return fmt.Errorf("--trace-sample-ratio must be between 0 and 1, got %v", c.tracing.SampleRatio)
+	}
+	return nil
+}
 
 var rootCmd = &cobra.Command{
 	Use:           "flights-api",
@@ -35,13 +75,154 @@ var rootCmd = &cobra.Command{
 	SilenceUsage:  true,
 	SilenceErrors: true,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := loader.Load(); err != nil {
+			return err
+		}
+		if loader.PrintRequested() {
+			return loader.Print(os.Stdout)
+		}
+		level, _ := settings.ParseLogLevel(cfg.logLevel)
+		slog.SetLogLoggerLevel(level)
+
 		ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 		defer cancel()
+		go reloadOnHangup(ctx)
+
+		shutdownTracing, err := tracing.Setup(ctx, "flights-api", cfg.tracing)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := shutdownTracing(shutdownCtx); err != nil {
+				slog.Error("Failed to flush traces", "error", err)
+			}
+		}()
 
-		store := flights.NewStore(seedAirlines(), seedFlights())
-		generator := newFlightGenerator(store)
-		generator.start(ctx)
+		var snap *flights.Snapshot
+		if cfg.snapshot != "" {
+			loaded, err := loadSnapshot(cfg.snapshot)
+			if err != nil {
+				return err
+			}
+			snap = &loaded
+		}
+
+		var clock flights.Clock = flights.SystemClock()
+		var mockClock *flights.MockClock
+		if cfg.mockClock {
+			clockStart := time.Now()
+			switch {
+			case cfg.clockStart > 0:
+				clockStart = time.Unix(cfg.clockStart, 0)
+			case snap != nil:
+				// Resume from the moment the snapshot was taken.
+				clockStart = time.Unix(snap.TakenAt, 0)
+			}
+			mockClock = flights.NewMockClock(clockStart, !cfg.clockFrozen)
+			clock = mockClock
+			slog.Info("Mock clock enabled", "start", clockStart.Unix(), "frozen", cfg.clockFrozen)
+		}
+
+		start := clock.Now()
+		sc := defaultScenario()
+		if cfg.scenarioPath != "" {
+			loaded, err := loadScenario(cfg.scenarioPath)
+			if err != nil {
+				return err
+			}
+			sc = loaded
+		}
+		seed := start.UnixNano()
+		switch {
+		case cmd.Flags().Changed("seed"):
+			seed = cfg.seed
+		case sc.Seed != nil:
+			seed = *sc.Seed
+		}
+
+		airlines, seeded := sc.seedData(start)
+		if cfg.seedFile != "" {
+			fileAirlines, fileFlights, err := loadSeedFile(cfg.seedFile)
+			if err != nil {
+				return err
+			}
+			airlines, seeded = fileAirlines, fileFlights
+			slog.Info("Loaded seed file", "path", cfg.seedFile, "airlines", len(airlines), "flights", len(seeded))
+		}
+		var keyRing flights.KeyRing
+		if cfg.airlineKeys != "" {
+			loaded, err := loadAirlineKeys(cfg.airlineKeys)
+			if err != nil {
+				return err
+			}
+			keyRing = loaded
+			if err := assignAirlineSigners(airlines, keyRing); err != nil {
+				return err
+			}
+			slog.Warn("Signing flight attestations with airline keys held by the API", "airlines", len(keyRing))
+		}
+		store := flights.NewStoreWithClock(clock, airlines, nil)
+		if keyRing != nil {
+			store.SetAttester(keyRing)
+		}
+		if _, err := store.ImportFlights(seeded); err != nil {
+			return fmt.Errorf("seed flights: %w", err)
+		}
+		if snap != nil {
+			if err := store.Restore(*snap, flights.RestoreMode(cfg.snapshotMode)); err != nil {
+				return fmt.Errorf("restore snapshot: %w", err)
+			}
+			slog.Info("Restored snapshot", "path", cfg.snapshot, "mode", cfg.snapshotMode, "takenAt", snap.TakenAt, "airlines", len(snap.Airlines), "flights", len(snap.Flights))
+		}
+		faultCfg, err := parseFaultSpec(cfg.faults)
+		if err != nil {
+			return fmt.Errorf("parse --faults: %w", err)
+		}
 		srv := newFlightServer(store)
+		srv.clock = mockClock
+		srv.faults = newFaultInjector(faultCfg, seed)
+		srv.idempotency = newIdempotencyCache(cfg.idemTTL)
+		if cfg.authFile != "" {
+			authCfg, err := loadAuthConfig(cfg.authFile)
+			if err != nil {
+				return err
+			}
+			if srv.auth, err = newAuthenticator(authCfg); err != nil {
+				return fmt.Errorf("auth file %s: %w", cfg.authFile, err)
+			}
+			slog.Info("API key authentication enabled", "keys", len(authCfg.Keys), "anonymousReads", authCfg.AnonymousReads)
+		}
+		limits, err := parseRateLimitSpec(cfg.rateLimit)
+		if err != nil {
+			return fmt.Errorf("parse --rate-limit: %w", err)
+		}
+		srv.limiter = newRateLimiter(limits)
+		if len(limits) > 0 {
+			slog.Info("Rate limiting enabled", "reads", limits[classReads], "writes", limits[classWrites], "admin", limits[classAdmin])
+		}
+		if faultCfg.enabled() {
+			slog.Warn("Fault injection enabled", "config", faultCfg)
+		}
+		if sc.Rules != nil {
+			rules := sc.Rules.withDefaults()
+			if err := rules.validate(); err != nil {
+				return err
+			}
+			generator, err := newFlightGenerator(store, rules, seed)
+			if err != nil {
+				return fmt.Errorf("create flight generator: %w", err)
+			}
+			generator.start(ctx)
+			srv.generator = generator
+			srv.onClockChange = generator.advanceFlights
+			slog.Info("Flight generator started", "seed", seed, "createInterval", rules.CreateInterval, "updateInterval", rules.UpdateInterval, "delayProbability", rules.DelayProbability)
+		}
+		if len(sc.Timeline) > 0 {
+			newScenarioRunner(store, start, sc.Timeline).start(ctx)
+			slog.Info("Scenario timeline started", "path", cfg.scenarioPath, "events", len(sc.Timeline))
+		}
 
 		httpServer := &http.Server{
 			Addr:              cfg.listenAddr,
@@ -62,8 +243,37 @@ var rootCmd = &cobra.Command{
 			close(done)
 		}()
 
+		if cfg.grpcAddr != "" {
+			lis, err := net.Listen("tcp", cfg.grpcAddr)
+			if err != nil {
+				return fmt.Errorf("listen gRPC: %w", err)
+			}
+			grpcServer := newGRPCServer(store, srv.auth, srv.limiter)
+			go func() {
+				<-ctx.Done()
+				// Watch streams only end with the client, so bound the graceful stop
+				// like the HTTP shutdown.
+				stopped := make(chan struct{})
+				go func() {
+					grpcServer.GracefulStop()
+					close(stopped)
+				}()
+				select {
+				case <-stopped:
+				case <-time.After(5 * time.Second):
+					grpcServer.Stop()
+				}
+			}()
+			go func() {
+				if err := grpcServer.Serve(lis); err != nil {
+					slog.Error("gRPC server stopped", "error", err)
+				}
+			}()
+			slog.Info("Flights gRPC service listening", "addr", cfg.grpcAddr)
+		}
+
 		slog.Info("Flights API listening", "addr", cfg.listenAddr)
-		err := httpServer.ListenAndServe()
+		err = httpServer.ListenAndServe()
 		if err != nil && !errors.Is(err, http.ErrServerClosed) {
 			return err
 		}
@@ -73,7 +283,26 @@ var rootCmd = &cobra.Command{
 }
 
 func main() {
+	loader = settings.New(rootCmd.PersistentFlags(), "FLIGHTS_API")
 	rootCmd.PersistentFlags().StringVar(&cfg.listenAddr, "listen", ":8085", "HTTP listen address")
+	rootCmd.PersistentFlags().StringVar(&cfg.grpcAddr, "grpc-listen", "", "gRPC listen address for FlightsService, e.g. :8086 (disabled when empty)")
+	rootCmd.PersistentFlags().StringVar(&cfg.scenarioPath, "scenario", "", "Path to a YAML/JSON scenario file describing seed data, a timeline and generator rules")
+	rootCmd.PersistentFlags().BoolVar(&cfg.mockClock, "mock-clock", false, "Use a controllable clock exposed through the /admin/clock endpoints")
+	rootCmd.PersistentFlags().Int64Var(&cfg.clockStart, "mock-clock-start", 0, "Initial unix timestamp of the mock clock (defaults to now)")
+	rootCmd.PersistentFlags().BoolVar(&cfg.clockFrozen, "mock-clock-frozen", false, "Keep the mock clock still between admin updates instead of following wall time")
+	rootCmd.PersistentFlags().StringVar(&cfg.seedFile, "seed-file", "", "Path to a JSON/YAML/CSV file with airlines and flights replacing the built-in or scenario seed data")
+	rootCmd.PersistentFlags().StringVar(&cfg.snapshot, "snapshot", "", "Path to a snapshot taken from GET /admin/snapshot to restore at startup")
+	rootCmd.PersistentFlags().StringVar(&cfg.snapshotMode, "snapshot-mode", string(flights.RestoreReplace), "How to restore --snapshot: replace the seed data or merge into it")
+	rootCmd.PersistentFlags().StringVar(&cfg.airlineKeys, "airline-keys", "", "Path to a YAML/JSON map of airlineId to ECDSA private key used to sign attestations on the airlines' behalf (local networks only)")
+	rootCmd.PersistentFlags().DurationVar(&cfg.idemTTL, "idempotency-ttl", defaultIdempotencyTTL, "How long responses to POSTs with an Idempotency-Key are kept for replay")
+	rootCmd.PersistentFlags().StringVar(&cfg.authFile, "auth-file", "", "Path to a YAML/JSON file with API keys; when unset the API accepts unauthenticated writes")
+	rootCmd.PersistentFlags().StringVar(&cfg.rateLimit, "rate-limit", "", "Token-bucket limits per API key or client IP and route class, e.g. reads=20/s,writes=60/m,admin=10/m (unlimited when empty)")
+	rootCmd.PersistentFlags().StringVar(&cfg.faults, "faults", "", "Fault injection spec, e.g. latency=200ms,jitter=50ms,error=0.1,throttle=0.05,truncate=0.02,malformed=0.02,stale=0.1,flap=0.05,per-client=true,routes=/airlines")
+	cfg.tracing.RegisterFlags(rootCmd.PersistentFlags())
+	rootCmd.PersistentFlags().Int64Var(&cfg.seed, "seed", 0, "Random seed for the flight generator (defaults to the scenario seed or the current time)")
+	rootCmd.PersistentFlags().StringVar(&cfg.logLevel, "log-level", "info", "Log level (debug,info,warn,error)")
+	loader.Reloadable("log-level")
+	loader.Validate = cfg.validate
 
 	if err := rootCmd.Execute(); err != nil {
 		if !errors.Is(err, context.Canceled) {
@@ -83,109 +312,229 @@ func main() {
 	}
 }
 
+// reloadOnHangup re-reads the config file and environment on SIGHUP until ctx
+// is done.
+func reloadOnHangup(ctx context.Context) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+	for {
+		select {
+		case <-hangup:
+			changed, err := loader.Reload()
+			if err != nil {
+				slog.Error("Reload config failed; keeping the current settings", "error", err)
+				continue
+			}
+			level, _ := settings.ParseLogLevel(cfg.logLevel)
+			slog.SetLogLoggerLevel(level)
+			slog.Info("Reloaded config", "changed", changed)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
 type flightServer struct {
 	store *flights.Store
+	// clock is set when the API runs on a mock clock that admins may move.
+	clock *flights.MockClock
+	// onClockChange runs after the mock clock moved so due transitions apply immediately.
+	onClockChange func()
+	// generator is set when stochastic flight generation runs and can be steered by admins.
+	generator *flightGenerator
+	// faults degrades responses on demand to exercise oracle node error handling.
+	faults *faultInjector
+	// auth is set when --auth-file enables API keys; nil leaves the API open.
+	auth *authenticator
+	// idempotency replays responses to POSTs retried with the same Idempotency-Key.
+	idempotency *idempotencyCache
+	// limiter throttles clients and counts their requests per route class.
+	limiter *rateLimiter
 }
 
 func newFlightServer(store *flights.Store) *flightServer {
-	return &flightServer{store: store}
+	return &flightServer{
+		store:       store,
+		faults:      newFaultInjector(faultConfig{}, 0),
+		idempotency: newIdempotencyCache(defaultIdempotencyTTL),
+		limiter:     newRateLimiter(nil),
+	}
 }
 
 func (s *flightServer) routes() http.Handler {
 	r := chi.NewRouter()
+	r.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		respondProblem(w, r, http.StatusNotFound, flights.CodeNotFound, "no route for "+r.URL.Path)
+	})
+	r.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+		respondProblem(w, r, http.StatusMethodNotAllowed, flights.CodeMethodNotAllowed, r.Method+" is not allowed on "+r.URL.Path)
+	})
+	r.Use(middleware.RequestID)
 	r.Use(middleware.RealIP)
+	r.Use(traceRequests)
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
 	r.Use(corsMiddleware)
+	if s.auth != nil {
+		r.Use(s.auth.middleware)
+	}
+	r.Use(s.limiter.middleware)
+	r.Use(s.faults.middleware)
+	r.Use(s.idempotency.middleware)
 	r.Get("/healthz", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+	r.Get("/openapi.json", handleOpenAPI)
 	r.Get("/airlines", s.handleListAirlines)
-	r.Post("/airlines", s.handleCreateAirline)
+	r.With(s.requireAdmin).Post("/airlines", s.handleCreateAirline)
+	r.Post("/flights/import", s.handleImportFlights)
 	r.Get("/airlines/{airlineId}/flights", s.handleListFlights)
-	r.Post("/airlines/{airlineId}/flights", s.handleCreateFlight)
-	r.Post("/airlines/{airlineId}/flights/{flightId}/delay", s.handleUpdateStatus(flights.StatusDelayed))
-	r.Post("/airlines/{airlineId}/flights/{flightId}/depart", s.handleUpdateStatus(flights.StatusDeparted))
+	r.Get("/airlines/{airlineId}/flights/{flightId}", s.handleGetFlight)
+	r.With(s.requireAirlineWrite).Post("/airlines/{airlineId}/flights", s.handleCreateFlight)
+	r.With(s.requireAirlineWrite).Post("/airlines/{airlineId}/flights/{flightId}/delay", s.handleUpdateStatus(flights.StatusDelayed))
+	r.With(s.requireAirlineWrite).Post("/airlines/{airlineId}/flights/{flightId}/depart", s.handleUpdateStatus(flights.StatusDeparted))
+	r.Route("/admin", s.adminRoutes)
 	return r
 }
 
 func (s *flightServer) handleListAirlines(w http.ResponseWriter, r *http.Request) {
-	writeJSON(w, http.StatusOK, map[string]any{"airlines": s.store.ListAirlines()})
-}
-
-type createAirlineRequest struct {
-	AirlineID string `json:"airlineId"`
-	Name      string `json:"name"`
-	Code      string `json:"code"`
+	writeJSONHashed(w, r, flights.AirlinesResponse{Airlines: s.store.ListAirlines()})
 }
 
 func (s *flightServer) handleCreateAirline(w http.ResponseWriter, r *http.Request) {
-	var body createAirlineRequest
+	var body flights.CreateAirlineRequest
 	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-		respondError(w, http.StatusBadRequest, "invalid JSON body")
+		respondProblem(w, r, http.StatusBadRequest, flights.CodeInvalidRequest, "invalid JSON body")
 		return
 	}
-	if body.AirlineID == "" || body.Name == "" {
-		respondError(w, http.StatusBadRequest, "airlineId and name are required")
+	var invalid []flights.FieldError
+	if body.AirlineID == "" {
+		invalid = append(invalid, requiredField("airlineId"))
+	}
+	if body.Name == "" {
+		invalid = append(invalid, requiredField("name"))
+	}
+	if len(invalid) > 0 {
+		respondValidation(w, r, invalid...)
 		return
 	}
-	if err := s.store.AddAirline(flights.Airline{AirlineID: body.AirlineID, Name: body.Name, Code: body.Code}); err != nil {
-		status, msg := mapStoreError(err)
-		respondError(w, status, msg)
+	airline := flights.Airline{AirlineID: body.AirlineID, Name: body.Name, Code: body.Code, Signer: body.Signer}
+	if err := s.store.AddAirline(airline); err != nil {
+		respondStoreError(w, r, err)
 		return
 	}
-	writeJSON(w, http.StatusCreated, map[string]any{"airline": flights.Airline{AirlineID: body.AirlineID, Name: body.Name, Code: body.Code}})
+	writeJSON(w, http.StatusCreated, flights.AirlineResponse{Airline: airline})
 }
 
 func (s *flightServer) handleListFlights(w http.ResponseWriter, r *http.Request) {
 	airlineID := chi.URLParam(r, "airlineId")
 	flightsList, err := s.store.ListFlights(airlineID)
 	if err != nil {
-		status, msg := mapStoreError(err)
-		respondError(w, status, msg)
+		respondStoreError(w, r, err)
 		return
 	}
-	writeJSON(w, http.StatusOK, map[string]any{"flights": flightsList})
+	writeJSONHashed(w, r, flights.FlightsResponse{Flights: flightsList})
 }
 
-type createFlightRequest struct {
-	FlightID           string `json:"flightId"`
-	DepartureTimestamp int64  `json:"departureTimestamp"`
+func (s *flightServer) handleGetFlight(w http.ResponseWriter, r *http.Request) {
+	flight, err := s.store.GetFlight(chi.URLParam(r, "airlineId"), chi.URLParam(r, "flightId"))
+	if err != nil {
+		respondStoreError(w, r, err)
+		return
+	}
+	writeJSONConditional(w, r, flightETag(flight), flights.FlightResponse{Flight: flight})
 }
 
 func (s *flightServer) handleCreateFlight(w http.ResponseWriter, r *http.Request) {
 	airlineID := chi.URLParam(r, "airlineId")
-	var body createFlightRequest
+	var body flights.CreateFlightRequest
 	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-		respondError(w, http.StatusBadRequest, "invalid JSON body")
+		respondProblem(w, r, http.StatusBadRequest, flights.CodeInvalidRequest, "invalid JSON body")
 		return
 	}
-	if body.FlightID == "" || body.DepartureTimestamp <= 0 {
-		respondError(w, http.StatusBadRequest, "flightId and departureTimestamp are required")
+	if invalid := validateCreateFlight(body); len(invalid) > 0 {
+		respondValidation(w, r, invalid...)
 		return
 	}
-	flight := flights.Flight{AirlineID: airlineID, FlightID: body.FlightID, DepartureTimestamp: body.DepartureTimestamp, Status: flights.StatusScheduled}
-	created, err := s.store.CreateFlight(airlineID, flight)
+	created, err := traceStatusChange(r.Context(), airlineID, body.FlightID, flights.StatusScheduled, func(traceParent string) (flights.Flight, error) {
+		flight := newFlight(airlineID, body)
+		flight.TraceParent = traceParent
+		return s.store.CreateFlight(airlineID, flight)
+	})
 	if err != nil {
-		status, msg := mapStoreError(err)
-		respondError(w, status, msg)
+		respondStoreError(w, r, err)
 		return
 	}
-	writeJSON(w, http.StatusCreated, map[string]any{"flight": created})
+	w.Header().Set("ETag", flightETag(created))
+	writeJSON(w, http.StatusCreated, flights.FlightResponse{Flight: created})
+}
+
+// validateCreateFlight checks a create request for both the REST and gRPC APIs.
+func validateCreateFlight(body flights.CreateFlightRequest) []flights.FieldError {
+	var invalid []flights.FieldError
+	if body.FlightID == "" {
+		invalid = append(invalid, requiredField("flightId"))
+	}
+	switch {
+	case body.DepartureTimestamp == 0:
+		invalid = append(invalid, requiredField("departureTimestamp"))
+	case body.DepartureTimestamp < 0:
+		invalid = append(invalid, flights.FieldError{Field: "departureTimestamp", Code: flights.CodeInvalidValue, Message: "must be a positive unix timestamp"})
+	}
+	if body.Signature != "" && body.UpdatedAt <= 0 {
+		invalid = append(invalid, flights.FieldError{Field: "updatedAt", Code: flights.CodeRequired, Message: "is required with a signature"})
+	}
+	return invalid
+}
+
+func newFlight(airlineID string, body flights.CreateFlightRequest) flights.Flight {
+	flight := flights.Flight{AirlineID: airlineID, FlightID: body.FlightID, DepartureTimestamp: body.DepartureTimestamp, Status: flights.StatusScheduled, AircraftID: body.AircraftID}
+	if body.Signature != "" {
+		flight.UpdatedAt, flight.Signature = body.UpdatedAt, body.Signature
+	}
+	return flight
 }
 
 func (s *flightServer) handleUpdateStatus(status flights.Status) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		airlineID := chi.URLParam(r, "airlineId")
 		flightID := chi.URLParam(r, "flightId")
-		updated, err := s.store.UpdateStatus(airlineID, flightID, status)
+		var body flights.StatusUpdateRequest
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+				respondProblem(w, r, http.StatusBadRequest, flights.CodeInvalidRequest, "invalid JSON body")
+				return
+			}
+		}
+		ifVersion, err := parseIfMatch(r)
+		if err != nil {
+			respondProblem(w, r, http.StatusPreconditionFailed, flights.CodeVersionMismatch, err.Error())
+			return
+		}
+		updated, err := traceStatusChange(r.Context(), airlineID, flightID, status, func(traceParent string) (flights.Flight, error) {
+			return s.store.ApplyStatusUpdate(airlineID, flightID, flights.StatusUpdate{
+				Status:      status,
+				UpdatedAt:   body.UpdatedAt,
+				Signature:   body.Signature,
+				IfVersion:   ifVersion,
+				TraceParent: traceParent,
+			})
+		})
 		if err != nil {
-			statusCode, msg := mapStoreError(err)
-			respondError(w, statusCode, msg)
+			respondStoreError(w, r, err)
 			return
 		}
-		writeJSON(w, http.StatusOK, map[string]any{"flight": updated})
+		w.Header().Set("ETag", flightETag(updated))
+		writeJSON(w, http.StatusOK, flights.FlightResponse{Flight: updated})
 	}
 }
 
+// handleOpenAPI serves the API description that the node client and tests are
+// checked against.
+func handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(flights.OpenAPISpec)
+}
+
 func writeJSON(w http.ResponseWriter, status int, payload any) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
@@ -197,26 +546,64 @@ func writeJSON(w http.ResponseWriter, status int, payload any) {
 	}
 }
 
-func respondError(w http.ResponseWriter, status int, message string) {
-	writeJSON(w, status, map[string]any{"error": message})
+// respondProblem writes an RFC 7807 problem document carrying a stable code and
+// the request ID.
+func respondProblem(w http.ResponseWriter, r *http.Request, status int, code flights.ErrorCode, detail string, fieldErrors ...flights.FieldError) {
+	problem := flights.Problem{
+		Type:      flights.ProblemType(code),
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    detail,
+		Instance:  r.URL.Path,
+		Code:      code,
+		RequestID: middleware.GetReqID(r.Context()),
+		Errors:    fieldErrors,
+	}
+	w.Header().Set("Content-Type", flights.ProblemContentType)
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(problem); err != nil {
+		slog.Error("failed to encode problem", "error", err)
+	}
+}
+
+// respondValidation reports field-level problems with a request body.
+func respondValidation(w http.ResponseWriter, r *http.Request, fieldErrors ...flights.FieldError) {
+	respondProblem(w, r, http.StatusBadRequest, flights.CodeValidationFailed, "request failed validation", fieldErrors...)
 }
 
-func mapStoreError(err error) (int, string) {
-	switch {
-	case errors.Is(err, flights.ErrAirlineNotFound):
-		return http.StatusNotFound, err.Error()
-	case errors.Is(err, flights.ErrInvalidAirline), errors.Is(err, flights.ErrInvalidFlight):
-		return http.StatusBadRequest, err.Error()
-	case errors.Is(err, flights.ErrAirlineExists):
-		return http.StatusConflict, err.Error()
-	case errors.Is(err, flights.ErrFlightExists):
-		return http.StatusConflict, err.Error()
-	case errors.Is(err, flights.ErrFlightNotFound):
-		return http.StatusNotFound, err.Error()
-	case errors.Is(err, flights.ErrInvalidStatus), errors.Is(err, flights.ErrInvalidStatusTransition):
-		return http.StatusBadRequest, err.Error()
+// respondTooLarge reports a body cut off by http.MaxBytesReader.
+func respondTooLarge(w http.ResponseWriter, r *http.Request, err *http.MaxBytesError) {
+	respondProblem(w, r, http.StatusRequestEntityTooLarge, flights.CodePayloadTooLarge, fmt.Sprintf("request body exceeds %d bytes", err.Limit))
+}
+
+func requiredField(field string) flights.FieldError {
+	return flights.FieldError{Field: field, Code: flights.CodeRequired, Message: "is required"}
+}
+
+// respondStoreError reports a store error under its code. Errors without a code
+// are logged and hidden behind a generic 500.
+func respondStoreError(w http.ResponseWriter, r *http.Request, err error) {
+	code := flights.CodeOf(err)
+	if code == flights.CodeInternal {
+		slog.Error("request failed", "requestId", middleware.GetReqID(r.Context()), "path", r.URL.Path, "error", err)
+		respondProblem(w, r, http.StatusInternalServerError, code, "internal server error")
+		return
+	}
+	respondProblem(w, r, statusForCode(code), code, err.Error())
+}
+
+func statusForCode(code flights.ErrorCode) int {
+	switch code {
+	case flights.CodeAirlineNotFound, flights.CodeFlightNotFound, flights.CodeNotFound:
+		return http.StatusNotFound
+	case flights.CodeAirlineExists, flights.CodeFlightExists:
+		return http.StatusConflict
+	case flights.CodeVersionMismatch:
+		return http.StatusPreconditionFailed
+	case flights.CodeInternal:
+		return http.StatusInternalServerError
 	default:
-		return http.StatusInternalServerError, "internal server error"
+		return http.StatusBadRequest
 	}
 }
 
@@ -228,8 +615,8 @@ func seedAirlines() []flights.Airline {
 	}
 }
 
-func seedFlights() []flights.Flight {
-	now := time.Now().Unix()
+func seedFlights(start time.Time) []flights.Flight {
+	now := start.Unix()
 	toSeconds := func(d time.Duration) int64 { return int64(d.Seconds()) }
 	return []flights.Flight{
 		{AirlineID: "ALPHA", FlightID: "ALPHA-001", DepartureTimestamp: now + toSeconds(1*time.Minute), Status: flights.StatusScheduled},
@@ -239,165 +626,12 @@ func seedFlights() []flights.Flight {
 	}
 }
 
-type flightGenerator struct {
-	store          *flights.Store
-	rand           *rand.Rand
-	counters       map[string]int
-	createInterval time.Duration
-	updateInterval time.Duration
-	mu             sync.Mutex
-}
-
-func newFlightGenerator(store *flights.Store) *flightGenerator {
-	gen := &flightGenerator{
-		store:          store,
-		rand:           rand.New(rand.NewSource(time.Now().UnixNano())),
-		counters:       make(map[string]int),
-		createInterval: 45 * time.Second,
-		updateInterval: 15 * time.Second,
-	}
-	gen.bootstrapCounters()
-	return gen
-}
-
-func (g *flightGenerator) start(ctx context.Context) {
-	createTicker := time.NewTicker(g.createInterval)
-	updateTicker := time.NewTicker(g.updateInterval)
-	go func() {
-		defer createTicker.Stop()
-		defer updateTicker.Stop()
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case <-createTicker.C:
-				g.maybeCreateFlights()
-			case <-updateTicker.C:
-				g.advanceFlights()
-			}
-		}
-	}()
-}
-
-func (g *flightGenerator) bootstrapCounters() {
-	airlines := g.store.ListAirlines()
-	for _, airline := range airlines {
-		flightsList, err := g.store.ListFlights(airline.AirlineID)
-		if err != nil {
-			continue
-		}
-		maxNum := 0
-		for _, f := range flightsList {
-			if n := parseFlightNumber(f.FlightID); n > maxNum {
-				maxNum = n
-			}
-		}
-		g.counters[airline.AirlineID] = maxNum
-	}
-}
-
-func (g *flightGenerator) maybeCreateFlights() {
-	airlines := g.store.ListAirlines()
-	now := time.Now().Unix()
-	for _, airline := range airlines {
-		flightsList, err := g.store.ListFlights(airline.AirlineID)
-		if err != nil {
-			continue
-		}
-		scheduled := 0
-		for _, f := range flightsList {
-			if f.Status == flights.StatusScheduled && f.DepartureTimestamp > now {
-				scheduled++
-			}
-		}
-		if scheduled >= 3 {
-			continue
-		}
-		flightID := g.nextFlightID(airline.AirlineID)
-		minutesAhead := time.Duration(g.rand.Intn(4)+1) * time.Minute
-		departure := now + int64(minutesAhead.Seconds())
-		latest := now
-		for _, f := range flightsList {
-			if f.DepartureTimestamp > latest {
-				latest = f.DepartureTimestamp
-			}
-		}
-		minGap := int64((1 * time.Minute).Seconds())
-		if latest >= departure {
-			departure = latest + minGap
-		}
-		_, err = g.store.CreateFlight(airline.AirlineID, flights.Flight{
-			AirlineID:          airline.AirlineID,
-			FlightID:           flightID,
-			DepartureTimestamp: departure,
-			Status:             flights.StatusScheduled,
-		})
-		if err != nil {
-			continue
-		}
-		slog.Info("auto-created flight", "airline", airline.AirlineID, "flight", flightID, "departure", departure)
-	}
-}
-
-func (g *flightGenerator) advanceFlights() {
-	airlines := g.store.ListAirlines()
-	now := time.Now().Unix()
-	for _, airline := range airlines {
-		flightsList, err := g.store.ListFlights(airline.AirlineID)
-		if err != nil {
-			continue
-		}
-		for _, f := range flightsList {
-			switch f.Status {
-			case flights.StatusScheduled:
-				if now < f.DepartureTimestamp {
-					continue
-				}
-				if g.rand.Float64() < 0.4 {
-					g.updateStatus(airline.AirlineID, f.FlightID, flights.StatusDelayed)
-				} else {
-					g.updateStatus(airline.AirlineID, f.FlightID, flights.StatusDeparted)
-				}
-			case flights.StatusDelayed:
-				if now >= f.DepartureTimestamp+int64((1*time.Minute).Seconds()) {
-					g.updateStatus(airline.AirlineID, f.FlightID, flights.StatusDeparted)
-				}
-			}
-		}
-	}
-}
-
-func (g *flightGenerator) updateStatus(airlineID, flightID string, status flights.Status) {
-	if _, err := g.store.UpdateStatus(airlineID, flightID, status); err == nil {
-		slog.Info("auto-updated flight", "airline", airlineID, "flight", flightID, "status", status)
-	}
-}
-
-func (g *flightGenerator) nextFlightID(airlineID string) string {
-	g.mu.Lock()
-	defer g.mu.Unlock()
-	n := g.counters[airlineID] + 1
-	g.counters[airlineID] = n
-	return fmt.Sprintf("%s-%03d", strings.ToUpper(airlineID), n)
-}
-
-func parseFlightNumber(id string) int {
-	idx := strings.LastIndex(id, "-")
-	if idx == -1 || idx == len(id)-1 {
-		return 0
-	}
-	n, err := strconv.Atoi(id[idx+1:])
-	if err != nil {
-		return 0
-	}
-	return n
-}
-
 func corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET,POST,OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+		w.Header().Set("Access-Control-Allow-Methods", "GET,POST,PUT,PATCH,DELETE,OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key, X-Key-ID, X-Timestamp, X-Signature, Idempotency-Key, If-Match, If-None-Match")
+		w.Header().Set("Access-Control-Expose-Headers", "ETag, Idempotent-Replayed, Retry-After, X-RateLimit-Limit, X-RateLimit-Remaining")
 		if r.Method == http.MethodOptions {
 			w.WriteHeader(http.StatusNoContent)
 			return
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/go-chi/chi/v5"
+
+	"sum/internal/flights"
+)
+
+func init() {
+	openapi3filter.RegisterBodyDecoder("application/x-ndjson", openapi3filter.FileBodyDecoder)
+	openapi3filter.RegisterBodyDecoder("text/csv", openapi3filter.FileBodyDecoder)
+}
+
+func loadSpec(t *testing.T) *openapi3.T {
+	t.Helper()
+	doc, err := openapi3.NewLoader().LoadFromData(flights.OpenAPISpec)
+	if err != nil {
+		t.Fatalf("load spec: %v", err)
+	}
+	if err := doc.Validate(context.Background()); err != nil {
+		t.Fatalf("invalid spec: %v", err)
+	}
+	return doc
+}
+
+func TestOpenAPIDocumentsEveryRoute(t *testing.T) {
+	doc := loadSpec(t)
+	routed := make(map[string]bool)
+	err := chi.Walk(newTestHandler().(chi.Routes), func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		route = strings.TrimSuffix(route, "/")
+		routed[method+" "+route] = true
+		item := doc.Paths.Find(route)
+		if item == nil || item.GetOperation(method) == nil {
+			t.Errorf("%s %s is not documented", method, route)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walk routes: %v", err)
+	}
+	for path, item := range doc.Paths.Map() {
+		for method := range item.Operations() {
+			if !routed[method+" "+path] {
+				t.Errorf("%s %s is documented but not routed", method, path)
+			}
+		}
+	}
+}
+
+// validatingHandler checks every request and response passing through next
+// against the spec and reports mismatches as test errors.
+func validatingHandler(t *testing.T, router routers.Router, next http.Handler) http.Handler {
+	options := &openapi3filter.Options{AuthenticationFunc: openapi3filter.NoopAuthenticationFunc, IncludeResponseStatus: true}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		route, params, err := router.FindRoute(r)
+		if err != nil {
+			t.Errorf("%s %s: %v", r.Method, r.URL.Path, err)
+			next.ServeHTTP(w, r)
+			return
+		}
+		input := &openapi3filter.RequestValidationInput{Request: r, PathParams: params, Route: route, Options: options}
+		if err := openapi3filter.ValidateRequest(r.Context(), input); err != nil {
+			t.Errorf("request %s %s: %v", r.Method, r.URL.Path, err)
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		rec := httptest.NewRecorder()
+		next.ServeHTTP(rec, r)
+		err = openapi3filter.ValidateResponse(r.Context(), &openapi3filter.ResponseValidationInput{
+			RequestValidationInput: input,
+			Status:                 rec.Code,
+			Header:                 rec.Header(),
+			Body:                   io.NopCloser(bytes.NewReader(rec.Body.Bytes())),
+			Options:                options,
+		})
+		if err != nil {
+			t.Errorf("response %s %s %d: %v", r.Method, r.URL.Path, rec.Code, err)
+		}
+		for name, values := range rec.Header() {
+			w.Header()[name] = values
+		}
+		w.WriteHeader(rec.Code)
+		w.Write(rec.Body.Bytes())
+	})
+}
+
+func TestAPIConformsToOpenAPI(t *testing.T) {
+	doc := loadSpec(t)
+	doc.Servers = nil
+	router, err := gorillamux.NewRouter(doc)
+	if err != nil {
+		t.Fatalf("build router: %v", err)
+	}
+	srv := httptest.NewServer(validatingHandler(t, router, newTestHandler()))
+	defer srv.Close()
+	ctx := context.Background()
+	client := flights.NewClient(srv.URL)
+
+	airlines, err := client.ListAirlines(ctx)
+	if err != nil || len(airlines) != 3 {
+		t.Fatalf("list airlines: %v %v", airlines, err)
+	}
+	if _, err := client.CreateAirline(ctx, flights.CreateAirlineRequest{AirlineID: "DELTA", Name: "Delta Lines", Code: "DL"}); err != nil {
+		t.Fatalf("create airline: %v", err)
+	}
+	created, err := client.CreateFlight(ctx, "DELTA", flights.CreateFlightRequest{FlightID: "DELTA-1", DepartureTimestamp: 1_700_003_600})
+	if err != nil || created.Version != 1 {
+		t.Fatalf("create flight: %+v %v", created, err)
+	}
+	delayed, err := client.UpdateStatus(ctx, "DELTA", "DELTA-1", flights.StatusUpdate{Status: flights.StatusDelayed, IfVersion: created.Version})
+	if err != nil || delayed.Status != flights.StatusDelayed {
+		t.Fatalf("delay flight: %+v %v", delayed, err)
+	}
+	if _, err := client.UpdateStatus(ctx, "DELTA", "DELTA-1", flights.StatusUpdate{Status: flights.StatusDeparted, IfVersion: created.Version}); !errors.Is(err, flights.ErrVersionMismatch) {
+		t.Fatalf("expected a version mismatch, got %v", err)
+	}
+	if _, err := client.GetFlight(ctx, "DELTA", "DELTA-2"); !errors.Is(err, flights.ErrFlightNotFound) {
+		t.Fatalf("expected flight not found, got %v", err)
+	}
+	imported, err := client.ImportFlights(ctx, []flights.Flight{{AirlineID: "BETA", FlightID: "BETA-9", DepartureTimestamp: 1_700_007_200}})
+	if err != nil || len(imported) != 1 {
+		t.Fatalf("import flights: %v %v", imported, err)
+	}
+	list, err := client.ListFlights(ctx, "DELTA")
+	if err != nil || len(list) != 1 || list[0].Version != 2 {
+		t.Fatalf("list flights: %+v %v", list, err)
+	}
+
+	// Routes the client does not cover, including error responses.
+	for _, tc := range []struct{ method, path, contentType, body string }{
+		{http.MethodGet, "/healthz", "", ""},
+		{http.MethodGet, "/openapi.json", "", ""},
+		{http.MethodPost, "/flights/import", "text/csv", "airlineId,flightId,departureTimestamp\nGAMMA,GAMMA-9,1700007200\n"},
+		{http.MethodPost, "/airlines/ALPHA/flights", "application/json", `{"flightId":"ALPHA-001","departureTimestamp":1700003600}`},
+		{http.MethodGet, "/admin/clock", "", ""},
+		{http.MethodPost, "/admin/clock/advance", "application/json", `{"seconds":60}`},
+		{http.MethodPost, "/admin/clock/set", "application/json", `{"timestamp":1700000000}`},
+		{http.MethodPut, "/admin/faults", "application/json", `{"latencyMs":0,"routes":["/none"]}`},
+		{http.MethodGet, "/admin/faults", "", ""},
+		{http.MethodDelete, "/admin/faults", "", ""},
+		{http.MethodGet, "/admin/snapshot", "", ""},
+		{http.MethodGet, "/admin/generator", "", ""},
+		{http.MethodGet, "/admin/usage", "", ""},
+	} {
+		req, err := http.NewRequest(tc.method, srv.URL+tc.path, strings.NewReader(tc.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tc.contentType != "" {
+			req.Header.Set("Content-Type", tc.contentType)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s: %v", tc.method, tc.path, err)
+		}
+		resp.Body.Close()
+	}
+
+	snapshot, err := http.Get(srv.URL + "/admin/snapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(snapshot.Body)
+	snapshot.Body.Close()
+	resp, err := http.Post(srv.URL+"/admin/snapshot?mode=merge", "application/json", bytes.NewReader(body))
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("restore snapshot: %v %v", resp, err)
+	}
+	resp.Body.Close()
+}
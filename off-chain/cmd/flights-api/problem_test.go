@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"sum/internal/flights"
+)
+
+func decodeProblem(t *testing.T, body []byte) *flights.Problem {
+	t.Helper()
+	var problem flights.Problem
+	if err := json.Unmarshal(body, &problem); err != nil {
+		t.Fatalf("decode problem: %v (%s)", err, body)
+	}
+	return &problem
+}
+
+func TestErrorsAreProblemDocuments(t *testing.T) {
+	handler := newTestHandler()
+
+	rec := serve(handler, http.MethodPost, "/airlines/ALPHA/flights", `{"departureTimestamp": -5}`, nil)
+	if rec.Code != http.StatusBadRequest || rec.Header().Get("Content-Type") != flights.ProblemContentType {
+		t.Fatalf("expected 400 problem, got %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	problem := decodeProblem(t, rec.Body.Bytes())
+	if problem.Code != flights.CodeValidationFailed || len(problem.Errors) != 2 ||
+		problem.Errors[0].Field != "flightId" || problem.Errors[1].Code != flights.CodeInvalidValue {
+		t.Fatalf("unexpected validation problem %+v", problem)
+	}
+	if problem.RequestID == "" || problem.Instance != "/airlines/ALPHA/flights" {
+		t.Fatalf("expected request ID and instance, got %+v", problem)
+	}
+
+	rec = serve(handler, http.MethodPost, "/airlines/ALPHA/flights/NOPE/delay", "", nil)
+	problem = decodeProblem(t, rec.Body.Bytes())
+	if rec.Code != http.StatusNotFound || problem.Type != flights.ProblemType(flights.CodeFlightNotFound) {
+		t.Fatalf("unexpected not-found problem %d %+v", rec.Code, problem)
+	}
+	if !errors.Is(problem, flights.ErrFlightNotFound) {
+		t.Fatalf("expected problem to unwrap to ErrFlightNotFound, got %v", problem)
+	}
+
+	rec = serve(handler, http.MethodDelete, "/airlines", "", nil)
+	if problem = decodeProblem(t, rec.Body.Bytes()); rec.Code != http.StatusMethodNotAllowed || problem.Code != flights.CodeMethodNotAllowed {
+		t.Fatalf("unexpected method problem %d %+v", rec.Code, problem)
+	}
+}
@@ -0,0 +1,330 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+
+	"sum/internal/flights"
+	"sum/internal/flights/flightspb"
+)
+
+// routeClass groups routes that share a rate limit.
+type routeClass string
+
+const (
+	classReads  routeClass = "reads"
+	classWrites routeClass = "writes"
+	classAdmin  routeClass = "admin"
+
+	// idleClientTTL is how long a client's bucket and counters outlive its last request.
+	idleClientTTL = 10 * time.Minute
+)
+
+// rateRule is a token bucket refilled at Rate tokens per second holding at most
+// Burst tokens. The zero rule is unlimited.
+type rateRule struct {
+	Rate  float64
+	Burst float64
+}
+
+func (r rateRule) limited() bool { return r.Rate > 0 }
+
+func (r rateRule) String() string {
+	if !r.limited() {
+		return "unlimited"
+	}
+	return strconv.FormatFloat(r.Rate, 'f', -1, 64) + "/s"
+}
+
+// rateLimits holds a rule per route class; missing classes are unlimited.
+type rateLimits map[routeClass]rateRule
+
+// parseRateLimitSpec parses the --rate-limit flag, e.g. "reads=20/s,writes=60/m,admin=5/m".
+func parseRateLimitSpec(spec string) (rateLimits, error) {
+	limits := make(rateLimits)
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return limits, nil
+	}
+	for _, part := range strings.Split(spec, ",") {
+		class, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return nil, fmt.Errorf("rate limit %q: expected class=rate", part)
+		}
+		if err := limits.set(class, value); err != nil {
+			return nil, err
+		}
+	}
+	return limits, nil
+}
+
+func (l rateLimits) set(class, value string) error {
+	switch routeClass(class) {
+	case classReads, classWrites, classAdmin:
+	default:
+		return fmt.Errorf("unknown route class %q (want reads, writes or admin)", class)
+	}
+	rule, err := parseRateRule(value)
+	if err != nil {
+		return fmt.Errorf("rate limit %s: %w", class, err)
+	}
+	l[routeClass(class)] = rule
+	return nil
+}
+
+// parseRateRule parses "<n>/<s|m|h>". The bucket holds n tokens, so a client
+// may spend a whole period's allowance at once.
+func parseRateRule(value string) (rateRule, error) {
+	count, unit, ok := strings.Cut(strings.TrimSpace(value), "/")
+	if !ok {
+		return rateRule{}, fmt.Errorf("%q: expected <n>/s, <n>/m or <n>/h", value)
+	}
+	n, err := strconv.ParseFloat(count, 64)
+	if err != nil || n <= 0 {
+		return rateRule{}, fmt.Errorf("%q: count must be a positive number", value)
+	}
+	var period time.Duration
+	switch unit {
+	case "s":
+		period = time.Second
+	case "m":
+		period = time.Minute
+	case "h":
+		period = time.Hour
+	default:
+		return rateRule{}, fmt.Errorf("%q: unknown unit %q", value, unit)
+	}
+	return rateRule{Rate: n / period.Seconds(), Burst: math.Max(n, 1)}, nil
+}
+
+type bucketKey struct {
+	client string
+	class  routeClass
+}
+
+type bucket struct {
+	rule    rateRule
+	tokens  float64
+	last    time.Time
+	allowed uint64
+	limited uint64
+}
+
+// rateLimiter keeps a token bucket and usage counters per client and route class.
+type rateLimiter struct {
+	defaults rateLimits
+	now      func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[bucketKey]*bucket
+	lastSweep time.Time
+}
+
+func newRateLimiter(defaults rateLimits) *rateLimiter {
+	return &rateLimiter{defaults: defaults, now: time.Now, buckets: make(map[bucketKey]*bucket)}
+}
+
+// allow takes a token for the client and reports the tokens left, or how long
+// to wait when none is available.
+func (l *rateLimiter) allow(client string, class routeClass, rule rateRule) (ok bool, remaining int, retryAfter time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	l.sweepLocked(now)
+	key := bucketKey{client: client, class: class}
+	b, found := l.buckets[key]
+	if !found || b.rule != rule {
+		// A changed rule (e.g. a reloaded key) starts from a full bucket.
+		counters := bucket{}
+		if found {
+			counters = *b
+		}
+		b = &bucket{rule: rule, tokens: rule.Burst, last: now, allowed: counters.allowed, limited: counters.limited}
+		l.buckets[key] = b
+	}
+	if !rule.limited() {
+		b.last = now
+		b.allowed++
+		return true, -1, 0
+	}
+	b.tokens = math.Min(rule.Burst, b.tokens+now.Sub(b.last).Seconds()*rule.Rate)
+	b.last = now
+	if b.tokens < 1 {
+		b.limited++
+		wait := time.Duration((1 - b.tokens) / rule.Rate * float64(time.Second))
+		return false, 0, wait
+	}
+	b.tokens--
+	b.allowed++
+	return true, int(b.tokens), 0
+}
+
+func (l *rateLimiter) sweepLocked(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if now.Sub(b.last) > idleClientTTL {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// clientUsage is one entry of GET /admin/usage.
+type clientUsage struct {
+	Client    string     `json:"client"`
+	Class     routeClass `json:"class"`
+	Limit     string     `json:"limit"`
+	Allowed   uint64     `json:"allowed"`
+	Limited   uint64     `json:"limited"`
+	Remaining *int       `json:"remaining,omitempty"`
+}
+
+func (l *rateLimiter) usage() []clientUsage {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	usage := make([]clientUsage, 0, len(l.buckets))
+	for key, b := range l.buckets {
+		u := clientUsage{Client: key.client, Class: key.class, Limit: b.rule.String(), Allowed: b.allowed, Limited: b.limited}
+		if b.rule.limited() {
+			remaining := int(math.Min(b.rule.Burst, b.tokens+now.Sub(b.last).Seconds()*b.rule.Rate))
+			u.Remaining = &remaining
+		}
+		usage = append(usage, u)
+	}
+	sort.Slice(usage, func(i, j int) bool {
+		if usage[i].Client != usage[j].Client {
+			return usage[i].Client < usage[j].Client
+		}
+		return usage[i].Class < usage[j].Class
+	})
+	return usage
+}
+
+// ruleFor returns the caller's own limit for class, falling back to the defaults.
+func (l *rateLimiter) ruleFor(p *principal, class routeClass) rateRule {
+	if p != nil {
+		if rule, ok := p.limits[class]; ok {
+			return rule
+		}
+	}
+	return l.defaults[class]
+}
+
+func classOf(r *http.Request) routeClass {
+	switch {
+	case strings.HasPrefix(r.URL.Path, "/admin/"):
+		return classAdmin
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		return classReads
+	default:
+		return classWrites
+	}
+}
+
+// rateLimitClient names the caller: its API key when authenticated, otherwise
+// the address set by middleware.RealIP.
+func rateLimitClient(p *principal, remoteAddr string) string {
+	if p != nil {
+		return "key:" + p.keyID
+	}
+	if host, _, err := net.SplitHostPort(remoteAddr); err == nil {
+		return "ip:" + host
+	}
+	return "ip:" + remoteAddr
+}
+
+// middleware runs after authentication so that keys are limited as a whole no
+// matter which address they call from.
+func (l *rateLimiter) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/healthz" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		p := principalFrom(r.Context())
+		class := classOf(r)
+		rule := l.ruleFor(p, class)
+		ok, remaining, retryAfter := l.allow(rateLimitClient(p, r.RemoteAddr), class, rule)
+		if rule.limited() {
+			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(int(rule.Burst)))
+			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+		}
+		if !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			respondProblem(w, r, http.StatusTooManyRequests, flights.CodeRateLimited, fmt.Sprintf("%s rate limit of %s exceeded", class, rule))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// grpcWriteMethods are the FlightsService methods limited as writes.
+var grpcWriteMethods = map[string]bool{
+	flightspb.FlightsService_CreateFlight_FullMethodName: true,
+	flightspb.FlightsService_UpdateStatus_FullMethodName: true,
+}
+
+// grpcAllow applies the REST limits to a gRPC call. Invalid keys are left for
+// the service to reject and are limited by address meanwhile.
+func (l *rateLimiter) grpcAllow(ctx context.Context, auth *authenticator, method string) error {
+	var p *principal
+	if auth != nil {
+		p, _ = auth.authenticateToken(metadataToken(ctx))
+	}
+	remoteAddr := ""
+	if pr, ok := peer.FromContext(ctx); ok {
+		remoteAddr = pr.Addr.String()
+	}
+	class := classReads
+	if grpcWriteMethods[method] {
+		class = classWrites
+	}
+	rule := l.ruleFor(p, class)
+	if ok, _, retryAfter := l.allow(rateLimitClient(p, remoteAddr), class, rule); !ok {
+		return flightspb.Error(flights.CodeRateLimited, fmt.Sprintf("%s rate limit of %s exceeded; retry in %s", class, rule, retryAfter.Round(time.Millisecond)))
+	}
+	return nil
+}
+
+func (l *rateLimiter) unaryInterceptor(auth *authenticator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := l.grpcAllow(ctx, auth, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func (l *rateLimiter) streamInterceptor(auth *authenticator) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := l.grpcAllow(ss.Context(), auth, info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+// metadataToken returns the static API key sent with a gRPC call.
+func metadataToken(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	token := first(md.Get("x-api-key"))
+	if bearer, ok := strings.CutPrefix(first(md.Get("authorization")), "Bearer "); ok {
+		token = strings.TrimSpace(bearer)
+	}
+	return token
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"sum/internal/flights"
+)
+
+func TestRateLimitsPerClientAndRouteClass(t *testing.T) {
+	limits, err := parseRateLimitSpec("reads=2/s,writes=1/m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Unix(1_700_000_000, 0)
+	srv := newFlightServer(flights.NewStoreWithClock(flights.NewMockClock(start, false), seedAirlines(), seedFlights(start)))
+	now := start
+	srv.limiter = newRateLimiter(limits)
+	srv.limiter.now = func() time.Time { return now }
+	handler := srv.routes()
+	from := func(ip string) map[string]string { return map[string]string{"X-Real-IP": ip} }
+
+	for i := 0; i < 2; i++ {
+		if rec := serve(handler, http.MethodGet, "/airlines", "", from("10.0.0.1")); rec.Code != http.StatusOK {
+			t.Fatalf("read %d: expected 200, got %d", i, rec.Code)
+		}
+	}
+	rec := serve(handler, http.MethodGet, "/airlines", "", from("10.0.0.1"))
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "1" {
+		t.Fatalf("expected 429 with Retry-After 1, got %d %q", rec.Code, rec.Header().Get("Retry-After"))
+	}
+	if problem := decodeProblem(t, rec.Body.Bytes()); problem.Code != flights.CodeRateLimited {
+		t.Fatalf("expected rate_limited, got %s", problem.Code)
+	}
+	if rec := serve(handler, http.MethodGet, "/airlines", "", from("10.0.0.2")); rec.Code != http.StatusOK {
+		t.Fatalf("other clients must not be limited, got %d", rec.Code)
+	}
+	if rec := serve(handler, http.MethodPost, "/airlines/ALPHA/flights/ALPHA-001/delay", "", from("10.0.0.1")); rec.Code != http.StatusOK {
+		t.Fatalf("writes have their own bucket, got %d", rec.Code)
+	}
+	if rec := serve(handler, http.MethodPost, "/airlines/ALPHA/flights/ALPHA-002/delay", "", from("10.0.0.1")); rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "60" {
+		t.Fatalf("expected 429 with Retry-After 60, got %d %q", rec.Code, rec.Header().Get("Retry-After"))
+	}
+
+	now = now.Add(500 * time.Millisecond)
+	if rec := serve(handler, http.MethodGet, "/airlines", "", from("10.0.0.1")); rec.Code != http.StatusOK {
+		t.Fatalf("expected a refilled token, got %d", rec.Code)
+	}
+
+	rec = serve(handler, http.MethodGet, "/admin/usage", "", from("10.0.0.3"))
+	var body struct {
+		Usage []clientUsage `json:"usage"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]clientUsage)
+	for _, u := range body.Usage {
+		got[u.Client+" "+string(u.Class)] = u
+	}
+	if u := got["ip:10.0.0.1 reads"]; u.Allowed != 3 || u.Limited != 1 || u.Limit != "2/s" {
+		t.Fatalf("unexpected read usage %+v", u)
+	}
+	if u := got["ip:10.0.0.1 writes"]; u.Allowed != 1 || u.Limited != 1 {
+		t.Fatalf("unexpected write usage %+v", u)
+	}
+	if u := got["ip:10.0.0.3 admin"]; u.Allowed != 1 || u.Remaining != nil {
+		t.Fatalf("admin is unlimited but counted, got %+v", u)
+	}
+}
+
+func TestRateLimitKeyOverrides(t *testing.T) {
+	auth, err := newAuthenticator(authConfig{Keys: []apiKeyConfig{
+		{ID: "ui", Secret: "ui-secret", ReadOnly: true, RateLimits: map[string]string{"reads": "1/h"}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	limiter := newRateLimiter(rateLimits{classReads: {Rate: 100, Burst: 100}})
+	limiter.now = func() time.Time { return time.Unix(1_700_000_000, 0) }
+	p, _ := auth.authenticateToken("ui-secret")
+	if rule := limiter.ruleFor(p, classReads); rule.Burst != 1 {
+		t.Fatalf("expected the key override, got %+v", rule)
+	}
+	if ok, _, _ := limiter.allow(rateLimitClient(p, "10.0.0.1:1234"), classReads, limiter.ruleFor(p, classReads)); !ok {
+		t.Fatal("expected the first read to pass")
+	}
+	// The key is limited as a whole, whatever address it calls from.
+	if ok, _, retry := limiter.allow(rateLimitClient(p, "10.0.0.2:1234"), classReads, limiter.ruleFor(p, classReads)); ok || retry != time.Hour {
+		t.Fatalf("expected the second read to wait an hour, got ok=%v retry=%s", ok, retry)
+	}
+	if _, err := newAuthenticator(authConfig{Keys: []apiKeyConfig{
+		{ID: "bad", Secret: "s", ReadOnly: true, RateLimits: map[string]string{"everything": "1/s"}},
+	}}); err == nil {
+		t.Fatal("expected an unknown route class to be rejected")
+	}
+}
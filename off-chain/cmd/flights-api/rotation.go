@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"sum/internal/flights"
+)
+
+// rotationRules assigns generated flights to aircraft so that a late inbound leg
+// delays the next leg flown by the same tail.
+type rotationRules struct {
+	AircraftPerAirline int                      `yaml:"aircraftPerAirline"`
+	LegDuration        time.Duration            `yaml:"legDuration"`
+	Turnaround         time.Duration            `yaml:"turnaround"`
+	AirlineTurnaround  map[string]time.Duration `yaml:"airlineTurnaround"`
+}
+
+func (r *rotationRules) enabled() bool {
+	return r != nil && r.AircraftPerAirline > 0
+}
+
+func (r *rotationRules) validate() error {
+	if r == nil {
+		return nil
+	}
+	if r.AircraftPerAirline < 0 {
+		return fmt.Errorf("aircraftPerAirline must not be negative")
+	}
+	if r.LegDuration < 0 || r.Turnaround < 0 {
+		return fmt.Errorf("legDuration and turnaround must not be negative")
+	}
+	for airlineID, d := range r.AirlineTurnaround {
+		if d < 0 {
+			return fmt.Errorf("airlineTurnaround[%s] must not be negative", airlineID)
+		}
+	}
+	return nil
+}
+
+func (r *rotationRules) turnaroundFor(airlineID string) time.Duration {
+	if d, ok := r.AirlineTurnaround[airlineID]; ok {
+		return d
+	}
+	return r.Turnaround
+}
+
+// cycle is the minimum time between two departures of the same aircraft.
+func (r *rotationRules) cycle(airlineID string) int64 {
+	return int64((r.LegDuration + r.turnaroundFor(airlineID)).Seconds())
+}
+
+func aircraftID(airlineID string, n int) string {
+	return fmt.Sprintf("%s-AC%02d", strings.ToUpper(airlineID), n)
+}
+
+// assignAircraft picks the aircraft that is free the earliest and returns it with
+// the earliest departure it can operate, never before notBefore.
+func (r *rotationRules) assignAircraft(airlineID string, flightsList []flights.Flight, notBefore int64) (string, int64) {
+	lastDeparture := make(map[string]int64, r.AircraftPerAirline)
+	for _, f := range flightsList {
+		if f.AircraftID != "" && f.DepartureTimestamp > lastDeparture[f.AircraftID] {
+			lastDeparture[f.AircraftID] = f.DepartureTimestamp
+		}
+	}
+	bestID, bestAt := "", int64(0)
+	for i := 1; i <= r.AircraftPerAirline; i++ {
+		id := aircraftID(airlineID, i)
+		availableAt := notBefore
+		if last, ok := lastDeparture[id]; ok {
+			availableAt = max(notBefore, last+r.cycle(airlineID))
+		}
+		if bestID == "" || availableAt < bestAt {
+			bestID, bestAt = id, availableAt
+		}
+	}
+	return bestID, bestAt
+}
+
+// inboundLeg returns the previous leg flown by the aircraft operating flight.
+func inboundLeg(flight flights.Flight, flightsList []flights.Flight) (flights.Flight, bool) {
+	var inbound flights.Flight
+	found := false
+	for _, f := range flightsList {
+		if f.AircraftID != flight.AircraftID || f.FlightID == flight.FlightID {
+			continue
+		}
+		if f.DepartureTimestamp > flight.DepartureTimestamp ||
+			(f.DepartureTimestamp == flight.DepartureTimestamp && f.FlightID > flight.FlightID) {
+			continue
+		}
+		if !found || f.DepartureTimestamp > inbound.DepartureTimestamp {
+			inbound, found = f, true
+		}
+	}
+	return inbound, found
+}
+
+// aircraftReady reports whether the aircraft operating flight is back from its
+// inbound leg and turned around by now. The inbound leg's UpdatedAt marks its
+// actual departure once it is DEPARTED.
+func (r *rotationRules) aircraftReady(flight flights.Flight, flightsList []flights.Flight, now int64) (bool, string) {
+	if !r.enabled() || flight.AircraftID == "" {
+		return true, ""
+	}
+	inbound, ok := inboundLeg(flight, flightsList)
+	if !ok {
+		return true, ""
+	}
+	if inbound.Status != flights.StatusDeparted {
+		return false, inbound.FlightID
+	}
+	return inbound.UpdatedAt+r.cycle(flight.AirlineID) <= now, inbound.FlightID
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"sum/internal/flights"
+)
+
+// scenario describes a reproducible run of the mock API. Offsets are relative to
+// the moment the scenario starts. JSON files are accepted as well since JSON is a
+// subset of YAML.
+type scenario struct {
+	Seed     *int64            `yaml:"seed"`
+	Airlines []scenarioAirline `yaml:"airlines"`
+	Flights  []scenarioFlight  `yaml:"flights"`
+	Timeline []scenarioEvent   `yaml:"timeline"`
+	Rules    *generatorRules   `yaml:"rules"`
+}
+
+// defaultScenario is the run without --scenario: no seed data and the
+// generator with its default rules.
+func defaultScenario() *scenario {
+	rules := defaultGeneratorRules()
+	return &scenario{Rules: &rules}
+}
+
+type scenarioAirline struct {
+	AirlineID string `yaml:"airlineId"`
+	Name      string `yaml:"name"`
+	Code      string `yaml:"code"`
+	Signer    string `yaml:"signer"`
+}
+
+type scenarioFlight struct {
+	AirlineID string         `yaml:"airlineId"`
+	FlightID  string         `yaml:"flightId"`
+	Departure time.Duration  `yaml:"departure"`
+	Status    flights.Status `yaml:"status"`
+	Aircraft  string         `yaml:"aircraftId"`
+}
+
+type scenarioAction string
+
+const (
+	scenarioActionCreate scenarioAction = "create"
+	scenarioActionDelay  scenarioAction = "delay"
+	scenarioActionDepart scenarioAction = "depart"
+)
+
+type scenarioEvent struct {
+	At        time.Duration  `yaml:"at"`
+	Action    scenarioAction `yaml:"action"`
+	AirlineID string         `yaml:"airlineId"`
+	FlightID  string         `yaml:"flightId"`
+	Departure time.Duration  `yaml:"departure"`
+	Aircraft  string         `yaml:"aircraftId"`
+}
+
+func loadScenario(path string) (*scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read scenario: %w", err)
+	}
+	var sc scenario
+	if err := yaml.Unmarshal(data, &sc); err != nil {
+		return nil, fmt.Errorf("parse scenario: %w", err)
+	}
+	sc.resolvePaths(filepath.Dir(path))
+	if err := sc.validate(); err != nil {
+		return nil, fmt.Errorf("invalid scenario: %w", err)
+	}
+	return &sc, nil
+}
+
+// resolvePaths makes relative paths in the scenario relative to the scenario
+// file, so it runs the same from any working directory.
+func (sc *scenario) resolvePaths(dir string) {
+	if sc.Rules == nil || sc.Rules.DelayModel == nil || sc.Rules.DelayModel.Calibrate == nil {
+		return
+	}
+	if csv := &sc.Rules.DelayModel.Calibrate.CSV; *csv != "" && !filepath.IsAbs(*csv) {
+		*csv = filepath.Join(dir, *csv)
+	}
+}
+
+func (sc *scenario) validate() error {
+	known := make(map[string]bool, len(sc.Airlines))
+	for i, airline := range sc.Airlines {
+		if strings.TrimSpace(airline.AirlineID) == "" || airline.Name == "" {
+			return fmt.Errorf("airlines[%d]: airlineId and name are required", i)
+		}
+		if known[airline.AirlineID] {
+			return fmt.Errorf("airlines[%d]: duplicate airline %s", i, airline.AirlineID)
+		}
+		known[airline.AirlineID] = true
+	}
+	if len(sc.Flights) > 0 && len(sc.Airlines) == 0 {
+		return fmt.Errorf("flights require the airlines section")
+	}
+	for i, flight := range sc.Flights {
+		if !known[flight.AirlineID] {
+			return fmt.Errorf("flights[%d]: unknown airline %q", i, flight.AirlineID)
+		}
+		if strings.TrimSpace(flight.FlightID) == "" {
+			return fmt.Errorf("flights[%d]: flightId is required", i)
+		}
+	}
+	for i, event := range sc.Timeline {
+		if event.At < 0 {
+			return fmt.Errorf("timeline[%d]: negative offset %s", i, event.At)
+		}
+		if event.AirlineID == "" || event.FlightID == "" {
+			return fmt.Errorf("timeline[%d]: airlineId and flightId are required", i)
+		}
+		switch event.Action {
+		case scenarioActionCreate, scenarioActionDelay, scenarioActionDepart:
+		default:
+			return fmt.Errorf("timeline[%d]: unknown action %q", i, event.Action)
+		}
+	}
+	if sc.Rules != nil {
+		if err := sc.Rules.validate(); err != nil {
+			return fmt.Errorf("rules: %w", err)
+		}
+	}
+	return nil
+}
+
+// seedData returns the initial store contents, falling back to the built-in seed
+// when the scenario does not declare any airlines.
+func (sc *scenario) seedData(start time.Time) ([]flights.Airline, []flights.Flight) {
+	if len(sc.Airlines) == 0 {
+		return seedAirlines(), seedFlights(start)
+	}
+	airlines := make([]flights.Airline, 0, len(sc.Airlines))
+	for _, airline := range sc.Airlines {
+		airlines = append(airlines, flights.Airline{AirlineID: airline.AirlineID, Name: airline.Name, Code: airline.Code, Signer: airline.Signer})
+	}
+	flightsList := make([]flights.Flight, 0, len(sc.Flights))
+	for _, flight := range sc.Flights {
+		flightsList = append(flightsList, flights.Flight{
+			AirlineID:          flight.AirlineID,
+			FlightID:           flight.FlightID,
+			DepartureTimestamp: start.Add(flight.Departure).Unix(),
+			Status:             flight.Status,
+			AircraftID:         flight.Aircraft,
+		})
+	}
+	return airlines, flightsList
+}
+
+// scenarioRunner replays a scenario timeline against the store.
+type scenarioRunner struct {
+	store  *flights.Store
+	begin  time.Time
+	events []scenarioEvent
+}
+
+func newScenarioRunner(store *flights.Store, start time.Time, events []scenarioEvent) *scenarioRunner {
+	sorted := make([]scenarioEvent, len(events))
+	copy(sorted, events)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].At < sorted[j].At })
+	return &scenarioRunner{store: store, begin: start, events: sorted}
+}
+
+func (r *scenarioRunner) start(ctx context.Context) {
+	go func() {
+		for _, event := range r.events {
+			if !r.waitUntil(ctx, r.begin.Add(event.At)) {
+				return
+			}
+			if err := r.apply(event); err != nil {
+				slog.Warn("scenario event failed", "at", event.At, "action", event.Action, "airline", event.AirlineID, "flight", event.FlightID, "error", err)
+				continue
+			}
+			slog.Info("scenario event applied", "at", event.At, "action", event.Action, "airline", event.AirlineID, "flight", event.FlightID)
+		}
+		slog.Info("scenario timeline finished", "events", len(r.events))
+	}()
+}
+
+// waitUntil blocks until the store clock reaches target. The clock is re-read at
+// least once per second so that mock clock jumps fire due events promptly.
+func (r *scenarioRunner) waitUntil(ctx context.Context, target time.Time) bool {
+	clock := r.store.Clock()
+	for {
+		remaining := target.Sub(clock.Now())
+		if remaining <= 0 {
+			return true
+		}
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(min(remaining, time.Second)):
+		}
+	}
+}
+
+func (r *scenarioRunner) apply(event scenarioEvent) error {
+	switch event.Action {
+	case scenarioActionCreate:
+		_, err := traceStatusChange(context.Background(), event.AirlineID, event.FlightID, flights.StatusScheduled, func(traceParent string) (flights.Flight, error) {
+			return r.store.CreateFlight(event.AirlineID, flights.Flight{
+				AirlineID:          event.AirlineID,
+				FlightID:           event.FlightID,
+				DepartureTimestamp: r.begin.Add(event.Departure).Unix(),
+				Status:             flights.StatusScheduled,
+				AircraftID:         event.Aircraft,
+				TraceParent:        traceParent,
+			})
+		})
+		return err
+	case scenarioActionDelay, scenarioActionDepart:
+		status := flights.StatusDelayed
+		if event.Action == scenarioActionDepart {
+			status = flights.StatusDeparted
+		}
+		_, err := traceStatusChange(context.Background(), event.AirlineID, event.FlightID, status, func(traceParent string) (flights.Flight, error) {
+			return r.store.ApplyStatusUpdate(event.AirlineID, event.FlightID, flights.StatusUpdate{Status: status, TraceParent: traceParent})
+		})
+		return err
+	default:
+		return fmt.Errorf("unknown action %q", event.Action)
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadScenarioParsesYAMLAndJSON(t *testing.T) {
+	dir := t.TempDir()
+	yamlPath := filepath.Join(dir, "scenario.yaml")
+	jsonPath := filepath.Join(dir, "scenario.json")
+	yamlBody := `
+seed: 7
+airlines:
+  - {airlineId: ALPHA, name: Alpha Air, code: AA}
+flights:
+  - {airlineId: ALPHA, flightId: ALPHA-001, departure: 2m}
+timeline:
+  - {at: 30s, action: delay, airlineId: ALPHA, flightId: ALPHA-001}
+rules:
+  delayProbability: 0.25
+`
+	jsonBody := `{"seed": 7, "airlines": [{"airlineId": "ALPHA", "name": "Alpha Air"}],
+"flights": [{"airlineId": "ALPHA", "flightId": "ALPHA-001", "departure": "2m"}],
+"timeline": [{"at": "30s", "action": "delay", "airlineId": "ALPHA", "flightId": "ALPHA-001"}],
+"rules": {"delayProbability": 0.25}}`
+	if err := os.WriteFile(yamlPath, []byte(yamlBody), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(jsonPath, []byte(jsonBody), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{yamlPath, jsonPath} {
+		sc, err := loadScenario(path)
+		if err != nil {
+			t.Fatalf("load %s: %v", path, err)
+		}
+		if sc.Seed == nil || *sc.Seed != 7 {
+			t.Fatalf("%s: expected seed 7, got %v", path, sc.Seed)
+		}
+		if len(sc.Timeline) != 1 || sc.Timeline[0].At != 30*time.Second || sc.Timeline[0].Action != scenarioActionDelay {
+			t.Fatalf("%s: unexpected timeline %+v", path, sc.Timeline)
+		}
+		if sc.Rules == nil || sc.Rules.DelayProbability != 0.25 {
+			t.Fatalf("%s: unexpected rules %+v", path, sc.Rules)
+		}
+		start := time.Unix(1_700_000_000, 0)
+		_, seeded := sc.seedData(start)
+		if len(seeded) != 1 || seeded[0].DepartureTimestamp != start.Add(2*time.Minute).Unix() {
+			t.Fatalf("%s: unexpected seed flights %+v", path, seeded)
+		}
+	}
+}
+
+func TestScenarioValidateRejectsUnknownAirline(t *testing.T) {
+	sc := scenario{
+		Airlines: []scenarioAirline{{AirlineID: "ALPHA", Name: "Alpha Air"}},
+		Flights:  []scenarioFlight{{AirlineID: "BETA", FlightID: "BETA-001"}},
+	}
+	if err := sc.validate(); err == nil {
+		t.Fatalf("expected unknown airline to be rejected")
+	}
+}
+
+func TestScenarioRulesKeepDefaultDelayProbability(t *testing.T) {
+	if got := defaultScenario().Rules.withDefaults().DelayProbability; got != 0.4 {
+		t.Fatalf("expected a run without --scenario to delay 40%% of flights, got %v", got)
+	}
+	path := filepath.Join(t.TempDir(), "scenario.yaml")
+	if err := os.WriteFile(path, []byte("rules:\n  createInterval: 10s\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	sc, err := loadScenario(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sc.Rules.DelayProbability != 0.4 || sc.Rules.CreateInterval != 10*time.Second {
+		t.Fatalf("expected omitted rules to keep their defaults, got %+v", sc.Rules)
+	}
+}
+
+func TestScenarioCalibrationIsRelativeToTheScenario(t *testing.T) {
+	// The test runs in cmd/flights-api, not next to the scenario.
+	sc, err := loadScenario(filepath.Join("..", "..", "scenarios", "statistical.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join("..", "..", "scenarios", "ontime-sample.csv"); sc.Rules.DelayModel.Calibrate.CSV != want {
+		t.Fatalf("expected the calibration CSV at %s, got %s", want, sc.Rules.DelayModel.Calibrate.CSV)
+	}
+	if _, err := newDelayModel(sc.Rules.withDefaults()); err != nil {
+		t.Fatalf("expected the calibration CSV to load: %v", err)
+	}
+}
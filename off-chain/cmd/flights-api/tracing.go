@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"sum/internal/flights"
+	"sum/internal/tracing"
+)
+
+var tracer = otel.Tracer("sum/cmd/flights-api")
+
+// traceStatusChange runs change in a flight.status_change span, the root of the
+// trace oracle nodes link their work on the flight to, and hands change the
+// traceparent to store with the flight.
+func traceStatusChange(ctx context.Context, airlineID, flightID string, status flights.Status, change func(traceParent string) (flights.Flight, error)) (flights.Flight, error) {
+	ctx, span := tracer.Start(ctx, "flight.status_change", trace.WithAttributes(
+		attribute.String("flight.airline_id", airlineID),
+		attribute.String("flight.id", flightID),
+		attribute.String("flight.status", string(status)),
+	))
+	defer span.End()
+	flight, err := change(tracing.TraceParent(ctx))
+	if err != nil {
+		span.SetStatus(otelcodes.Error, err.Error())
+		return flight, err
+	}
+	span.SetAttributes(attribute.Int64("flight.version", flight.Version))
+	return flight, nil
+}
+
+// traceRequests serves every request in a server span named after its chi
+// route, continuing the caller's trace when the request carries one.
+func traceRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracer.Start(ctx, r.Method, trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(r.Method),
+			semconv.URLPath(r.URL.Path),
+		))
+		defer span.End()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r.WithContext(ctx))
+
+		code := ww.Status()
+		if code == 0 {
+			code = http.StatusOK
+		}
+		if pattern := chi.RouteContext(r.Context()).RoutePattern(); pattern != "" {
+			span.SetName(r.Method + " " + pattern)
+			span.SetAttributes(semconv.HTTPRoute(pattern))
+		}
+		span.SetAttributes(semconv.HTTPResponseStatusCode(code))
+		if code >= http.StatusInternalServerError {
+			span.SetStatus(otelcodes.Error, http.StatusText(code))
+		}
+	})
+}
+
+// traceUnary serves unary gRPC calls in server spans.
+func traceUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, span := startServerSpan(ctx, info.FullMethod)
+	defer span.End()
+	resp, err := handler(ctx, req)
+	endServerSpan(span, err)
+	return resp, err
+}
+
+// traceStream serves gRPC streams in server spans.
+func traceStream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, span := startServerSpan(ss.Context(), info.FullMethod)
+	defer span.End()
+	err := handler(srv, &tracedStream{ServerStream: ss, ctx: ctx})
+	endServerSpan(span, err)
+	return err
+}
+
+func startServerSpan(ctx context.Context, method string) (context.Context, trace.Span) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	carrier := propagation.MapCarrier{}
+	for _, key := range otel.GetTextMapPropagator().Fields() {
+		if values := md.Get(key); len(values) > 0 {
+			carrier[key] = values[0]
+		}
+	}
+	ctx = otel.GetTextMapPropagator().Extract(ctx, carrier)
+	return tracer.Start(ctx, method[1:], trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(semconv.RPCSystemGRPC))
+}
+
+func endServerSpan(span trace.Span, err error) {
+	code := status.Code(err)
+	span.SetAttributes(semconv.RPCGRPCStatusCodeKey.Int(int(code)))
+	if err != nil {
+		span.SetStatus(otelcodes.Error, err.Error())
+	}
+}
+
+// tracedStream hands the stream handler the context carrying the server span.
+type tracedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *tracedStream) Context() context.Context { return s.ctx }
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"sum/internal/flights"
+	"sum/internal/tracing"
+)
+
+func TestStatusChangeStartsFlightTrace(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(noop.NewTracerProvider())
+		otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+	})
+
+	const caller = "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"
+	rec := serve(newTestHandler(), http.MethodPost, "/airlines/ALPHA/flights/ALPHA-001/delay", "", map[string]string{"traceparent": caller})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp flights.FlightResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("expected the status change and request spans, got %d", len(spans))
+	}
+	change, request := spans[0], spans[1]
+	if change.Name != "flight.status_change" || request.Name != "POST /airlines/{airlineId}/flights/{flightId}/delay" {
+		t.Fatalf("unexpected spans %q and %q", change.Name, request.Name)
+	}
+	if request.SpanContext.TraceID().String() != "0af7651916cd43dd8448eb211c80319c" || change.Parent.SpanID() != request.SpanContext.SpanID() {
+		t.Fatal("expected the status change inside the request span, continuing the caller's trace")
+	}
+	link, ok := tracing.Link(resp.Flight.TraceParent)
+	if !ok || link.SpanContext.SpanID() != change.SpanContext.SpanID() {
+		t.Fatalf("expected the flight to carry the status change span, got %q", resp.Flight.TraceParent)
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/ethereum/go-ethereum/common"
+	"gopkg.in/yaml.v3"
+
+	"sum/internal/flights"
+)
+
+// airlineSigners is the node's own registry of the addresses allowed to attest
+// each airline's flights. It is configured locally so that a compromised flights
+// API cannot vouch for forged statuses.
+type airlineSigners map[string]common.Address
+
+// loadAirlineSigners reads a YAML/JSON map of airlineId to signer address.
+func loadAirlineSigners(path string) (airlineSigners, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read airline signers: %w", err)
+	}
+	var raw map[string]string
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parse airline signers: %w", err)
+	}
+	signers := make(airlineSigners, len(raw))
+	for airlineID, address := range raw {
+		if !common.IsHexAddress(address) {
+			return nil, fmt.Errorf("airline %s: invalid signer address %q", airlineID, address)
+		}
+		signers[airlineID] = common.HexToAddress(address)
+	}
+	return signers, nil
+}
+
+// verify checks that the flight's current state is signed by its airline.
+func (a airlineSigners) verify(flight flights.Flight) error {
+	signer, ok := a[flight.AirlineID]
+	if !ok {
+		return fmt.Errorf("%w: no trusted signer configured for airline %s", flights.ErrMissingAttestation, flight.AirlineID)
+	}
+	return flights.VerifyAttestation(flight, signer)
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Submitter key selections accepted by --submitter-selection.
+const (
+	selectLeastLoaded = "least-loaded"
+	selectBalance     = "balance"
+)
+
+// errNoSubmitter is returned while every submitter key is below the critical
+// balance, so no transaction could be paid for.
+var errNoSubmitter = errors.New("no submitter key available")
+
+type balanceLevel int
+
+const (
+	balanceOK balanceLevel = iota
+	balanceWarning
+	balanceCritical
+)
+
+func (l balanceLevel) String() string {
+	switch l {
+	case balanceWarning:
+		return "warning"
+	case balanceCritical:
+		return "critical"
+	default:
+		return "ok"
+	}
+}
+
+// balanceReader is the part of the EVM backend the key pool needs.
+type balanceReader interface {
+	BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error)
+}
+
+// submitterKey is a key of the pool with the state used to pick it.
+type submitterKey struct {
+	signer txSigner
+
+	// Guarded by keyPool.mu.
+	balance  *big.Int // nil until the first balance check
+	level    balanceLevel
+	inFlight int // submitted actions not yet confirmed on-chain
+}
+
+type keyPoolConfig struct {
+	selection string
+	// warnBalance and criticalBalance are in wei. Keys below criticalBalance
+	// are taken out of rotation until they are funded again.
+	warnBalance     *big.Int
+	criticalBalance *big.Int
+}
+
+// keyPool spreads submissions over several submitter keys, so each key keeps
+// its own nonce stream and a drained key does not stop the node.
+type keyPool struct {
+	backend   balanceReader
+	selection string
+	warn      *big.Int
+	critical  *big.Int
+	metrics   *metrics
+
+	mu   sync.Mutex
+	keys []*submitterKey
+	next int
+}
+
+func newKeyPool(signers []txSigner, backend balanceReader, cfg keyPoolConfig, m *metrics) (*keyPool, error) {
+	switch cfg.selection {
+	case selectLeastLoaded, selectBalance:
+	default:
+		return nil, fmt.Errorf("unknown submitter selection %q (want %s or %s)", cfg.selection, selectLeastLoaded, selectBalance)
+	}
+	if cfg.criticalBalance.Cmp(cfg.warnBalance) > 0 {
+		return nil, fmt.Errorf("critical submitter balance %s ETH is above the warning balance %s ETH", formatEther(cfg.criticalBalance), formatEther(cfg.warnBalance))
+	}
+	p := &keyPool{backend: backend, selection: cfg.selection, warn: cfg.warnBalance, critical: cfg.criticalBalance, metrics: m}
+	for _, signer := range signers {
+		p.keys = append(p.keys, &submitterKey{signer: signer})
+		m.submitterInFlight.WithLabelValues(signer.Address().Hex()).Set(0)
+	}
+	m.submittersAvailable.Set(float64(len(p.keys)))
+	return p, nil
+}
+
+// acquire picks the key for the next submission and counts it in flight until
+// release. Keys below the critical balance are skipped; ties go round-robin.
+func (p *keyPool) acquire() (*submitterKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	best := -1
+	for i := range p.keys {
+		j := (p.next + i) % len(p.keys)
+		if p.keys[j].level == balanceCritical {
+			continue
+		}
+		if best < 0 || p.better(p.keys[j], p.keys[best]) {
+			best = j
+		}
+	}
+	if best < 0 {
+		return nil, fmt.Errorf("%w: all %d keys are below the critical balance of %s ETH", errNoSubmitter, len(p.keys), formatEther(p.critical))
+	}
+	p.next = best + 1
+	key := p.keys[best]
+	key.inFlight++
+	p.metrics.submitterInFlight.WithLabelValues(key.signer.Address().Hex()).Set(float64(key.inFlight))
+	return key, nil
+}
+
+// better reports whether a should be picked over b.
+func (p *keyPool) better(a, b *submitterKey) bool {
+	byBalance := compareBalances(a.balance, b.balance)
+	if p.selection == selectBalance {
+		return byBalance > 0 || (byBalance == 0 && a.inFlight < b.inFlight)
+	}
+	return a.inFlight < b.inFlight || (a.inFlight == b.inFlight && byBalance > 0)
+}
+
+// compareBalances compares balances with unknown ones lowest.
+func compareBalances(a, b *big.Int) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+	return a.Cmp(b)
+}
+
+// release ends a submission started by acquire once its action is confirmed.
+func (p *keyPool) release(key *submitterKey) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	key.inFlight = max(key.inFlight-1, 0)
+	p.metrics.submitterInFlight.WithLabelValues(key.signer.Address().Hex()).Set(float64(key.inFlight))
+}
+
+// failed releases key after a submission failed. A key the chain refused for
+// lack of funds is taken out of rotation until a balance check finds it funded.
+func (p *keyPool) failed(key *submitterKey, err error) {
+	p.release(key)
+	if !strings.Contains(strings.ToLower(err.Error()), "insufficient funds") {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if key.level != balanceCritical {
+		slog.Error("submitter key refused for insufficient funds; key taken out of rotation until its next balance check", "address", key.signer.Address().Hex(), "error", err)
+		key.level = balanceCritical
+		p.countAvailable()
+	}
+}
+
+// checkBalances refreshes every key's balance and level.
+func (p *keyPool) checkBalances(ctx context.Context) {
+	for _, key := range p.keys {
+		address := key.signer.Address()
+		balance, err := p.backend.BalanceAt(ctx, address, nil)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			slog.Warn("check submitter balance failed", "address", address.Hex(), "error", err)
+			continue
+		}
+		level := balanceOK
+		switch {
+		case balance.Cmp(p.critical) < 0:
+			level = balanceCritical
+		case balance.Cmp(p.warn) < 0:
+			level = balanceWarning
+		}
+		p.mu.Lock()
+		key.balance = balance
+		p.setLevel(key, level)
+		p.mu.Unlock()
+		balanceEther, _ := weiToEther(balance).Float64()
+		p.metrics.submitterBalance.WithLabelValues(address.Hex()).Set(balanceEther)
+	}
+}
+
+// setLevel moves key to level and logs the change. p.mu must be held.
+func (p *keyPool) setLevel(key *submitterKey, level balanceLevel) {
+	if key.level == level {
+		return
+	}
+	attrs := []any{"address", key.signer.Address().Hex(), "level", level.String()}
+	if key.balance != nil {
+		attrs = append(attrs, "balance", formatEther(key.balance)+" ETH")
+	}
+	switch level {
+	case balanceCritical:
+		slog.Error("submitter key balance critical; key taken out of rotation", attrs...)
+	case balanceWarning:
+		slog.Warn("submitter key balance low", attrs...)
+	default:
+		slog.Info("submitter key balance recovered", attrs...)
+	}
+	key.level = level
+	p.countAvailable()
+}
+
+// countAvailable updates the available keys metric. p.mu must be held.
+func (p *keyPool) countAvailable() {
+	available := 0
+	for _, k := range p.keys {
+		if k.level != balanceCritical {
+			available++
+		}
+	}
+	p.metrics.submittersAvailable.Set(float64(available))
+}
+
+func (p *keyPool) monitor(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			p.checkBalances(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (p *keyPool) close() {
+	for _, key := range p.keys {
+		key.signer.Close()
+	}
+}
+
+var weiPerEther = new(big.Float).SetFloat64(1e18)
+
+func weiToEther(wei *big.Int) *big.Float {
+	return new(big.Float).Quo(new(big.Float).SetInt(wei), weiPerEther)
+}
+
+// etherToWei converts the shortest decimal form of ether, so 0.1 is exactly
+// 10^17 wei.
+func etherToWei(ether float64) *big.Int {
+	f, _ := new(big.Float).SetPrec(256).SetString(strconv.FormatFloat(ether, 'f', -1, 64))
+	wei, _ := f.Mul(f, weiPerEther).Int(nil)
+	return wei
+}
+
+func formatEther(wei *big.Int) string {
+	return weiToEther(wei).Text('f', -1)
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// fakeBalances answers BalanceAt from a map of wei balances.
+type fakeBalances struct {
+	mu       sync.Mutex
+	balances map[common.Address]*big.Int
+}
+
+func (f *fakeBalances) BalanceAt(_ context.Context, account common.Address, _ *big.Int) (*big.Int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.balances[account], nil
+}
+
+func (f *fakeBalances) set(account common.Address, ether float64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.balances[account] = etherToWei(ether)
+}
+
+func newTestKeyPool(t *testing.T, selection string, balances ...float64) (*keyPool, *fakeBalances, *metrics) {
+	t.Helper()
+	backend := &fakeBalances{balances: map[common.Address]*big.Int{}}
+	var signers []txSigner
+	for _, ether := range balances {
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		signer := &localSigner{key: key}
+		signers = append(signers, signer)
+		backend.set(signer.Address(), ether)
+	}
+	m := newMetrics()
+	pool, err := newKeyPool(signers, backend, keyPoolConfig{
+		selection:       selection,
+		warnBalance:     etherToWei(0.1),
+		criticalBalance: etherToWei(0.01),
+	}, m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool.checkBalances(context.Background())
+	return pool, backend, m
+}
+
+func mustAcquire(t *testing.T, pool *keyPool) *submitterKey {
+	t.Helper()
+	key, err := pool.acquire()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestKeyPoolPicksLeastLoadedKey(t *testing.T) {
+	pool, _, m := newTestKeyPool(t, selectLeastLoaded, 1, 2, 0.05)
+
+	// The idle keys go first, richest first, then round-robin.
+	order := []*submitterKey{mustAcquire(t, pool), mustAcquire(t, pool), mustAcquire(t, pool)}
+	if order[0] != pool.keys[1] || order[1] != pool.keys[0] || order[2] != pool.keys[2] {
+		t.Fatal("expected the idle keys by balance")
+	}
+	pool.release(order[0])
+	if mustAcquire(t, pool) != pool.keys[1] {
+		t.Fatal("expected the released key to be the least loaded")
+	}
+	if got := testutil.ToFloat64(m.submitterInFlight.WithLabelValues(pool.keys[1].signer.Address().Hex())); got != 1 {
+		t.Fatalf("expected one action in flight, got %v", got)
+	}
+	if got := testutil.ToFloat64(m.submitterBalance.WithLabelValues(pool.keys[2].signer.Address().Hex())); got != 0.05 {
+		t.Fatalf("expected the balance in ETH, got %v", got)
+	}
+}
+
+func TestKeyPoolPicksHighestBalance(t *testing.T) {
+	pool, _, _ := newTestKeyPool(t, selectBalance, 1, 2)
+	for range 3 {
+		if mustAcquire(t, pool) != pool.keys[1] {
+			t.Fatal("expected the richest key regardless of load")
+		}
+	}
+}
+
+func TestKeyPoolSkipsDrainedKeys(t *testing.T) {
+	pool, backend, m := newTestKeyPool(t, selectLeastLoaded, 0.005, 1)
+	if pool.keys[0].level != balanceCritical || testutil.ToFloat64(m.submittersAvailable) != 1 {
+		t.Fatal("expected the key below the critical balance out of rotation")
+	}
+	for range 2 {
+		if mustAcquire(t, pool) != pool.keys[1] {
+			t.Fatal("expected only the funded key to be used")
+		}
+	}
+
+	// A key the chain refuses for lack of funds leaves rotation right away.
+	key := mustAcquire(t, pool)
+	pool.failed(key, errors.New("insufficient funds for gas * price + value"))
+	if _, err := pool.acquire(); !errors.Is(err, errNoSubmitter) {
+		t.Fatalf("expected an exhausted pool to refuse, got %v", err)
+	}
+	if testutil.ToFloat64(m.submittersAvailable) != 0 {
+		t.Fatal("expected no available submitters")
+	}
+
+	backend.set(pool.keys[0].signer.Address(), 0.05)
+	pool.checkBalances(context.Background())
+	if pool.keys[0].level != balanceWarning || mustAcquire(t, pool) != pool.keys[0] {
+		t.Fatal("expected the funded key back in rotation with a warning")
+	}
+}
+
+func TestKeyPoolRejectsBadConfig(t *testing.T) {
+	m := newMetrics()
+	if _, err := newKeyPool(nil, nil, keyPoolConfig{selection: "random", warnBalance: big.NewInt(1), criticalBalance: big.NewInt(0)}, m); err == nil {
+		t.Fatal("expected an unknown selection to be rejected")
+	}
+	if _, err := newKeyPool(nil, nil, keyPoolConfig{selection: selectBalance, warnBalance: big.NewInt(1), criticalBalance: big.NewInt(2)}, m); err == nil {
+		t.Fatal("expected a critical balance above the warning to be rejected")
+	}
+	if got := etherToWei(0.1); got.String() != "100000000000000000" {
+		t.Fatalf("expected 0.1 ETH to be exact, got %s wei", got)
+	}
+}
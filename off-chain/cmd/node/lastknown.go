@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"time"
+
+	"sum/internal/flights"
+)
+
+type flightsSnapshot struct {
+	flights []flights.Flight
+	at      time.Time
+}
+
+// lastKnownGood wraps a flightsSource and answers from the last successful
+// listing while the flights API fails, so the node keeps evaluating the flights
+// it already knows about. Served data is marked stale in metrics.
+type lastKnownGood struct {
+	source  flightsSource
+	metrics *metrics
+	// breaker, when set, is reported in metrics after every call.
+	breaker *flights.CircuitBreaker
+	now     func() time.Time
+
+	airlines   []flights.Airline
+	airlinesAt time.Time
+	flights    map[string]flightsSnapshot
+}
+
+func newLastKnownGood(source flightsSource, m *metrics, breaker *flights.CircuitBreaker) *lastKnownGood {
+	return &lastKnownGood{source: source, metrics: m, breaker: breaker, now: time.Now, flights: make(map[string]flightsSnapshot)}
+}
+
+func (l *lastKnownGood) ListAirlines(ctx context.Context) ([]flights.Airline, error) {
+	airlines, err := l.source.ListAirlines(ctx)
+	defer l.reportBreaker()
+	if err == nil {
+		l.airlines, l.airlinesAt = airlines, l.now()
+		for id := range l.flights {
+			if !slices.ContainsFunc(airlines, func(a flights.Airline) bool { return a.AirlineID == id }) {
+				delete(l.flights, id)
+				l.metrics.flightsStale.DeleteLabelValues(id)
+				l.metrics.flightsDataAge.DeleteLabelValues(id)
+			}
+		}
+		l.markFresh("")
+		return airlines, nil
+	}
+	l.metrics.flightsErrors.WithLabelValues("list_airlines").Inc()
+	if l.airlinesAt.IsZero() {
+		return nil, err
+	}
+	age := l.markStale("", l.airlinesAt)
+	slog.Warn("flights API unavailable; using last known airlines", "age", age, "error", err)
+	return slices.Clone(l.airlines), nil
+}
+
+func (l *lastKnownGood) ListFlights(ctx context.Context, airlineID string) ([]flights.Flight, error) {
+	list, err := l.source.ListFlights(ctx, airlineID)
+	defer l.reportBreaker()
+	switch {
+	case err == nil:
+		l.flights[airlineID] = flightsSnapshot{flights: list, at: l.now()}
+		l.markFresh(airlineID)
+		return list, nil
+	case errors.Is(err, flights.ErrAirlineNotFound):
+		// The API answered; the airline is gone rather than unreachable.
+		delete(l.flights, airlineID)
+		return nil, err
+	}
+	l.metrics.flightsErrors.WithLabelValues("list_flights").Inc()
+	cached, ok := l.flights[airlineID]
+	if !ok {
+		return nil, err
+	}
+	age := l.markStale(airlineID, cached.at)
+	slog.Warn("flights API unavailable; using last known flights", "airline", airlineID, "age", age, "error", err)
+	return slices.Clone(cached.flights), nil
+}
+
+func (l *lastKnownGood) markFresh(airlineID string) {
+	l.metrics.flightsStale.WithLabelValues(airlineID).Set(0)
+	l.metrics.flightsDataAge.WithLabelValues(airlineID).Set(0)
+}
+
+func (l *lastKnownGood) markStale(airlineID string, at time.Time) time.Duration {
+	age := l.now().Sub(at)
+	l.metrics.flightsStale.WithLabelValues(airlineID).Set(1)
+	l.metrics.flightsDataAge.WithLabelValues(airlineID).Set(age.Seconds())
+	return age.Round(time.Second)
+}
+
+func (l *lastKnownGood) reportBreaker() {
+	if l.breaker == nil {
+		return
+	}
+	open := 0.0
+	if l.breaker.State() != flights.BreakerClosed {
+		open = 1
+	}
+	l.metrics.flightsCircuitOpen.Set(open)
+}
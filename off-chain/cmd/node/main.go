@@ -2,16 +2,13 @@ package main
 
 import (
 	"context"
-	"crypto/ecdsa"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
 	"math/big"
-	"net/http"
-	"net/url"
 	"os"
 	"os/signal"
+	"slices"
 	"sort"
 	"strings"
 	"syscall"
@@ -20,33 +17,96 @@ import (
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
-	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/spf13/cobra"
 	v1 "github.com/symbioticfi/relay/api/client/v1"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
 	"sum/internal/contracts"
 	"sum/internal/flights"
+	"sum/internal/flights/flightspb"
+	"sum/internal/settings"
+	"sum/internal/tracing"
 	"sum/internal/utils"
 )
 
 const (
-	keyTag    = 15
+	// envPrefix prefixes the environment variables that set the node's flags.
+	envPrefix = "FLIGHT_NODE"
 	maxUint48 = (1 << 48) - 1
 )
 
 type config struct {
-	relayAPIURL       string
-	evmRPCURL         string
+	relayAPIURLs      []string
+	relayHealthEvery  time.Duration
+	relayGRPC         utils.GRPCOptions
+	flightsGRPC       utils.GRPCOptions
+	evmRPCURLs        []string
+	evmSendRPCURLs    []string
+	evmRPCRateLimit   float64
+	evmRPCMaxLag      uint64
+	evmRPCHealthEvery time.Duration
 	contractAddress   string
 	flightsAPIURL     string
-	privateKeyHex     string
+	flightsGRPCURL    string
+	flightsAPIKey     string
+	flightsAPIKeyID   string
+	flightsAPI        flightsAPIConfig
+	metricsListen     string
+	airlineSigners    string
+	signer            signerConfig
+	keyPool           keyPoolConfig
+	balanceWarn       float64
+	balanceCritical   float64
+	balanceEvery      time.Duration
 	pollInterval      time.Duration
 	proofPollInterval time.Duration
 	logLevel          string
+	keyTag            uint8
+	tracing           tracing.Options
 }
 
-var cfg config
+var (
+	cfg config
+	// loader layers the config file and environment under the flags.
+	loader *settings.Loader
+)
+
+// validate checks settings the flag types cannot.
+func (c *config) validate() error {
+	if _, err := settings.ParseLogLevel(c.logLevel); err != nil {
+		return fmt.Errorf("--log-level: %w", err)
+	}
+	for name, interval := range map[string]time.Duration{
+		"--poll-interval":              c.pollInterval,
+		"--proof-poll-interval":        c.proofPollInterval,
+		"--relay-health-interval":      c.relayHealthEvery,
+		"--evm-rpc-health-interval":    c.evmRPCHealthEvery,
+		"--submitter-balance-interval": c.balanceEvery,
+	} {
+		if interval <= 0 {
+			return fmt.Errorf("%s must be positive, got %s", name, interval)
+		}
+	}
+	if !common.IsHexAddress(c.contractAddress) {
+		return fmt.Errorf("--flight-delays-address: invalid address %q", c.contractAddress)
+	}
+	if c.evmRPCRateLimit < 0 {
+		return fmt.Errorf("--evm-rpc-rate-limit must not be negative, got %v", c.evmRPCRateLimit)
+	}
+	if c.balanceCritical < 0 || c.balanceWarn < c.balanceCritical {
+		return fmt.Errorf("submitter balances must satisfy 0 <= --submitter-balance-critical (%v) <= --submitter-balance-warn (%v)", c.balanceCritical, c.balanceWarn)
+	}
+	if c.keyPool.selection != selectLeastLoaded && c.keyPool.selection != selectBalance {
+		return fmt.Errorf("--submitter-selection must be %s or %s, got %q", selectLeastLoaded, selectBalance, c.keyPool.selection)
+	}
+	if c.tracing.SampleRatio < 0 || c.tracing.SampleRatio > 1 {
+		return fmt.Errorf("--trace-sample-ratio must be between 0 and 1, got %v", c.tracing.SampleRatio)
+	}
+	return nil
+}
 
 var rootCmd = &cobra.Command{
 	Use:           "flight-node",
@@ -54,70 +114,84 @@ var rootCmd = &cobra.Command{
 	SilenceUsage:  true,
 	SilenceErrors: true,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		switch strings.ToLower(cfg.logLevel) {
-		case "debug":
-			slog.SetLogLoggerLevel(slog.LevelDebug)
-		case "warn":
-			slog.SetLogLoggerLevel(slog.LevelWarn)
-		case "error":
-			slog.SetLogLoggerLevel(slog.LevelError)
-		default:
-			slog.SetLogLoggerLevel(slog.LevelInfo)
+		if err := loader.Load(); err != nil {
+			return err
 		}
+		if loader.PrintRequested() {
+			return loader.Print(os.Stdout)
+		}
+		level, _ := settings.ParseLogLevel(cfg.logLevel)
+		slog.SetLogLoggerLevel(level)
 
 		ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 		defer cancel()
 
-		conn, err := utils.GetGRPCConnection(cfg.relayAPIURL)
+		shutdownTracing, err := tracing.Setup(ctx, "flight-node", cfg.tracing)
 		if err != nil {
-			return fmt.Errorf("create relay client: %w", err)
+			return err
 		}
-		defer conn.Close()
-
-		relayClient := v1.NewSymbioticClient(conn)
+		defer func() {
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := shutdownTracing(shutdownCtx); err != nil {
+				slog.Error("failed to flush traces", "error", err)
+			}
+		}()
 
-		evmClient, err := ethclient.DialContext(ctx, cfg.evmRPCURL)
-		if err != nil {
-			return fmt.Errorf("dial evm rpc: %w", err)
+		m := newMetrics()
+		if cfg.metricsListen != "" {
+			go m.serve(ctx, cfg.metricsListen)
 		}
-		defer evmClient.Close()
 
-		chainID, err := evmClient.ChainID(ctx)
+		deps, err := dial(ctx, m)
 		if err != nil {
-			return fmt.Errorf("fetch chain id: %w", err)
+			return err
 		}
-
-		contractAddr := common.HexToAddress(cfg.contractAddress)
-		flightDelays, err := contracts.NewFlightDelays(contractAddr, evmClient)
-		if err != nil {
-			return fmt.Errorf("bind flight delays: %w", err)
+		defer deps.close()
+		if err := deps.preflight().run(ctx); err != nil {
+			return err
 		}
-
-		flightsClient := newFlightsAPIClient(cfg.flightsAPIURL)
-
-		privKey, err := crypto.HexToECDSA(strings.TrimPrefix(cfg.privateKeyHex, "0x"))
-		if err != nil {
-			return fmt.Errorf("parse private key: %w", err)
+		go deps.relays.monitor(ctx, cfg.relayHealthEvery)
+		go deps.evm.monitor(ctx, cfg.evmRPCHealthEvery)
+		go deps.keys.monitor(ctx, cfg.balanceEvery)
+
+		var signers airlineSigners
+		if cfg.airlineSigners != "" {
+			if signers, err = loadAirlineSigners(cfg.airlineSigners); err != nil {
+				return err
+			}
+			slog.Info("Verifying airline attestations", "airlines", len(signers))
 		}
 
 		node := &flightNode{
-			relayClient: relayClient,
-			ethClient:   evmClient,
-			contract:    flightDelays,
-			chainID:     chainID,
-			privateKey:  privKey,
-			flightsAPI:  flightsClient,
-			pending:     make(map[string]*pendingAction),
+			relays:     deps.relays,
+			evm:        deps.evm,
+			contract:   deps.contract,
+			chainID:    deps.chainID,
+			keys:       deps.keys,
+			flightsAPI: newLastKnownGood(deps.flightsAPI, m, deps.breaker),
+			signers:    signers,
+			pending:    make(map[string]*pendingAction),
 		}
 
 		if err := node.syncFlights(ctx); err != nil {
 			slog.Warn("initial sync failed", "error", err)
 		}
 
+		// With gRPC, flight changes trigger a sync right away; polling remains the
+		// fallback while the stream reconnects.
+		changed := make(chan struct{}, 1)
+		if deps.watcher != nil {
+			go watchFlights(ctx, deps.watcher, changed, cfg.pollInterval)
+		}
+
 		pollTicker := time.NewTicker(cfg.pollInterval)
 		defer pollTicker.Stop()
 		proofTicker := time.NewTicker(cfg.proofPollInterval)
 		defer proofTicker.Stop()
+		reload := make(chan os.Signal, 1)
+		signal.Notify(reload, syscall.SIGHUP)
+		defer signal.Stop(reload)
 
 		for {
 			select {
@@ -125,6 +199,10 @@ var rootCmd = &cobra.Command{
 				if err := node.syncFlights(ctx); err != nil {
 					slog.Warn("sync flights failed", "error", err)
 				}
+			case <-changed:
+				if err := node.syncFlights(ctx); err != nil {
+					slog.Warn("sync flights failed", "error", err)
+				}
 			case <-proofTicker.C:
 				if err := node.fetchProofs(ctx); err != nil {
 					slog.Warn("fetch proofs failed", "error", err)
@@ -132,6 +210,17 @@ var rootCmd = &cobra.Command{
 				if err := node.submitReadyActions(ctx); err != nil {
 					slog.Warn("submit actions failed", "error", err)
 				}
+			case <-reload:
+				changed, err := loader.Reload()
+				if err != nil {
+					slog.Error("reload config failed; keeping the current settings", "error", err)
+					continue
+				}
+				level, _ := settings.ParseLogLevel(cfg.logLevel)
+				slog.SetLogLoggerLevel(level)
+				pollTicker.Reset(cfg.pollInterval)
+				proofTicker.Reset(cfg.proofPollInterval)
+				slog.Info("reloaded config", "changed", changed)
 			case <-ctx.Done():
 				slog.Info("shutting down flight node")
 				return nil
@@ -140,21 +229,211 @@ var rootCmd = &cobra.Command{
 	},
 }
 
+var preflightCmd = &cobra.Command{
+	Use:   "preflight",
+	Short: "Run the startup checks against the configured deployment and exit",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := loader.Load(); err != nil {
+			return err
+		}
+		level, _ := settings.ParseLogLevel(cfg.logLevel)
+		slog.SetLogLoggerLevel(level)
+
+		ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+		deps, err := dial(ctx, newMetrics())
+		if err != nil {
+			return err
+		}
+		defer deps.close()
+		if err := deps.preflight().run(ctx); err != nil {
+			return err
+		}
+		slog.Info("all preflight checks passed")
+		return nil
+	},
+}
+
+// nodeDeps are the clients the node is built from, shared with the preflight
+// subcommand.
+type nodeDeps struct {
+	relays     *relayPool
+	evm        *rpcPool
+	chainID    *big.Int
+	contract   *contracts.FlightDelays
+	flightsAPI flightsSource
+	// flightsHealth probes the flights API without retries.
+	flightsHealth func(ctx context.Context) error
+	watcher       *flightspb.Client
+	breaker       *flights.CircuitBreaker
+	keys          *keyPool
+	closers       []func()
+}
+
+// dial connects to the relays, the EVM endpoints and the flights API and loads
+// the submitter keys. The health monitors are left for the caller to start.
+func dial(ctx context.Context, m *metrics) (_ *nodeDeps, err error) {
+	d := &nodeDeps{}
+	defer func() {
+		if err != nil {
+			d.close()
+		}
+	}()
+
+	cfg.relayGRPC.Metrics, cfg.flightsGRPC.Metrics = m.grpcClient, m.grpcClient
+	if d.relays, err = newRelayPool(cfg.relayAPIURLs, cfg.relayGRPC, m); err != nil {
+		return nil, fmt.Errorf("create relay clients: %w", err)
+	}
+	d.closers = append(d.closers, d.relays.close)
+	d.relays.checkHealth(ctx)
+
+	d.evm, err = newRPCPool(ctx, rpcPoolConfig{
+		readURLs:  cfg.evmRPCURLs,
+		sendURLs:  cfg.evmSendRPCURLs,
+		rateLimit: cfg.evmRPCRateLimit,
+		maxLag:    cfg.evmRPCMaxLag,
+	}, m)
+	if err != nil {
+		return nil, fmt.Errorf("dial evm rpc: %w", err)
+	}
+	d.closers = append(d.closers, d.evm.Close)
+	if d.chainID, err = d.evm.ChainID(ctx); err != nil {
+		return nil, fmt.Errorf("fetch chain id: %w", err)
+	}
+	d.evm.checkHealth(ctx)
+
+	if d.contract, err = contracts.NewFlightDelays(common.HexToAddress(cfg.contractAddress), d.evm); err != nil {
+		return nil, fmt.Errorf("bind flight delays: %w", err)
+	}
+
+	switch {
+	case cfg.flightsGRPCURL != "":
+		if cfg.flightsAPIKeyID != "" {
+			return nil, fmt.Errorf("--flights-api-key-id: HMAC signing is not supported over gRPC")
+		}
+		conn, err := utils.GetGRPCConnection(cfg.flightsGRPCURL, cfg.flightsGRPC)
+		if err != nil {
+			return nil, fmt.Errorf("connect flights gRPC: %w", err)
+		}
+		d.closers = append(d.closers, func() { conn.Close() })
+		d.watcher = flightspb.NewClient(conn)
+		d.watcher.APIKey = cfg.flightsAPIKey
+		d.flightsAPI = d.watcher
+		// The gRPC service has no health endpoint; listing airlines proves the
+		// service is up and accepts the key.
+		d.flightsHealth = func(ctx context.Context) error {
+			_, err := d.watcher.ListAirlines(ctx)
+			return err
+		}
+	case cfg.flightsAPIURL != "":
+		client := cfg.flightsAPI.newClient(cfg.flightsAPIURL)
+		client.APIKey, client.KeyID = cfg.flightsAPIKey, cfg.flightsAPIKeyID
+		d.breaker = client.Breaker
+		d.flightsAPI = client
+		d.flightsHealth = client.Healthz
+	default:
+		return nil, fmt.Errorf("set --flights-api-url or --flights-grpc-url")
+	}
+
+	submitters, err := cfg.signer.newSigners(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("load submitter keys: %w", err)
+	}
+	if loader.Source("private-key") == settings.SourceFlag {
+		slog.Warn("--private-key is visible to other processes; prefer --private-key-file, --keystore, --signer-url or " + privateKeyEnv)
+	}
+	cfg.keyPool.warnBalance, cfg.keyPool.criticalBalance = etherToWei(cfg.balanceWarn), etherToWei(cfg.balanceCritical)
+	if d.keys, err = newKeyPool(submitters, d.evm, cfg.keyPool, m); err != nil {
+		for _, submitter := range submitters {
+			submitter.Close()
+		}
+		return nil, err
+	}
+	d.closers = append(d.closers, d.keys.close)
+	slog.Info("Submitting transactions", "addresses", d.submitterAddresses(), "selection", cfg.keyPool.selection)
+	d.keys.checkBalances(ctx)
+	return d, nil
+}
+
+func (d *nodeDeps) submitterAddresses() []common.Address {
+	addresses := make([]common.Address, len(d.keys.keys))
+	for i, key := range d.keys.keys {
+		addresses[i] = key.signer.Address()
+	}
+	return addresses
+}
+
+// preflight returns the startup checks for the dialed deployment.
+func (d *nodeDeps) preflight() *preflight {
+	return &preflight{
+		evm:          d.evm,
+		relays:       d.relays,
+		flightsAPI:   d.flightsHealth,
+		contractAddr: common.HexToAddress(cfg.contractAddress),
+		chainID:      d.chainID,
+		submitters:   d.submitterAddresses(),
+		keyTag:       uint32(cfg.keyTag),
+	}
+}
+
+// close releases the clients in the reverse order of dial.
+func (d *nodeDeps) close() {
+	for _, c := range slices.Backward(d.closers) {
+		c()
+	}
+}
+
 func main() {
-	rootCmd.PersistentFlags().StringVar(&cfg.relayAPIURL, "relay-api-url", "", "Relay API URL")
-	rootCmd.PersistentFlags().StringVar(&cfg.evmRPCURL, "evm-rpc-url", "", "Execution client RPC URL")
+	loader = settings.New(rootCmd.PersistentFlags(), envPrefix)
+	cfg.relayGRPC = utils.DefaultGRPCOptions()
+	cfg.relayGRPC.RegisterFlags(rootCmd.PersistentFlags(), "relay-", "relay API")
+	// WatchFlights streams stay idle between changes; keepalive detects a dead peer.
+	cfg.flightsGRPC = utils.DefaultGRPCOptions()
+	cfg.flightsGRPC.KeepaliveTime = 30 * time.Second
+	cfg.flightsGRPC.RegisterFlags(rootCmd.PersistentFlags(), "flights-grpc-", "flights gRPC service")
+	rootCmd.PersistentFlags().StringSliceVar(&cfg.relayAPIURLs, "relay-api-url", nil, "Relay API URL; repeat or comma-separate to fail over between relays")
+	rootCmd.PersistentFlags().DurationVar(&cfg.relayHealthEvery, "relay-health-interval", 10*time.Second, "Interval between relay health checks")
+	rootCmd.PersistentFlags().StringSliceVar(&cfg.evmRPCURLs, "evm-rpc-url", nil, "Execution client RPC URL for reads; repeat or comma-separate to fail over between endpoints")
+	rootCmd.PersistentFlags().StringSliceVar(&cfg.evmSendRPCURLs, "evm-send-rpc-url", nil, "RPC URLs that transactions are broadcast to (default: --evm-rpc-url)")
+	rootCmd.PersistentFlags().Float64Var(&cfg.evmRPCRateLimit, "evm-rpc-rate-limit", 0, "Requests per second allowed per EVM RPC endpoint (0 is unlimited)")
+	rootCmd.PersistentFlags().Uint64Var(&cfg.evmRPCMaxLag, "evm-rpc-max-lag", 5, "Blocks an EVM RPC endpoint may trail the best head before it is only used as a last resort (0 disables the check)")
+	rootCmd.PersistentFlags().DurationVar(&cfg.evmRPCHealthEvery, "evm-rpc-health-interval", 15*time.Second, "Interval between EVM RPC endpoint health checks")
 	rootCmd.PersistentFlags().StringVar(&cfg.contractAddress, "flight-delays-address", "", "FlightDelays contract address")
 	rootCmd.PersistentFlags().StringVar(&cfg.flightsAPIURL, "flights-api-url", "", "Mock flights API URL")
-	rootCmd.PersistentFlags().StringVar(&cfg.privateKeyHex, "private-key", "", "Flight oracle ECDSA private key")
+	rootCmd.PersistentFlags().StringVar(&cfg.flightsGRPCURL, "flights-grpc-url", "", "Flights gRPC service address (host:port); used instead of --flights-api-url when set")
+	rootCmd.PersistentFlags().StringVar(&cfg.flightsAPIKey, "flights-api-key", "", "Read-only flights API key (bearer token, or HMAC secret with --flights-api-key-id)")
+	rootCmd.PersistentFlags().StringVar(&cfg.flightsAPIKeyID, "flights-api-key-id", "", "Key ID to sign flights API requests with HMAC instead of sending --flights-api-key")
+	rootCmd.PersistentFlags().DurationVar(&cfg.flightsAPI.timeout, "flights-api-timeout", 5*time.Second, "Timeout of a single flights API request")
+	rootCmd.PersistentFlags().IntVar(&cfg.flightsAPI.retries, "flights-api-retries", 3, "Attempts per flights API read, including the first")
+	rootCmd.PersistentFlags().DurationVar(&cfg.flightsAPI.retryDelay, "flights-api-retry-delay", 200*time.Millisecond, "Base backoff between flights API read attempts; doubles per attempt with full jitter")
+	rootCmd.PersistentFlags().DurationVar(&cfg.flightsAPI.retryMaxDelay, "flights-api-retry-max-delay", 2*time.Second, "Maximum backoff between flights API read attempts, including Retry-After")
+	rootCmd.PersistentFlags().IntVar(&cfg.flightsAPI.breakerFailures, "flights-api-breaker-failures", 5, "Consecutive failed flights API requests that open the circuit breaker (0 disables it)")
+	rootCmd.PersistentFlags().DurationVar(&cfg.flightsAPI.breakerCooldown, "flights-api-breaker-cooldown", 30*time.Second, "How long the flights API circuit stays open before a probe request")
+	rootCmd.PersistentFlags().StringVar(&cfg.metricsListen, "metrics-listen", "", "Address to serve Prometheus metrics on, e.g. :9102 (disabled when empty)")
+	rootCmd.PersistentFlags().StringVar(&cfg.airlineSigners, "airline-signers", "", "Path to a YAML/JSON map of airlineId to the address whose attestations are required before signing")
+	rootCmd.PersistentFlags().StringSliceVar(&cfg.signer.privateKeys, "private-key", nil, "Flight oracle ECDSA private key in hex; visible in the process list, prefer the options below or "+privateKeyEnv+" (comma-separated)")
+	rootCmd.PersistentFlags().StringSliceVar(&cfg.signer.privateKeyFiles, "private-key-file", nil, "File holding a hex submitter private key; repeat to add keys to the pool")
+	rootCmd.PersistentFlags().StringSliceVar(&cfg.signer.keystores, "keystore", nil, "Encrypted go-ethereum keystore JSON file of a submitter key; repeat to add keys to the pool")
+	rootCmd.PersistentFlags().StringVar(&cfg.signer.keystorePasswordFile, "keystore-password-file", "", "File whose first line is the password of every --keystore")
+	rootCmd.PersistentFlags().StringVar(&cfg.signer.url, "signer-url", "", "Clef-compatible external signer endpoint (HTTP, WebSocket or IPC path) that signs transactions instead of a local key")
+	rootCmd.PersistentFlags().StringSliceVar(&cfg.signer.addresses, "signer-address", nil, "Accounts of the external signer to submit with (default: every account it manages)")
+	rootCmd.PersistentFlags().StringVar(&cfg.keyPool.selection, "submitter-selection", selectLeastLoaded, "How to pick the submitter key per action: least-loaded (fewest unconfirmed actions) or balance (highest balance); ties go round-robin")
+	rootCmd.PersistentFlags().Float64Var(&cfg.balanceWarn, "submitter-balance-warn", 0.1, "Submitter balance in ETH below which a warning is logged")
+	rootCmd.PersistentFlags().Float64Var(&cfg.balanceCritical, "submitter-balance-critical", 0.01, "Submitter balance in ETH below which the key is taken out of rotation")
+	rootCmd.PersistentFlags().DurationVar(&cfg.balanceEvery, "submitter-balance-interval", 30*time.Second, "Interval between submitter balance checks")
 	rootCmd.PersistentFlags().DurationVar(&cfg.pollInterval, "poll-interval", 5*time.Second, "Polling interval for flights API")
 	rootCmd.PersistentFlags().DurationVar(&cfg.proofPollInterval, "proof-poll-interval", 3*time.Second, "Polling interval for settlement proofs")
 	rootCmd.PersistentFlags().StringVar(&cfg.logLevel, "log-level", "info", "Log level (debug,info,warn,error)")
+	rootCmd.PersistentFlags().Uint8Var(&cfg.keyTag, "key-tag", 15, "Relay key tag that signs Settlement messages")
+	cfg.tracing.RegisterFlags(rootCmd.PersistentFlags())
+	rootCmd.AddCommand(preflightCmd)
 
-	_ = rootCmd.MarkPersistentFlagRequired("relay-api-url")
-	_ = rootCmd.MarkPersistentFlagRequired("evm-rpc-url")
-	_ = rootCmd.MarkPersistentFlagRequired("flight-delays-address")
-	_ = rootCmd.MarkPersistentFlagRequired("flights-api-url")
-	_ = rootCmd.MarkPersistentFlagRequired("private-key")
+	loader.Require("relay-api-url", "evm-rpc-url", "flight-delays-address")
+	loader.Secret("private-key", "flights-api-key")
+	loader.SecretURL("evm-rpc-url", "evm-send-rpc-url")
+	loader.Reloadable("log-level", "poll-interval", "proof-poll-interval")
+	loader.Validate = cfg.validate
 
 	if err := rootCmd.Execute(); err != nil {
 		slog.Error("node failed", "error", err)
@@ -206,20 +485,31 @@ type pendingAction struct {
 	Type               actionType
 	Epoch              uint64
 	RequestID          string
-	Proof              []byte
-	Submitted          bool
-	TargetStatus       flightStatus
-	TxHash             common.Hash
-	CreatedAt          time.Time
+	// Relay is the relay that accepted the sign request; any relay may serve the proof.
+	Relay        string
+	Proof        []byte
+	Submitted    bool
+	TargetStatus flightStatus
+	TxHash       common.Hash
+	CreatedAt    time.Time
+
+	// trace is the evaluation span later steps link to; waiting is the open
+	// span of the step the action waits in.
+	trace   trace.SpanContext
+	waiting trace.Span
+	// submitter is the pool key the action was submitted with.
+	submitter *submitterKey
 }
 
 type flightNode struct {
-	relayClient *v1.SymbioticClient
-	ethClient   *ethclient.Client
-	contract    *contracts.FlightDelays
-	chainID     *big.Int
-	privateKey  *ecdsa.PrivateKey
-	flightsAPI  *flightsAPIClient
+	relays     *relayPool
+	evm        *rpcPool
+	contract   *contracts.FlightDelays
+	chainID    *big.Int
+	keys       *keyPool
+	flightsAPI flightsSource
+	// signers, when set, must have attested a flight's state before it is signed.
+	signers airlineSigners
 
 	pending map[string]*pendingAction
 }
@@ -231,6 +521,10 @@ func (n *flightNode) syncFlights(ctx context.Context) error {
 	}
 	for _, airline := range airlines {
 		flightsForAirline, err := n.flightsAPI.ListFlights(ctx, airline.AirlineID)
+		if errors.Is(err, flights.ErrAirlineNotFound) {
+			slog.Debug("airline disappeared between listings", "airline", airline.AirlineID)
+			continue
+		}
 		if err != nil {
 			slog.Warn("list flights failed", "airline", airline.AirlineID, "error", err)
 			continue
@@ -286,9 +580,17 @@ func (n *flightNode) evaluateFlight(ctx context.Context, airline flights.Airline
 	if _, exists := n.pending[key]; exists {
 		return nil
 	}
+	ctx, span := startEvaluate(ctx, flight, nextAction)
+	defer span.End()
+	span.SetAttributes(attribute.String("flight.status", string(flight.Status)), attribute.Int("flight.chain_status", int(onChainStatus)))
+	if n.signers != nil {
+		if err := n.signers.verify(flight); err != nil {
+			return failSpan(span, fmt.Errorf("refusing to sign %s: %w", nextAction, err))
+		}
+	}
 
 	if err := n.enqueueAction(ctx, key, nextAction, airline, flight, airlineHash, flightHash, previousFlightHash); err != nil {
-		return err
+		return failSpan(span, err)
 	}
 	return nil
 }
@@ -319,10 +621,15 @@ func (n *flightNode) enqueueAction(ctx context.Context, key string, action actio
 		return err
 	}
 
-	epoch, requestID, err := n.requestSignature(ctx, payload)
+	signCtx, span := tracer.Start(ctx, "flight.sign")
+	epoch, requestID, relay, err := n.requestSignature(signCtx, payload)
 	if err != nil {
-		return fmt.Errorf("sign message: %w", err)
+		err = failSpan(span, fmt.Errorf("sign message: %w", err))
+		span.End()
+		return err
 	}
+	span.SetAttributes(attribute.Int64("relay.epoch", int64(epoch)), attribute.String("relay.request_id", requestID), attribute.String("relay.url", relay))
+	span.End()
 
 	pending := &pendingAction{
 		Key:                key,
@@ -334,19 +641,24 @@ func (n *flightNode) enqueueAction(ctx context.Context, key string, action actio
 		Type:               action,
 		Epoch:              epoch,
 		RequestID:          requestID,
+		Relay:              relay,
 		TargetStatus:       targetStatusFor(action),
 		CreatedAt:          time.Now(),
+		trace:              trace.SpanContextFromContext(ctx),
 	}
+	pending.await(ctx, "flight.proof")
 	n.pending[key] = pending
 
-	slog.Info("scheduled flight action", "airline", airline.AirlineID, "flight", flight.FlightID, "action", string(action), "epoch", epoch)
+	slog.Info("scheduled flight action", "airline", airline.AirlineID, "flight", flight.FlightID, "action", string(action), "epoch", epoch, "relay", relay)
 	return nil
 }
 
-func (n *flightNode) requestSignature(ctx context.Context, payload []byte) (uint64, string, error) {
-	epochInfos, err := n.relayClient.GetLastAllCommitted(ctx, &v1.GetLastAllCommittedRequest{})
+// requestSignature asks a healthy relay to sign payload and returns the epoch,
+// the request ID and the relay that accepted it.
+func (n *flightNode) requestSignature(ctx context.Context, payload []byte) (uint64, string, string, error) {
+	epochInfos, err := n.relays.lastAllCommitted(ctx)
 	if err != nil {
-		return 0, "", fmt.Errorf("last committed: %w", err)
+		return 0, "", "", fmt.Errorf("last committed: %w", err)
 	}
 	var suggestedEpoch uint64
 	for _, info := range epochInfos.EpochInfos {
@@ -356,15 +668,15 @@ func (n *flightNode) requestSignature(ctx context.Context, payload []byte) (uint
 		}
 	}
 
-	resp, err := n.relayClient.SignMessage(ctx, &v1.SignMessageRequest{
-		KeyTag:        keyTag,
+	resp, relay, err := n.relays.signMessage(ctx, &v1.SignMessageRequest{
+		KeyTag:        uint32(cfg.keyTag),
 		Message:       payload,
 		RequiredEpoch: &suggestedEpoch,
 	})
 	if err != nil {
-		return 0, "", err
+		return 0, "", "", err
 	}
-	return resp.Epoch, resp.RequestId, nil
+	return resp.Epoch, resp.RequestId, relay, nil
 }
 
 func (n *flightNode) fetchProofs(ctx context.Context) error {
@@ -372,14 +684,16 @@ func (n *flightNode) fetchProofs(ctx context.Context) error {
 		if action.Proof != nil {
 			continue
 		}
-		resp, err := n.relayClient.GetAggregationProof(ctx, &v1.GetAggregationProofRequest{RequestId: action.RequestID})
+		proof, err := n.relays.aggregationProof(action.context(ctx), action.RequestID)
 		if err != nil {
+			slog.Debug("fetch aggregation proof failed", "requestId", action.RequestID, "error", err)
 			continue
 		}
-		if resp.GetAggregationProof() == nil {
+		if proof == nil {
 			continue
 		}
-		action.Proof = resp.AggregationProof.Proof
+		action.Proof = proof.Proof
+		action.endWait()
 		slog.Info("aggregation proof ready", "airline", action.Airline.AirlineID, "flight", action.Flight.FlightID, "action", string(action.Type))
 	}
 	return nil
@@ -400,7 +714,9 @@ func (n *flightNode) submitReadyActions(ctx context.Context) error {
 				continue
 			}
 		}
-		if err := n.submitAction(ctx, action); err != nil {
+		if err := n.submitAction(ctx, action); errors.Is(err, errNoSubmitter) {
+			return fmt.Errorf("refusing to submit %s for %s/%s: %w", action.Type, action.Airline.AirlineID, action.Flight.FlightID, err)
+		} else if err != nil {
 			slog.Warn("submit action failed", "airline", action.Airline.AirlineID, "flight", action.Flight.FlightID, "action", string(action.Type), "error", err)
 			continue
 		}
@@ -410,44 +726,53 @@ func (n *flightNode) submitReadyActions(ctx context.Context) error {
 }
 
 func (n *flightNode) submitAction(ctx context.Context, action *pendingAction) error {
-	txOpts, err := bind.NewKeyedTransactorWithChainID(n.privateKey, n.chainID)
+	ctx, span := action.startStep(ctx, "flight.submit")
+	defer span.End()
+	key, err := n.keys.acquire()
 	if err != nil {
-		return err
+		return failSpan(span, err)
+	}
+	from := key.signer.Address()
+	span.SetAttributes(attribute.String("tx.from", from.Hex()))
+	txOpts := &bind.TransactOpts{
+		From:    from,
+		Context: ctx,
+		Signer: func(address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+			if address != from {
+				return nil, bind.ErrNotAuthorized
+			}
+			return key.signer.SignTx(ctx, tx, n.chainID)
+		},
+	}
+	tx, err := n.sendAction(txOpts, action)
+	if err != nil {
+		n.keys.failed(key, err)
+		return failSpan(span, err)
 	}
-	txOpts.Context = ctx
 
-	epoch := big.NewInt(int64(action.Epoch))
-	var txHash common.Hash
+	action.Submitted = true
+	action.TxHash = tx.Hash()
+	action.submitter = key
+	span.SetAttributes(attribute.String("tx.hash", tx.Hash().Hex()))
+	action.await(ctx, "flight.confirm")
+	slog.Info("submitted flight action", "airline", action.Airline.AirlineID, "flight", action.Flight.FlightID, "action", string(action.Type), "tx", tx.Hash().Hex(), "from", from.Hex())
+	return nil
+}
 
+func (n *flightNode) sendAction(txOpts *bind.TransactOpts, action *pendingAction) (*types.Transaction, error) {
+	epoch := big.NewInt(int64(action.Epoch))
 	switch action.Type {
 	case actionCreate:
 		scheduled := big.NewInt(action.Flight.DepartureTimestamp)
 		prev := [32]byte(action.PreviousFlightHash)
-		tx, err := n.contract.CreateFlight(txOpts, action.AirlineHash, action.FlightHash, scheduled, prev, epoch, action.Proof)
-		if err != nil {
-			return err
-		}
-		txHash = tx.Hash()
+		return n.contract.CreateFlight(txOpts, action.AirlineHash, action.FlightHash, scheduled, prev, epoch, action.Proof)
 	case actionDelay:
-		tx, err := n.contract.DelayFlight(txOpts, action.AirlineHash, action.FlightHash, epoch, action.Proof)
-		if err != nil {
-			return err
-		}
-		txHash = tx.Hash()
+		return n.contract.DelayFlight(txOpts, action.AirlineHash, action.FlightHash, epoch, action.Proof)
 	case actionDepart:
-		tx, err := n.contract.DepartFlight(txOpts, action.AirlineHash, action.FlightHash, epoch, action.Proof)
-		if err != nil {
-			return err
-		}
-		txHash = tx.Hash()
+		return n.contract.DepartFlight(txOpts, action.AirlineHash, action.FlightHash, epoch, action.Proof)
 	default:
-		return fmt.Errorf("unknown action %s", action.Type)
+		return nil, fmt.Errorf("unknown action %s", action.Type)
 	}
-
-	action.Submitted = true
-	action.TxHash = txHash
-	slog.Info("submitted flight action", "airline", action.Airline.AirlineID, "flight", action.Flight.FlightID, "action", string(action.Type), "tx", txHash.Hex())
-	return nil
 }
 
 func (n *flightNode) canSubmitCreate(ctx context.Context, action *pendingAction) (bool, error) {
@@ -463,6 +788,13 @@ func (n *flightNode) clearSatisfiedPending(airlineHash, flightHash common.Hash,
 	for key, action := range n.pending {
 		if action.AirlineHash == airlineHash && action.FlightHash == flightHash && action.TargetStatus == status {
 			slog.Info("action confirmed on-chain", "airline", action.Airline.AirlineID, "flight", action.Flight.FlightID, "action", string(action.Type))
+			if action.waiting != nil {
+				action.waiting.AddEvent("confirmed on-chain")
+			}
+			action.endWait()
+			if action.submitter != nil {
+				n.keys.release(action.submitter)
+			}
 			delete(n.pending, key)
 		}
 	}
@@ -512,59 +844,59 @@ func actionKey(airlineHash, flightHash common.Hash, action actionType) string {
 	return fmt.Sprintf("%s|%s|%s", airlineHash.Hex(), flightHash.Hex(), action)
 }
 
-type flightsAPIClient struct {
-	baseURL    string
-	httpClient *http.Client
+// flightsAPIConfig tunes the REST flights client.
+type flightsAPIConfig struct {
+	timeout         time.Duration
+	retries         int
+	retryDelay      time.Duration
+	retryMaxDelay   time.Duration
+	breakerFailures int
+	breakerCooldown time.Duration
 }
 
-func newFlightsAPIClient(baseURL string) *flightsAPIClient {
-	return &flightsAPIClient{
-		baseURL:    strings.TrimSuffix(baseURL, "/"),
-		httpClient: &http.Client{Timeout: 5 * time.Second},
-	}
+// newClient returns a client that retries reads, revalidates them by ETag and,
+// unless disabled, stops calling a failing API until the cooldown passes.
+func (c flightsAPIConfig) newClient(baseURL string) *flights.Client {
+	client := flights.NewClient(baseURL)
+	client.HTTPClient.Timeout = c.timeout
+	client.Retry = flights.RetryPolicy{Attempts: c.retries, BaseDelay: c.retryDelay, MaxDelay: c.retryMaxDelay}
+	client.Cache = flights.NewResponseCache()
+	if c.breakerFailures > 0 {
+		client.Breaker = flights.NewCircuitBreaker(c.breakerFailures, c.breakerCooldown)
+	}
+	return client
 }
 
-func (c *flightsAPIClient) ListAirlines(ctx context.Context) ([]flights.Airline, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/airlines", nil)
-	if err != nil {
-		return nil, err
-	}
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("airlines request status %d", resp.StatusCode)
-	}
-	var body struct {
-		Airlines []flights.Airline `json:"airlines"`
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
-		return nil, err
-	}
-	return body.Airlines, nil
+// flightsSource lists airlines and flights from the REST or gRPC flights API.
+type flightsSource interface {
+	ListAirlines(ctx context.Context) ([]flights.Airline, error)
+	ListFlights(ctx context.Context, airlineID string) ([]flights.Flight, error)
 }
 
-func (c *flightsAPIClient) ListFlights(ctx context.Context, airlineID string) ([]flights.Flight, error) {
-	endpoint := fmt.Sprintf("%s/airlines/%s/flights", c.baseURL, url.PathEscape(airlineID))
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
-	if err != nil {
-		return nil, err
-	}
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("flights request status %d", resp.StatusCode)
-	}
-	var body struct {
-		Flights []flights.Flight `json:"flights"`
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
-		return nil, err
+// watchFlights signals changed whenever a flight changes, resubscribing after
+// retryDelay when the stream ends.
+func watchFlights(ctx context.Context, client *flightspb.Client, changed chan<- struct{}, retryDelay time.Duration) {
+	for {
+		err := client.WatchFlights(ctx, "", false, func(flights.Flight) error {
+			select {
+			case changed <- struct{}{}:
+			default:
+			}
+			return nil
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		slog.Warn("flights watch ended; resubscribing", "error", err, "retryIn", retryDelay)
+		// Changes missed while reconnecting are picked up by this sync.
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(retryDelay):
+		}
 	}
-	return body.Flights, nil
 }
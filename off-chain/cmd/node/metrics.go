@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"sum/internal/utils"
+)
+
+// metrics holds the node's Prometheus collectors.
+type metrics struct {
+	registry *prometheus.Registry
+
+	flightsErrors       *prometheus.CounterVec
+	flightsStale        *prometheus.GaugeVec
+	flightsDataAge      *prometheus.GaugeVec
+	flightsCircuitOpen  prometheus.Gauge
+	relayUp             *prometheus.GaugeVec
+	rpcScore            *prometheus.GaugeVec
+	rpcHead             *prometheus.GaugeVec
+	rpcErrors           *prometheus.CounterVec
+	submitterBalance    *prometheus.GaugeVec
+	submitterInFlight   *prometheus.GaugeVec
+	submittersAvailable prometheus.Gauge
+	grpcClient          *utils.GRPCClientMetrics
+}
+
+func newMetrics() *metrics {
+	m := &metrics{
+		registry: prometheus.NewRegistry(),
+		flightsErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "flight_node_flights_api_errors_total",
+			Help: "Failed flights API calls by call.",
+		}, []string{"call"}),
+		flightsStale: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "flight_node_flights_data_stale",
+			Help: "1 while the node evaluates cached flights because the flights API is failing; airline=\"\" is the airline list.",
+		}, []string{"airline"}),
+		flightsDataAge: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "flight_node_flights_data_age_seconds",
+			Help: "Age of the flights data last evaluated; airline=\"\" is the airline list.",
+		}, []string{"airline"}),
+		flightsCircuitOpen: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "flight_node_flights_api_circuit_open",
+			Help: "1 while the flights API circuit breaker is open or probing.",
+		}),
+		relayUp: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "flight_node_relay_up",
+			Help: "1 while the relay passes health checks.",
+		}, []string{"relay"}),
+		rpcScore: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "flight_node_evm_rpc_score",
+			Help: "Moving success rate of the EVM RPC endpoint, from 0 to 1.",
+		}, []string{"endpoint"}),
+		rpcHead: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "flight_node_evm_rpc_head",
+			Help: "Latest block number reported by the EVM RPC endpoint.",
+		}, []string{"endpoint"}),
+		rpcErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "flight_node_evm_rpc_errors_total",
+			Help: "Failed requests to the EVM RPC endpoint.",
+		}, []string{"endpoint"}),
+		submitterBalance: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "flight_node_submitter_balance_ether",
+			Help: "Last checked balance of the submitter key.",
+		}, []string{"address"}),
+		submitterInFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "flight_node_submitter_in_flight",
+			Help: "Actions submitted with the key and not yet confirmed on-chain.",
+		}, []string{"address"}),
+		submittersAvailable: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "flight_node_submitters_available",
+			Help: "Submitter keys above the critical balance.",
+		}),
+	}
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.flightsErrors, m.flightsStale, m.flightsDataAge, m.flightsCircuitOpen, m.relayUp, m.rpcScore, m.rpcHead, m.rpcErrors,
+		m.submitterBalance, m.submitterInFlight, m.submittersAvailable,
+	)
+	m.grpcClient = utils.NewGRPCClientMetrics(m.registry, "flight_node")
+	return m
+}
+
+// serve exposes /metrics on addr until ctx is done.
+func (m *metrics) serve(ctx context.Context, addr string) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{}))
+	server := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+	slog.Info("serving metrics", "addr", addr)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		slog.Error("metrics server failed", "error", err)
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"slices"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	v1 "github.com/symbioticfi/relay/api/client/v1"
+
+	"sum/internal/contracts"
+)
+
+// preflightTimeout bounds a single preflight check.
+const preflightTimeout = 10 * time.Second
+
+// preflightChain is the part of the EVM backend the preflight checks need.
+type preflightChain interface {
+	bind.ContractCaller
+	balanceReader
+}
+
+// preflightRelay is the part of the relay pool the preflight checks need.
+type preflightRelay interface {
+	lastAllCommitted(ctx context.Context) (*v1.GetLastAllCommittedResponse, error)
+	validatorSet(ctx context.Context) (*v1.GetValidatorSetResponse, error)
+}
+
+// preflight verifies, before the node starts, that its settings point at a
+// working deployment. Each failure says what to fix rather than leaving the
+// node to retry the same mistake in its main loop.
+type preflight struct {
+	evm          preflightChain
+	relays       preflightRelay
+	flightsAPI   func(ctx context.Context) error
+	contractAddr common.Address
+	chainID      *big.Int
+	submitters   []common.Address
+	keyTag       uint32
+}
+
+type preflightCheck struct {
+	name string
+	run  func(ctx context.Context) error
+}
+
+func (p *preflight) checks() []preflightCheck {
+	return []preflightCheck{
+		{"contract", p.checkContract},
+		{"relay-epochs", p.checkRelayEpochs},
+		{"relay-key-tag", p.checkKeyTag},
+		{"submitter-balance", p.checkBalances},
+		{"flights-api", p.checkFlightsAPI},
+	}
+}
+
+// run runs every check, so one start reports every problem, and returns the
+// failures joined.
+func (p *preflight) run(ctx context.Context) error {
+	var errs []error
+	for _, check := range p.checks() {
+		checkCtx, cancel := context.WithTimeout(ctx, preflightTimeout)
+		err := check.run(checkCtx)
+		cancel()
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			slog.Error("preflight check failed", "check", check.name, "error", err)
+			errs = append(errs, fmt.Errorf("%s: %w", check.name, err))
+			continue
+		}
+		slog.Info("preflight check passed", "check", check.name)
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("preflight failed: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+func (p *preflight) checkContract(ctx context.Context) error {
+	code, err := p.evm.CodeAt(ctx, p.contractAddr, nil)
+	if err != nil {
+		return fmt.Errorf("fetch code at %s: %w", p.contractAddr.Hex(), err)
+	}
+	if len(code) == 0 {
+		return fmt.Errorf("no contract deployed at %s on chain %s; check that --flight-delays-address and --evm-rpc-url point at the same network", p.contractAddr.Hex(), p.chainID)
+	}
+	caller, err := contracts.NewFlightDelaysCaller(p.contractAddr, p.evm)
+	if err != nil {
+		return err
+	}
+	opts := &bind.CallOpts{Context: ctx}
+	if _, err := caller.Settlement(opts); err != nil {
+		return fmt.Errorf("contract at %s does not answer Settlement(); is --flight-delays-address a FlightDelays deployment? %w", p.contractAddr.Hex(), err)
+	}
+	if _, err := caller.VotingPowers(opts); err != nil {
+		return fmt.Errorf("contract at %s does not answer VotingPowers(); is --flight-delays-address a FlightDelays deployment? %w", p.contractAddr.Hex(), err)
+	}
+	return nil
+}
+
+func (p *preflight) checkRelayEpochs(ctx context.Context) error {
+	resp, err := p.relays.lastAllCommitted(ctx)
+	if err != nil {
+		return fmt.Errorf("no relay answered GetLastAllCommitted; check --relay-api-url and that the relay is running: %w", err)
+	}
+	infos := resp.GetEpochInfos()
+	if _, ok := infos[p.chainID.Uint64()]; ok {
+		return nil
+	}
+	chains := make([]uint64, 0, len(infos))
+	for chain := range infos {
+		chains = append(chains, chain)
+	}
+	slices.Sort(chains)
+	return fmt.Errorf("the relay commits epochs to chains %v but not to chain %s of --evm-rpc-url; point the node at the relay's settlement chain", chains, p.chainID)
+}
+
+func (p *preflight) checkKeyTag(ctx context.Context) error {
+	set, err := p.relays.validatorSet(ctx)
+	if err != nil {
+		return fmt.Errorf("no relay answered GetValidatorSet; check --relay-api-url and that the relay is running: %w", err)
+	}
+	tags := []uint32{set.GetRequiredKeyTag()}
+	for _, validator := range set.GetValidators() {
+		for _, key := range validator.GetKeys() {
+			if !slices.Contains(tags, key.GetTag()) {
+				tags = append(tags, key.GetTag())
+			}
+		}
+	}
+	if slices.Contains(tags, p.keyTag) {
+		return nil
+	}
+	slices.Sort(tags)
+	return fmt.Errorf("--key-tag %d is not among the key tags the relay advertises %v; set --key-tag to the tag the relay signs with", p.keyTag, tags)
+}
+
+func (p *preflight) checkBalances(ctx context.Context) error {
+	var errs []error
+	for _, address := range p.submitters {
+		balance, err := p.evm.BalanceAt(ctx, address, nil)
+		switch {
+		case err != nil:
+			errs = append(errs, fmt.Errorf("fetch balance of %s: %w", address.Hex(), err))
+		case balance.Sign() == 0:
+			errs = append(errs, fmt.Errorf("submitter %s has no funds on chain %s; fund it or remove it from the pool", address.Hex(), p.chainID))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (p *preflight) checkFlightsAPI(ctx context.Context) error {
+	if err := p.flightsAPI(ctx); err != nil {
+		return fmt.Errorf("flights API is not answering; check --flights-api-url or --flights-grpc-url and the API key: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	v1 "github.com/symbioticfi/relay/api/client/v1"
+)
+
+// fakeChain has code at the addresses in code, answers every call with an
+// address and reads balances from fakeBalances.
+type fakeChain struct {
+	fakeBalances
+	code map[common.Address][]byte
+}
+
+func (c *fakeChain) CodeAt(_ context.Context, contract common.Address, _ *big.Int) ([]byte, error) {
+	return c.code[contract], nil
+}
+
+func (c *fakeChain) CallContract(_ context.Context, msg ethereum.CallMsg, _ *big.Int) ([]byte, error) {
+	if len(c.code[*msg.To]) == 0 {
+		return nil, nil
+	}
+	return common.LeftPadBytes(common.HexToAddress("0x01").Bytes(), 32), nil
+}
+
+type fakeRelay struct {
+	chains []uint64
+	tags   []uint32
+}
+
+func (r *fakeRelay) lastAllCommitted(context.Context) (*v1.GetLastAllCommittedResponse, error) {
+	infos := map[uint64]*v1.ChainEpochInfo{}
+	for _, chain := range r.chains {
+		infos[chain] = &v1.ChainEpochInfo{LastCommittedEpoch: 1}
+	}
+	return &v1.GetLastAllCommittedResponse{EpochInfos: infos}, nil
+}
+
+func (r *fakeRelay) validatorSet(context.Context) (*v1.GetValidatorSetResponse, error) {
+	var keys []*v1.Key
+	for _, tag := range r.tags {
+		keys = append(keys, &v1.Key{Tag: tag})
+	}
+	return &v1.GetValidatorSetResponse{RequiredKeyTag: r.tags[0], Validators: []*v1.Validator{{Keys: keys}}}, nil
+}
+
+func TestPreflightReportsEveryMisconfiguration(t *testing.T) {
+	contract := common.HexToAddress("0xA4b0f5eb09891c1538494c4989Eea0203b1153b1")
+	submitter := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	newPreflight := func() (*preflight, *fakeChain, *fakeRelay) {
+		chain := &fakeChain{
+			fakeBalances: fakeBalances{balances: map[common.Address]*big.Int{submitter: etherToWei(1)}},
+			code:         map[common.Address][]byte{contract: {0x60, 0x80}},
+		}
+		relay := &fakeRelay{chains: []uint64{31337}, tags: []uint32{15}}
+		return &preflight{
+			evm:          chain,
+			relays:       relay,
+			flightsAPI:   func(context.Context) error { return nil },
+			contractAddr: contract,
+			chainID:      big.NewInt(31337),
+			submitters:   []common.Address{submitter},
+			keyTag:       15,
+		}, chain, relay
+	}
+
+	p, _, _ := newPreflight()
+	if err := p.run(context.Background()); err != nil {
+		t.Fatalf("expected a working deployment to pass, got %v", err)
+	}
+
+	for name, tc := range map[string]struct {
+		breakIt func(*preflight, *fakeChain, *fakeRelay)
+		want    string
+	}{
+		"no contract":   {func(p *preflight, _ *fakeChain, _ *fakeRelay) { p.contractAddr = submitter }, "contract: no contract deployed at"},
+		"wrong chain":   {func(_ *preflight, _ *fakeChain, r *fakeRelay) { r.chains = []uint64{1, 17000} }, "chains [1 17000] but not to chain 31337"},
+		"wrong key tag": {func(p *preflight, _ *fakeChain, _ *fakeRelay) { p.keyTag = 16 }, "--key-tag 16 is not among the key tags the relay advertises [15]"},
+		"no funds":      {func(_ *preflight, c *fakeChain, _ *fakeRelay) { c.set(submitter, 0) }, "submitter " + submitter.Hex() + " has no funds"},
+		"flights down": {func(p *preflight, _ *fakeChain, _ *fakeRelay) {
+			p.flightsAPI = func(context.Context) error { return errors.New("connection refused") }
+		}, "flights-api: flights API is not answering"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			p, chain, relay := newPreflight()
+			tc.breakIt(p, chain, relay)
+			err := p.run(context.Background())
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("expected an error mentioning %q, got %v", tc.want, err)
+			}
+			if strings.Count(err.Error(), "\n") != 0 {
+				t.Fatalf("expected only the broken check to fail, got %v", err)
+			}
+		})
+	}
+}
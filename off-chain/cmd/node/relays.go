@@ -0,0 +1,222 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	v1 "github.com/symbioticfi/relay/api/client/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"sum/internal/utils"
+)
+
+// relayHealthTimeout bounds a single GetLastAllCommitted health check.
+const relayHealthTimeout = 3 * time.Second
+
+// relayEndpoint is one relay sidecar the node can talk to.
+type relayEndpoint struct {
+	url    string
+	conn   *grpc.ClientConn
+	client *v1.SymbioticClient
+
+	healthy bool
+	lastErr error
+}
+
+// relayPool routes relay calls across several sidecars. Sign requests go to the
+// first healthy relay in flag order and fail over to the next one; aggregation
+// proofs are looked up on every healthy relay, since any relay in the network
+// can serve a proof for a request another relay accepted.
+type relayPool struct {
+	metrics *metrics
+
+	mu        sync.Mutex
+	endpoints []*relayEndpoint
+}
+
+func newRelayPool(urls []string, opts utils.GRPCOptions, m *metrics) (*relayPool, error) {
+	if len(urls) == 0 {
+		return nil, errors.New("no relay endpoints")
+	}
+	p := &relayPool{metrics: m}
+	for _, url := range urls {
+		conn, err := utils.GetGRPCConnection(url, opts)
+		if err != nil {
+			p.close()
+			return nil, fmt.Errorf("relay %s: %w", url, err)
+		}
+		// Endpoints start healthy so that the node can work before the first check.
+		p.endpoints = append(p.endpoints, &relayEndpoint{url: url, conn: conn, client: v1.NewSymbioticClient(conn), healthy: true})
+	}
+	return p, nil
+}
+
+func (p *relayPool) close() {
+	for _, e := range p.endpoints {
+		e.conn.Close()
+	}
+}
+
+// checkHealth calls GetLastAllCommitted on every relay.
+func (p *relayPool) checkHealth(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, e := range p.endpoints {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, relayHealthTimeout)
+			defer cancel()
+			_, err := e.client.GetLastAllCommitted(checkCtx, &v1.GetLastAllCommittedRequest{})
+			if ctx.Err() == nil {
+				p.setHealth(e, err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// monitor rechecks every relay each interval until ctx is done.
+func (p *relayPool) monitor(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			p.checkHealth(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (p *relayPool) setHealth(e *relayEndpoint, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	healthy := err == nil
+	switch {
+	case healthy && !e.healthy:
+		slog.Info("relay recovered", "relay", e.url)
+	case !healthy && e.healthy:
+		slog.Warn("relay unhealthy", "relay", e.url, "error", err)
+	}
+	e.healthy, e.lastErr = healthy, err
+	up := 0.0
+	if healthy {
+		up = 1
+	}
+	p.metrics.relayUp.WithLabelValues(e.url).Set(up)
+}
+
+// candidates returns the healthy relays in flag order, or every relay when
+// none is known to be healthy.
+func (p *relayPool) candidates() []*relayEndpoint {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var healthy []*relayEndpoint
+	for _, e := range p.endpoints {
+		if e.healthy {
+			healthy = append(healthy, e)
+		}
+	}
+	if len(healthy) == 0 {
+		return append([]*relayEndpoint(nil), p.endpoints...)
+	}
+	return healthy
+}
+
+// unreachable reports whether err means the relay itself is down, as opposed
+// to it rejecting the request.
+func unreachable(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.Canceled:
+		return true
+	}
+	return false
+}
+
+// lastAllCommitted asks the healthy relays in turn for the last committed epochs.
+func (p *relayPool) lastAllCommitted(ctx context.Context) (*v1.GetLastAllCommittedResponse, error) {
+	var errs []error
+	for _, e := range p.candidates() {
+		resp, err := e.client.GetLastAllCommitted(ctx, &v1.GetLastAllCommittedRequest{})
+		if err == nil {
+			return resp, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", e.url, err))
+		if ctx.Err() != nil {
+			break
+		}
+		if unreachable(err) {
+			p.setHealth(e, err)
+		}
+	}
+	return nil, errors.Join(errs...)
+}
+
+// validatorSet asks the healthy relays in turn for the current validator set.
+func (p *relayPool) validatorSet(ctx context.Context) (*v1.GetValidatorSetResponse, error) {
+	var errs []error
+	for _, e := range p.candidates() {
+		resp, err := e.client.GetValidatorSet(ctx, &v1.GetValidatorSetRequest{})
+		if err == nil {
+			return resp, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", e.url, err))
+		if ctx.Err() != nil {
+			break
+		}
+		if unreachable(err) {
+			p.setHealth(e, err)
+		}
+	}
+	return nil, errors.Join(errs...)
+}
+
+// signMessage submits req to the first healthy relay that accepts it and
+// returns that relay's URL with the response.
+func (p *relayPool) signMessage(ctx context.Context, req *v1.SignMessageRequest) (*v1.SignMessageResponse, string, error) {
+	var errs []error
+	for _, e := range p.candidates() {
+		resp, err := e.client.SignMessage(ctx, req)
+		if err == nil {
+			return resp, e.url, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", e.url, err))
+		if ctx.Err() != nil || !unreachable(err) {
+			// The relay answered; another one would reject the request the same way.
+			break
+		}
+		p.setHealth(e, err)
+		slog.Warn("relay unavailable; failing over", "relay", e.url, "error", err)
+	}
+	return nil, "", errors.Join(errs...)
+}
+
+// aggregationProof looks the proof of requestID up on the healthy relays and
+// returns the first one found, or nil when no relay has it yet.
+func (p *relayPool) aggregationProof(ctx context.Context, requestID string) (*v1.AggregationProof, error) {
+	var errs []error
+	for _, e := range p.candidates() {
+		resp, err := e.client.GetAggregationProof(ctx, &v1.GetAggregationProofRequest{RequestId: requestID})
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, err
+			}
+			if unreachable(err) {
+				p.setHealth(e, err)
+				errs = append(errs, fmt.Errorf("%s: %w", e.url, err))
+			}
+			continue
+		}
+		if proof := resp.GetAggregationProof(); proof != nil {
+			return proof, nil
+		}
+	}
+	return nil, errors.Join(errs...)
+}
@@ -0,0 +1,421 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// rpcScoreWeight is the weight of the latest outcome in an endpoint's score.
+	rpcScoreWeight = 0.2
+	// rpcMaxBackoff caps how long a failing endpoint is skipped.
+	rpcMaxBackoff = time.Minute
+	// rpcHealthTimeout bounds a single eth_blockNumber health check.
+	rpcHealthTimeout = 3 * time.Second
+)
+
+// rpcEndpoint is one execution client RPC URL.
+type rpcEndpoint struct {
+	// name identifies the endpoint in logs and metrics without the path or
+	// credentials, which often hold provider API keys.
+	name    string
+	client  *ethclient.Client
+	limiter *rate.Limiter
+
+	mu       sync.Mutex
+	score    float64
+	failures int
+	retryAt  time.Time
+	head     uint64
+	lagging  bool
+}
+
+// available reports whether the endpoint is outside its failure backoff and
+// keeping up with the chain.
+func (e *rpcEndpoint) available(now time.Time) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return !e.lagging && !now.Before(e.retryAt)
+}
+
+func (e *rpcEndpoint) currentScore() float64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.score
+}
+
+// rpcPoolConfig configures an rpcPool.
+type rpcPoolConfig struct {
+	readURLs []string
+	// sendURLs receive transactions and the calls that prepare them (nonce, gas);
+	// they default to readURLs.
+	sendURLs []string
+	// rateLimit is the request rate allowed per endpoint; zero is unlimited.
+	rateLimit float64
+	// maxLag is how many blocks an endpoint may trail the best head before it is
+	// only used as a last resort.
+	maxLag uint64
+}
+
+// rpcPool is a bind.ContractBackend spread over several RPC endpoints. Reads
+// go to the best scoring read endpoint and fail over to the next one on
+// transport errors; transactions are broadcast to every send endpoint.
+type rpcPool struct {
+	read    []*rpcEndpoint
+	send    []*rpcEndpoint
+	all     []*rpcEndpoint
+	maxLag  uint64
+	metrics *metrics
+	now     func() time.Time
+}
+
+var _ bind.ContractBackend = (*rpcPool)(nil)
+
+func newRPCPool(ctx context.Context, cfg rpcPoolConfig, m *metrics) (*rpcPool, error) {
+	if len(cfg.readURLs) == 0 {
+		return nil, errors.New("no EVM RPC endpoints")
+	}
+	if len(cfg.sendURLs) == 0 {
+		cfg.sendURLs = cfg.readURLs
+	}
+	p := &rpcPool{maxLag: cfg.maxLag, metrics: m, now: time.Now}
+	byURL := make(map[string]*rpcEndpoint)
+	endpoint := func(rawURL string) (*rpcEndpoint, error) {
+		if e, ok := byURL[rawURL]; ok {
+			return e, nil
+		}
+		client, err := ethclient.DialContext(ctx, rawURL)
+		if err != nil {
+			return nil, fmt.Errorf("dial %s: %w", endpointName(rawURL), err)
+		}
+		e := &rpcEndpoint{name: endpointName(rawURL), client: client, score: 1}
+		if cfg.rateLimit > 0 {
+			e.limiter = rate.NewLimiter(rate.Limit(cfg.rateLimit), max(1, int(cfg.rateLimit)))
+		}
+		byURL[rawURL] = e
+		p.all = append(p.all, e)
+		return e, nil
+	}
+	for _, set := range []struct {
+		urls []string
+		dst  *[]*rpcEndpoint
+	}{{cfg.readURLs, &p.read}, {cfg.sendURLs, &p.send}} {
+		for _, u := range set.urls {
+			e, err := endpoint(u)
+			if err != nil {
+				p.Close()
+				return nil, err
+			}
+			*set.dst = append(*set.dst, e)
+		}
+	}
+	return p, nil
+}
+
+// endpointName strips everything but the scheme and host from an RPC URL.
+func endpointName(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return "rpc"
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+// Close closes every endpoint.
+func (p *rpcPool) Close() {
+	for _, e := range p.all {
+		e.client.Close()
+	}
+}
+
+// ChainID asks every endpoint for its chain ID and fails unless those that
+// answer agree, so that a misconfigured URL cannot receive transactions.
+func (p *rpcPool) ChainID(ctx context.Context) (*big.Int, error) {
+	var (
+		chainID *big.Int
+		errs    []error
+	)
+	for _, e := range p.all {
+		callCtx, cancel := context.WithTimeout(ctx, rpcHealthTimeout)
+		id, err := e.client.ChainID(callCtx)
+		cancel()
+		p.record(e, err)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", e.name, err))
+			continue
+		}
+		if chainID != nil && chainID.Cmp(id) != 0 {
+			return nil, fmt.Errorf("%s is on chain %s, other endpoints on %s", e.name, id, chainID)
+		}
+		chainID = id
+	}
+	if chainID == nil {
+		return nil, errors.Join(errs...)
+	}
+	for _, err := range errs {
+		slog.Warn("EVM RPC endpoint unavailable at startup", "error", err)
+	}
+	return chainID, nil
+}
+
+// checkHealth polls every endpoint's head and flags endpoints trailing the best
+// head by more than maxLag blocks.
+func (p *rpcPool) checkHealth(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, e := range p.all {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, rpcHealthTimeout)
+			defer cancel()
+			head, err := e.client.BlockNumber(checkCtx)
+			if ctx.Err() != nil {
+				return
+			}
+			p.record(e, err)
+			if err == nil {
+				e.mu.Lock()
+				e.head = head
+				e.mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	var best uint64
+	for _, e := range p.all {
+		e.mu.Lock()
+		best = max(best, e.head)
+		e.mu.Unlock()
+	}
+	for _, e := range p.all {
+		e.mu.Lock()
+		lagging := p.maxLag > 0 && best-e.head > p.maxLag
+		if lagging != e.lagging {
+			slog.Warn("EVM RPC endpoint lag changed", "endpoint", e.name, "lagging", lagging, "head", e.head, "best", best)
+		}
+		e.lagging = lagging
+		head := e.head
+		e.mu.Unlock()
+		p.metrics.rpcHead.WithLabelValues(e.name).Set(float64(head))
+	}
+}
+
+// monitor runs checkHealth every interval until ctx is done.
+func (p *rpcPool) monitor(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			p.checkHealth(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// rpcFailure reports whether err means the endpoint failed, as opposed to the
+// node answering with an error such as a reverted call.
+func rpcFailure(err error) bool {
+	if err == nil || errors.Is(err, ethereum.NotFound) {
+		return false
+	}
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) {
+		// -32005 is the conventional "limit exceeded" code of hosted providers.
+		return rpcErr.ErrorCode() == -32005
+	}
+	return true
+}
+
+// record folds the outcome of a request into the endpoint's score and backs
+// off from an endpoint that keeps failing.
+func (p *rpcPool) record(e *rpcEndpoint, err error) {
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+	failed := rpcFailure(err)
+	e.mu.Lock()
+	outcome := 1.0
+	if failed {
+		outcome = 0
+		e.failures++
+		backoff := min(time.Second<<min(e.failures-1, 6), rpcMaxBackoff)
+		e.retryAt = p.now().Add(backoff)
+	} else {
+		e.failures = 0
+		e.retryAt = time.Time{}
+	}
+	e.score = (1-rpcScoreWeight)*e.score + rpcScoreWeight*outcome
+	score := e.score
+	e.mu.Unlock()
+	p.metrics.rpcScore.WithLabelValues(e.name).Set(score)
+	if failed {
+		p.metrics.rpcErrors.WithLabelValues(e.name).Inc()
+	}
+}
+
+// ordered returns the available endpoints of set by descending score, followed
+// by the unavailable ones as a last resort.
+func (p *rpcPool) ordered(set []*rpcEndpoint) []*rpcEndpoint {
+	now := p.now()
+	var up, down []*rpcEndpoint
+	for _, e := range set {
+		if e.available(now) {
+			up = append(up, e)
+		} else {
+			down = append(down, e)
+		}
+	}
+	byScore := func(a, b *rpcEndpoint) int {
+		sa, sb := a.currentScore(), b.currentScore()
+		switch {
+		case sa > sb:
+			return -1
+		case sa < sb:
+			return 1
+		}
+		return 0
+	}
+	slices.SortStableFunc(up, byScore)
+	slices.SortStableFunc(down, byScore)
+	return append(up, down...)
+}
+
+// acquire picks the first endpoint in order with a free request slot, or
+// waits for the first one when all are at their rate limit.
+func acquire(ctx context.Context, candidates []*rpcEndpoint) (*rpcEndpoint, []*rpcEndpoint, error) {
+	for i, e := range candidates {
+		if e.limiter == nil || e.limiter.Allow() {
+			return e, slices.Delete(slices.Clone(candidates), i, i+1), nil
+		}
+	}
+	e := candidates[0]
+	if err := e.limiter.Wait(ctx); err != nil {
+		return nil, nil, fmt.Errorf("%s: rate limit: %w", e.name, err)
+	}
+	return e, candidates[1:], nil
+}
+
+// call runs fn against the endpoints of set, failing over on endpoint failures.
+func call[T any](ctx context.Context, p *rpcPool, set []*rpcEndpoint, fn func(*ethclient.Client) (T, error)) (T, error) {
+	var (
+		zero T
+		errs []error
+	)
+	candidates := p.ordered(set)
+	for len(candidates) > 0 {
+		e, rest, err := acquire(ctx, candidates)
+		if err != nil {
+			return zero, errors.Join(append(errs, err)...)
+		}
+		candidates = rest
+		result, err := fn(e.client)
+		p.record(e, err)
+		if !rpcFailure(err) || ctx.Err() != nil {
+			return result, err
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", e.name, err))
+	}
+	return zero, errors.Join(errs...)
+}
+
+func (p *rpcPool) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
+	return call(ctx, p, p.read, func(c *ethclient.Client) ([]byte, error) { return c.CodeAt(ctx, contract, blockNumber) })
+}
+
+func (p *rpcPool) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	return call(ctx, p, p.read, func(c *ethclient.Client) ([]byte, error) { return c.CallContract(ctx, msg, blockNumber) })
+}
+
+func (p *rpcPool) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	return call(ctx, p, p.read, func(c *ethclient.Client) ([]types.Log, error) { return c.FilterLogs(ctx, q) })
+}
+
+func (p *rpcPool) SubscribeFilterLogs(ctx context.Context, q ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+	return call(ctx, p, p.read, func(c *ethclient.Client) (ethereum.Subscription, error) { return c.SubscribeFilterLogs(ctx, q, ch) })
+}
+
+func (p *rpcPool) BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error) {
+	return call(ctx, p, p.read, func(c *ethclient.Client) (*big.Int, error) { return c.BalanceAt(ctx, account, blockNumber) })
+}
+
+// Transactions are prepared on the send endpoints, whose pools hold the
+// node's pending transactions.
+
+func (p *rpcPool) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	return call(ctx, p, p.send, func(c *ethclient.Client) (*types.Header, error) { return c.HeaderByNumber(ctx, number) })
+}
+
+func (p *rpcPool) PendingCodeAt(ctx context.Context, account common.Address) ([]byte, error) {
+	return call(ctx, p, p.send, func(c *ethclient.Client) ([]byte, error) { return c.PendingCodeAt(ctx, account) })
+}
+
+func (p *rpcPool) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	return call(ctx, p, p.send, func(c *ethclient.Client) (uint64, error) { return c.PendingNonceAt(ctx, account) })
+}
+
+func (p *rpcPool) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	return call(ctx, p, p.send, func(c *ethclient.Client) (*big.Int, error) { return c.SuggestGasPrice(ctx) })
+}
+
+func (p *rpcPool) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
+	return call(ctx, p, p.send, func(c *ethclient.Client) (*big.Int, error) { return c.SuggestGasTipCap(ctx) })
+}
+
+func (p *rpcPool) EstimateGas(ctx context.Context, msg ethereum.CallMsg) (uint64, error) {
+	return call(ctx, p, p.send, func(c *ethclient.Client) (uint64, error) { return c.EstimateGas(ctx, msg) })
+}
+
+// SendTransaction broadcasts tx to every send endpoint at once and succeeds as
+// soon as one of them accepts it. An endpoint that already knows the
+// transaction counts as accepting it.
+func (p *rpcPool) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	endpoints := p.ordered(p.send)
+	results := make(chan error, len(endpoints))
+	for _, e := range endpoints {
+		go func() {
+			if e.limiter != nil {
+				if err := e.limiter.Wait(ctx); err != nil {
+					results <- fmt.Errorf("%s: rate limit: %w", e.name, err)
+					return
+				}
+			}
+			err := e.client.SendTransaction(ctx, tx)
+			if err != nil && strings.Contains(strings.ToLower(err.Error()), "already known") {
+				err = nil
+			}
+			p.record(e, err)
+			if err != nil {
+				err = fmt.Errorf("%s: %w", e.name, err)
+			}
+			results <- err
+		}()
+	}
+	var errs []error
+	for range endpoints {
+		err := <-results
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// fakeRPC answers JSON-RPC requests with handle, or with HTTP 502 while down.
+func fakeRPC(t *testing.T, down *atomic.Bool, handle func(method string) (any, *rpcError)) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if down != nil && down.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		var req struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode request: %v", err)
+			return
+		}
+		result, rpcErr := handle(req.Method)
+		resp := map[string]any{"jsonrpc": "2.0", "id": req.ID}
+		if rpcErr != nil {
+			resp["error"] = rpcErr
+		} else {
+			resp["result"] = result
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func TestRPCPoolFailsOverReadsAndBroadcastsSends(t *testing.T) {
+	var primaryDown atomic.Bool
+	answer := func(method string) (any, *rpcError) {
+		switch method {
+		case "eth_chainId":
+			return "0x7a69", nil
+		case "eth_call":
+			return "0x01", nil
+		case "eth_sendRawTransaction":
+			return nil, &rpcError{Code: -32000, Message: "already known"}
+		}
+		return nil, &rpcError{Code: -32601, Message: "method not found"}
+	}
+	primary, primaryCalls := fakeRPC(t, &primaryDown, answer)
+	backup, _ := fakeRPC(t, nil, answer)
+	sender, sends := fakeRPC(t, nil, func(method string) (any, *rpcError) {
+		if method == "eth_sendRawTransaction" {
+			return common.Hash{1}.Hex(), nil
+		}
+		return answer(method)
+	})
+	ctx := context.Background()
+	pool, err := newRPCPool(ctx, rpcPoolConfig{readURLs: []string{primary.URL, backup.URL}, sendURLs: []string{backup.URL, sender.URL}}, newMetrics())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+	if id, err := pool.ChainID(ctx); err != nil || id.Int64() != 31337 {
+		t.Fatalf("chain id: %v %v", id, err)
+	}
+
+	primaryDown.Store(true)
+	out, err := pool.CallContract(ctx, ethereum.CallMsg{}, nil)
+	if err != nil || len(out) != 1 {
+		t.Fatalf("expected the backup to answer, got %x %v", out, err)
+	}
+	// The failed endpoint backs off, so the next read skips it.
+	before := primaryCalls.Load()
+	if _, err := pool.CallContract(ctx, ethereum.CallMsg{}, nil); err != nil || primaryCalls.Load() != before {
+		t.Fatalf("expected the backup without retrying the primary, got %v", err)
+	}
+	if pool.read[0].currentScore() >= pool.read[1].currentScore() {
+		t.Fatalf("expected the failing endpoint to score lower")
+	}
+
+	// A node answering with an execution error is healthy and not failed over.
+	if rpcFailure(&jsonRPCError{code: 3}) {
+		t.Fatal("expected an execution error not to count against the endpoint")
+	}
+
+	tx := types.NewTx(&types.LegacyTx{Nonce: 1, GasPrice: big.NewInt(1), Gas: 21000})
+	sendsBefore := sends.Load()
+	if err := pool.SendTransaction(ctx, tx); err != nil {
+		t.Fatalf("broadcast: %v", err)
+	}
+	// The backup already knowing the transaction counts as success, so the
+	// broadcast may return before the sender answers.
+	deadline := time.Now().Add(time.Second)
+	for sends.Load() == sendsBefore && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if sends.Load() == sendsBefore {
+		t.Fatal("expected the transaction on every send endpoint")
+	}
+	primaryDown.Store(false)
+	primaryOnly, err := newRPCPool(ctx, rpcPoolConfig{readURLs: []string{primary.URL}}, newMetrics())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer primaryOnly.Close()
+	if err := primaryOnly.SendTransaction(ctx, tx); err != nil {
+		t.Fatalf("expected \"already known\" to count as accepted, got %v", err)
+	}
+}
+
+type jsonRPCError struct{ code int }
+
+func (e *jsonRPCError) Error() string  { return "execution reverted" }
+func (e *jsonRPCError) ErrorCode() int { return e.code }
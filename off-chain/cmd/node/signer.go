@@ -0,0 +1,252 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+// privateKeyEnv sets --private-key without exposing it in the process list.
+const privateKeyEnv = envPrefix + "_PRIVATE_KEY"
+
+// txSigner signs the node's on-chain submissions, either with a key held in
+// the node or through an external signer that never hands the key out.
+type txSigner interface {
+	Address() common.Address
+	SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+	Close()
+}
+
+// signerConfig lists the submitter keys. Every source adds keys to the pool.
+type signerConfig struct {
+	privateKeys          []string
+	privateKeyFiles      []string
+	keystores            []string
+	keystorePasswordFile string
+	url                  string
+	addresses            []string
+}
+
+func (c signerConfig) newSigners(ctx context.Context) (signers []txSigner, err error) {
+	defer func() {
+		if err != nil {
+			for _, signer := range signers {
+				signer.Close()
+			}
+			signers = nil
+		}
+	}()
+	if len(c.addresses) > 0 && c.url == "" {
+		return nil, errors.New("--signer-address requires --signer-url")
+	}
+	for _, hex := range c.privateKeys {
+		signer, err := parseKey(hex)
+		if err != nil {
+			return signers, err
+		}
+		signers = append(signers, signer)
+	}
+	for _, path := range c.privateKeyFiles {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return signers, fmt.Errorf("read private key file: %w", err)
+		}
+		signer, err := parseKey(string(data))
+		if err != nil {
+			return signers, fmt.Errorf("%s: %w", path, err)
+		}
+		signers = append(signers, signer)
+	}
+	for _, path := range c.keystores {
+		signer, err := c.openKeystore(path)
+		if err != nil {
+			return signers, fmt.Errorf("%s: %w", path, err)
+		}
+		signers = append(signers, signer)
+	}
+	if c.url != "" {
+		external, err := dialExternalSigner(ctx, c.url, c.addresses)
+		if err != nil {
+			return signers, err
+		}
+		signers = append(signers, external...)
+	}
+
+	if len(signers) == 0 {
+		return nil, fmt.Errorf("set --private-key-file, --keystore, --signer-url or %s", privateKeyEnv)
+	}
+	seen := make(map[common.Address]bool, len(signers))
+	for _, signer := range signers {
+		if seen[signer.Address()] {
+			return signers, fmt.Errorf("submitter key %s is configured twice", signer.Address().Hex())
+		}
+		seen[signer.Address()] = true
+	}
+	return signers, nil
+}
+
+// openKeystore decrypts the keystore at path with the password file, which is
+// shared by every keystore.
+func (c signerConfig) openKeystore(path string) (txSigner, error) {
+	if c.keystorePasswordFile == "" {
+		return nil, errors.New("--keystore requires --keystore-password-file")
+	}
+	keyJSON, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read keystore: %w", err)
+	}
+	password, err := os.ReadFile(c.keystorePasswordFile)
+	if err != nil {
+		return nil, fmt.Errorf("read keystore password: %w", err)
+	}
+	// Like geth, only the first line of the password file is the password.
+	line, _, _ := strings.Cut(string(password), "\n")
+	key, err := keystore.DecryptKey(keyJSON, strings.TrimRight(line, "\r"))
+	if err != nil {
+		return nil, fmt.Errorf("decrypt keystore: %w", err)
+	}
+	return &localSigner{key: key.PrivateKey}, nil
+}
+
+// localSigner signs with a private key held in memory.
+type localSigner struct {
+	key *ecdsa.PrivateKey
+}
+
+func parseKey(hex string) (*localSigner, error) {
+	key, err := crypto.HexToECDSA(strings.TrimPrefix(strings.TrimSpace(hex), "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("parse private key: %w", err)
+	}
+	return &localSigner{key: key}, nil
+}
+
+func (s *localSigner) Address() common.Address { return crypto.PubkeyToAddress(s.key.PublicKey) }
+
+func (s *localSigner) SignTx(_ context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return types.SignTx(tx, types.LatestSignerForChainID(chainID), s.key)
+}
+
+func (s *localSigner) Close() {}
+
+// externalSigner asks a Clef-compatible signer to sign over JSON-RPC
+// (account_list and account_signTransaction). Every account used shares one
+// connection, which can be HTTP(S), WebSocket or IPC.
+type externalSigner struct {
+	client  *rpc.Client
+	close   func()
+	address common.Address
+}
+
+// dialExternalSigner returns a signer for each of addresses, or for every
+// account the signer manages when addresses is empty.
+func dialExternalSigner(ctx context.Context, url string, addresses []string) ([]txSigner, error) {
+	for _, address := range addresses {
+		if !common.IsHexAddress(address) {
+			return nil, fmt.Errorf("invalid --signer-address %q", address)
+		}
+	}
+	client, err := rpc.DialContext(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("dial external signer: %w", err)
+	}
+	var accounts []common.Address
+	if err := client.CallContext(ctx, &accounts, "account_list"); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("list external signer accounts: %w", err)
+	}
+	if len(addresses) == 0 {
+		for _, account := range accounts {
+			addresses = append(addresses, account.Hex())
+		}
+	}
+	if len(addresses) == 0 {
+		client.Close()
+		return nil, errors.New("external signer manages no accounts")
+	}
+	closeClient := sync.OnceFunc(client.Close)
+	signers := make([]txSigner, 0, len(addresses))
+	for _, address := range addresses {
+		account := common.HexToAddress(address)
+		if !slices.Contains(accounts, account) {
+			client.Close()
+			return nil, fmt.Errorf("external signer does not manage %s", account.Hex())
+		}
+		signers = append(signers, &externalSigner{client: client, close: closeClient, address: account})
+	}
+	return signers, nil
+}
+
+func (s *externalSigner) Address() common.Address { return s.address }
+
+func (s *externalSigner) SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	input := hexutil.Bytes(tx.Data())
+	args := apitypes.SendTxArgs{
+		From:    common.NewMixedcaseAddress(s.address),
+		Gas:     hexutil.Uint64(tx.Gas()),
+		Value:   hexutil.Big(*tx.Value()),
+		Nonce:   hexutil.Uint64(tx.Nonce()),
+		Input:   &input,
+		ChainID: (*hexutil.Big)(chainID),
+	}
+	if to := tx.To(); to != nil {
+		mixed := common.NewMixedcaseAddress(*to)
+		args.To = &mixed
+	}
+	switch tx.Type() {
+	case types.LegacyTxType:
+		args.GasPrice = (*hexutil.Big)(tx.GasPrice())
+	case types.DynamicFeeTxType:
+		args.MaxFeePerGas = (*hexutil.Big)(tx.GasFeeCap())
+		args.MaxPriorityFeePerGas = (*hexutil.Big)(tx.GasTipCap())
+		accessList := tx.AccessList()
+		args.AccessList = &accessList
+	default:
+		return nil, fmt.Errorf("external signer: unsupported transaction type %d", tx.Type())
+	}
+
+	var result struct {
+		Raw hexutil.Bytes `json:"raw"`
+	}
+	if err := s.client.CallContext(ctx, &result, "account_signTransaction", args); err != nil {
+		return nil, fmt.Errorf("external signer: %w", err)
+	}
+	signed := new(types.Transaction)
+	if err := signed.UnmarshalBinary(result.Raw); err != nil {
+		return nil, fmt.Errorf("external signer: decode signed transaction: %w", err)
+	}
+	// Never broadcast something other than what was asked for.
+	sender, err := types.Sender(types.LatestSignerForChainID(chainID), signed)
+	if err != nil {
+		return nil, fmt.Errorf("external signer: %w", err)
+	}
+	if sender != s.address || !sameCall(signed, tx) {
+		return nil, errors.New("external signer returned a different transaction than requested")
+	}
+	return signed, nil
+}
+
+// sameCall reports whether a and b send the same call with the same nonce.
+func sameCall(a, b *types.Transaction) bool {
+	if (a.To() == nil) != (b.To() == nil) || (a.To() != nil && *a.To() != *b.To()) {
+		return false
+	}
+	return a.Nonce() == b.Nonce() && a.Value().Cmp(b.Value()) == 0 && bytes.Equal(a.Data(), b.Data())
+}
+
+func (s *externalSigner) Close() { s.close() }
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"math/big"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+const testKeyHex = "b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291"
+
+func TestSignerKeySources(t *testing.T) {
+	key, _ := crypto.HexToECDSA(testKeyHex)
+	want := crypto.PubkeyToAddress(key.PublicKey)
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	account, err := keystore.NewKeyStore(filepath.Join(dir, "keystore"), keystore.LightScryptN, keystore.LightScryptP).ImportECDSA(key, "hunter2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, c := range map[string]signerConfig{
+		"flag":     {privateKeys: []string{"0x" + testKeyHex}},
+		"key file": {privateKeyFiles: []string{write("key", "0x"+testKeyHex+"\n")}},
+		"keystore": {keystores: []string{account.URL.Path}, keystorePasswordFile: write("password", "hunter2\nignored\n")},
+	} {
+		signers, err := c.newSigners(context.Background())
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if len(signers) != 1 || signers[0].Address() != want {
+			t.Fatalf("%s: expected only %s, got %d keys", name, want.Hex(), len(signers))
+		}
+	}
+
+	other, _ := crypto.GenerateKey()
+	otherHex := hex.EncodeToString(crypto.FromECDSA(other))
+	signers, err := (signerConfig{privateKeys: []string{otherHex}, privateKeyFiles: []string{write("pool", testKeyHex)}}).newSigners(context.Background())
+	if err != nil || len(signers) != 2 {
+		t.Fatalf("expected the sources to add up, got %d keys (%v)", len(signers), err)
+	}
+
+	for name, c := range map[string]signerConfig{
+		"duplicate key":  {privateKeys: []string{testKeyHex}, privateKeyFiles: []string{write("key", testKeyHex)}},
+		"wrong password": {keystores: []string{account.URL.Path}, keystorePasswordFile: write("wrong", "hunter3")},
+		"no password":    {keystores: []string{account.URL.Path}},
+		"stray address":  {privateKeys: []string{testKeyHex}, addresses: []string{want.Hex()}},
+		"no key":         {},
+	} {
+		if _, err := c.newSigners(context.Background()); err == nil {
+			t.Fatalf("%s: expected an error", name)
+		}
+	}
+}
+
+// fakeClef is a stand-in for Clef's account_* JSON-RPC API that signs
+// everything it is asked to, after tamper when set.
+type fakeClef struct {
+	key    *ecdsa.PrivateKey
+	tamper func(*types.DynamicFeeTx)
+}
+
+func (c *fakeClef) List() []common.Address {
+	return []common.Address{crypto.PubkeyToAddress(c.key.PublicKey)}
+}
+
+func (c *fakeClef) SignTransaction(args apitypes.SendTxArgs, _ *string) (map[string]hexutil.Bytes, error) {
+	tx, err := args.ToTransaction()
+	if err != nil {
+		return nil, err
+	}
+	inner := &types.DynamicFeeTx{
+		ChainID: (*big.Int)(args.ChainID), Nonce: tx.Nonce(), GasTipCap: tx.GasTipCap(), GasFeeCap: tx.GasFeeCap(),
+		Gas: tx.Gas(), To: tx.To(), Value: tx.Value(), Data: tx.Data(),
+	}
+	if c.tamper != nil {
+		c.tamper(inner)
+	}
+	signed, err := types.SignNewTx(c.key, types.LatestSignerForChainID(inner.ChainID), inner)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := signed.MarshalBinary()
+	return map[string]hexutil.Bytes{"raw": raw}, err
+}
+
+func TestExternalSignerSignsOverJSONRPC(t *testing.T) {
+	key, _ := crypto.HexToECDSA(testKeyHex)
+	clef := &fakeClef{key: key}
+	server := rpc.NewServer()
+	if err := server.RegisterName("account", clef); err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(server)
+	t.Cleanup(srv.Close)
+
+	ctx := context.Background()
+	signers, err := (signerConfig{url: srv.URL}).newSigners(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := signers[0]
+	defer signer.Close()
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	if len(signers) != 1 || signer.Address() != from {
+		t.Fatalf("expected the signer's only account, got %d accounts", len(signers))
+	}
+
+	chainID := big.NewInt(31337)
+	contract := common.HexToAddress("0xA4b0f5eb09891c1538494c4989Eea0203b1153b1")
+	tx := types.NewTx(&types.DynamicFeeTx{
+		ChainID: chainID, Nonce: 7, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(2), Gas: 100000,
+		To: &contract, Data: []byte{0xde, 0xad},
+	})
+	signed, err := signer.SignTx(ctx, tx, chainID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sender, _ := types.Sender(types.LatestSignerForChainID(chainID), signed); sender != from || signed.Nonce() != 7 || *signed.To() != contract {
+		t.Fatalf("unexpected signed transaction from %s", sender.Hex())
+	}
+
+	clef.tamper = func(tx *types.DynamicFeeTx) { tx.Data = []byte{0xbe, 0xef} }
+	if _, err := signer.SignTx(ctx, tx, chainID); err == nil {
+		t.Fatal("expected a transaction different from the request to be rejected")
+	}
+
+	other := common.HexToAddress("0x0000000000000000000000000000000000000001")
+	if _, err := (signerConfig{url: srv.URL, addresses: []string{other.Hex()}}).newSigners(ctx); err == nil {
+		t.Fatal("expected an account the signer does not manage to be rejected")
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"sum/internal/flights"
+	"sum/internal/tracing"
+)
+
+// A flight action is traced as one span per step: flight.evaluate (with its
+// flight.sign child), flight.proof, flight.submit and flight.confirm. The steps
+// happen in different loop iterations, so every later step starts its own
+// trace linked to the evaluation, which in turn links to the flights API span
+// that changed the flight's status.
+var tracer = otel.Tracer("sum/cmd/node")
+
+func flightAttributes(airlineID, flightID string, action actionType) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("flight.airline_id", airlineID),
+		attribute.String("flight.id", flightID),
+		attribute.String("flight.action", string(action)),
+	}
+}
+
+// startEvaluate starts the span of an evaluation that found action to take.
+func startEvaluate(ctx context.Context, flight flights.Flight, action actionType) (context.Context, trace.Span) {
+	opts := []trace.SpanStartOption{trace.WithAttributes(flightAttributes(flight.AirlineID, flight.FlightID, action)...)}
+	if link, ok := tracing.Link(flight.TraceParent); ok {
+		opts = append(opts, trace.WithLinks(link))
+	}
+	return tracer.Start(ctx, "flight.evaluate", opts...)
+}
+
+// startStep starts a step of a in a new trace linked to its evaluation.
+func (a *pendingAction) startStep(ctx context.Context, name string) (context.Context, trace.Span) {
+	attrs := append(flightAttributes(a.Airline.AirlineID, a.Flight.FlightID, a.Type), attribute.String("relay.request_id", a.RequestID))
+	return tracer.Start(ctx, name, trace.WithNewRoot(), trace.WithLinks(trace.Link{SpanContext: a.trace}), trace.WithAttributes(attrs...))
+}
+
+// await ends the step a was waiting in, if any, and starts waiting in name
+// until the next call.
+func (a *pendingAction) await(ctx context.Context, name string) {
+	a.endWait()
+	_, a.waiting = a.startStep(ctx, name)
+}
+
+// context returns ctx carrying the span a waits in, so calls made while
+// waiting show up in that step.
+func (a *pendingAction) context(ctx context.Context) context.Context {
+	if a.waiting == nil {
+		return ctx
+	}
+	return trace.ContextWithSpan(ctx, a.waiting)
+}
+
+func (a *pendingAction) endWait() {
+	if a.waiting != nil {
+		a.waiting.End()
+		a.waiting = nil
+	}
+}
+
+// failSpan marks span failed with err and returns err.
+func failSpan(span trace.Span, err error) error {
+	if err != nil {
+		span.SetStatus(otelcodes.Error, err.Error())
+	}
+	return err
+}
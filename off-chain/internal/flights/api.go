@@ -0,0 +1,62 @@
+package flights
+
+import _ "embed"
+
+// OpenAPISpec is the OpenAPI 3 description of the flights API, served by
+// flights-api at /openapi.json. The types below are the request and response
+// bodies it describes; the server and Client both use them.
+//
+//go:embed openapi.json
+var OpenAPISpec []byte
+
+// AirlinesResponse is the body of GET /airlines.
+type AirlinesResponse struct {
+	Airlines []Airline `json:"airlines"`
+}
+
+// AirlineResponse is the body of POST /airlines.
+type AirlineResponse struct {
+	Airline Airline `json:"airline"`
+}
+
+// FlightsResponse is the body of GET /airlines/{airlineId}/flights.
+type FlightsResponse struct {
+	Flights []Flight `json:"flights"`
+}
+
+// FlightResponse is the body of every endpoint returning a single flight.
+type FlightResponse struct {
+	Flight Flight `json:"flight"`
+}
+
+// ImportResponse is the body of a successful POST /flights/import.
+type ImportResponse struct {
+	Imported int      `json:"imported"`
+	Flights  []Flight `json:"flights"`
+}
+
+// CreateAirlineRequest is the body of POST /airlines.
+type CreateAirlineRequest struct {
+	AirlineID string `json:"airlineId"`
+	Name      string `json:"name"`
+	Code      string `json:"code,omitempty"`
+	Signer    string `json:"signer,omitempty"`
+}
+
+// CreateFlightRequest is the body of POST /airlines/{airlineId}/flights. It may
+// carry the airline's attestation of the new flight, in which case UpdatedAt is
+// the signed timestamp.
+type CreateFlightRequest struct {
+	FlightID           string `json:"flightId"`
+	DepartureTimestamp int64  `json:"departureTimestamp"`
+	AircraftID         string `json:"aircraftId,omitempty"`
+	UpdatedAt          int64  `json:"updatedAt,omitempty"`
+	Signature          string `json:"signature,omitempty"`
+}
+
+// StatusUpdateRequest is the optional body of the delay and depart endpoints
+// carrying the airline's signature over the new state.
+type StatusUpdateRequest struct {
+	UpdatedAt int64  `json:"updatedAt,omitempty"`
+	Signature string `json:"signature,omitempty"`
+}
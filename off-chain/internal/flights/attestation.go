@@ -0,0 +1,114 @@
+package flights
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var (
+	ErrMissingAttestation = errors.New("flight has no airline attestation")
+	ErrInvalidAttestation = errors.New("invalid airline attestation")
+)
+
+// attestationDomain separates attestation digests from other signed payloads.
+const attestationDomain = "flights-api/status-attestation/v1"
+
+var attestationArgs = func() abi.Arguments {
+	str, _ := abi.NewType("string", "", nil)
+	u64, _ := abi.NewType("uint64", "", nil)
+	return abi.Arguments{{Type: str}, {Type: str}, {Type: str}, {Type: str}, {Type: u64}, {Type: u64}}
+}()
+
+// AttestationHash is the EIP-191 personal-message hash an airline signs to
+// attest a flight's status. The message is the keccak256 of the ABI-encoded
+// (domain, airlineId, flightId, status, departureTimestamp, updatedAt), so the
+// same signature can be produced with personal_sign and checked on-chain.
+func AttestationHash(f Flight) (common.Hash, error) {
+	if f.DepartureTimestamp < 0 || f.UpdatedAt < 0 {
+		return common.Hash{}, fmt.Errorf("%w: negative timestamp", ErrInvalidAttestation)
+	}
+	packed, err := attestationArgs.Pack(attestationDomain, f.AirlineID, f.FlightID, string(f.Status), uint64(f.DepartureTimestamp), uint64(f.UpdatedAt))
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("encode attestation: %w", err)
+	}
+	return common.BytesToHash(accounts.TextHash(crypto.Keccak256(packed))), nil
+}
+
+// SignAttestation signs the flight's current state and returns the hex
+// signature in the 65-byte [R || S || V] form with V of 27 or 28.
+func SignAttestation(f Flight, key *ecdsa.PrivateKey) (string, error) {
+	hash, err := AttestationHash(f)
+	if err != nil {
+		return "", err
+	}
+	sig, err := crypto.Sign(hash.Bytes(), key)
+	if err != nil {
+		return "", fmt.Errorf("sign attestation: %w", err)
+	}
+	sig[crypto.RecoveryIDOffset] += 27
+	return hexutil.Encode(sig), nil
+}
+
+// AttestationSigner recovers the address that signed the flight's attestation.
+func AttestationSigner(f Flight) (common.Address, error) {
+	if f.Signature == "" {
+		return common.Address{}, ErrMissingAttestation
+	}
+	sig, err := hexutil.Decode(f.Signature)
+	if err != nil || len(sig) != crypto.SignatureLength {
+		return common.Address{}, fmt.Errorf("%w: malformed signature", ErrInvalidAttestation)
+	}
+	if sig[crypto.RecoveryIDOffset] >= 27 {
+		sig[crypto.RecoveryIDOffset] -= 27
+	}
+	hash, err := AttestationHash(f)
+	if err != nil {
+		return common.Address{}, err
+	}
+	pub, err := crypto.SigToPub(hash.Bytes(), sig)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("%w: %v", ErrInvalidAttestation, err)
+	}
+	return crypto.PubkeyToAddress(*pub), nil
+}
+
+// VerifyAttestation checks that the flight's attestation was signed by signer.
+func VerifyAttestation(f Flight, signer common.Address) error {
+	recovered, err := AttestationSigner(f)
+	if err != nil {
+		return err
+	}
+	if recovered != signer {
+		return fmt.Errorf("%w: signed by %s, expected %s", ErrInvalidAttestation, recovered.Hex(), signer.Hex())
+	}
+	return nil
+}
+
+// KeyRing signs attestations with per-airline keys held by the API. It exists
+// for local networks where airlines do not run their own signers.
+type KeyRing map[string]*ecdsa.PrivateKey
+
+// Sign returns the attestation for f, or "" when the airline has no key.
+func (k KeyRing) Sign(f Flight) (string, error) {
+	key, ok := k[f.AirlineID]
+	if !ok {
+		return "", nil
+	}
+	return SignAttestation(f, key)
+}
+
+// Address returns the signer address of an airline's key.
+func (k KeyRing) Address(airlineID string) (common.Address, bool) {
+	key, ok := k[airlineID]
+	if !ok {
+		return common.Address{}, false
+	}
+	return crypto.PubkeyToAddress(key.PublicKey), true
+}
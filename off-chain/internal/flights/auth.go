@@ -0,0 +1,36 @@
+package flights
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Headers carrying an HMAC-signed flights API request.
+const (
+	HeaderKeyID     = "X-Key-ID"
+	HeaderTimestamp = "X-Timestamp"
+	HeaderSignature = "X-Signature"
+)
+
+// RequestSignature returns the hex HMAC-SHA256 of a request. The signed string is
+// the method, the request URI (path and query), the unix timestamp and the hex
+// SHA-256 of the body, separated by newlines.
+func RequestSignature(secret []byte, method, requestURI string, timestamp int64, body []byte) string {
+	bodyHash := sha256.Sum256(body)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(method + "\n" + requestURI + "\n" + strconv.FormatInt(timestamp, 10) + "\n" + hex.EncodeToString(bodyHash[:])))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// SignRequest sets the HMAC headers on req. body must be the exact bytes sent as
+// the request body.
+func SignRequest(req *http.Request, keyID string, secret []byte, body []byte, now time.Time) {
+	ts := now.Unix()
+	req.Header.Set(HeaderKeyID, keyID)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(ts, 10))
+	req.Header.Set(HeaderSignature, RequestSignature(secret, req.Method, req.URL.RequestURI(), ts, body))
+}
@@ -0,0 +1,291 @@
+package flights
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// Client is a typed client for the flights API described by OpenAPISpec.
+// Error responses are returned as *Problem, so callers can match store
+// sentinels with errors.Is.
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+	// APIKey is sent as a bearer token, or used as the HMAC secret when KeyID is set.
+	APIKey string
+	KeyID  string
+
+	// Retry applies to GET requests; the zero policy makes a single attempt.
+	Retry RetryPolicy
+	// Breaker, when set, fails requests fast while the API host is down.
+	Breaker *CircuitBreaker
+	// Cache, when set, revalidates GET responses with their ETag.
+	Cache *ResponseCache
+}
+
+// NewClient returns a client for the API at baseURL with a 5s request timeout.
+func NewClient(baseURL string) *Client {
+	return &Client{
+		BaseURL:    strings.TrimSuffix(baseURL, "/"),
+		HTTPClient: &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// ListAirlines calls GET /airlines.
+func (c *Client) ListAirlines(ctx context.Context) ([]Airline, error) {
+	var out AirlinesResponse
+	if err := c.doJSON(ctx, http.MethodGet, "/airlines", nil, nil, &out); err != nil {
+		return nil, fmt.Errorf("list airlines: %w", err)
+	}
+	return out.Airlines, nil
+}
+
+// CreateAirline calls POST /airlines; it needs an admin key.
+func (c *Client) CreateAirline(ctx context.Context, req CreateAirlineRequest) (Airline, error) {
+	var out AirlineResponse
+	if err := c.doJSON(ctx, http.MethodPost, "/airlines", nil, req, &out); err != nil {
+		return Airline{}, fmt.Errorf("create airline: %w", err)
+	}
+	return out.Airline, nil
+}
+
+// ListFlights calls GET /airlines/{airlineId}/flights.
+func (c *Client) ListFlights(ctx context.Context, airlineID string) ([]Flight, error) {
+	var out FlightsResponse
+	if err := c.doJSON(ctx, http.MethodGet, flightsPath(airlineID), nil, nil, &out); err != nil {
+		return nil, fmt.Errorf("list flights: %w", err)
+	}
+	return out.Flights, nil
+}
+
+// GetFlight calls GET /airlines/{airlineId}/flights/{flightId}.
+func (c *Client) GetFlight(ctx context.Context, airlineID, flightID string) (Flight, error) {
+	var out FlightResponse
+	if err := c.doJSON(ctx, http.MethodGet, flightPath(airlineID, flightID), nil, nil, &out); err != nil {
+		return Flight{}, fmt.Errorf("get flight: %w", err)
+	}
+	return out.Flight, nil
+}
+
+// CreateFlight calls POST /airlines/{airlineId}/flights.
+func (c *Client) CreateFlight(ctx context.Context, airlineID string, req CreateFlightRequest) (Flight, error) {
+	var out FlightResponse
+	if err := c.doJSON(ctx, http.MethodPost, flightsPath(airlineID), nil, req, &out); err != nil {
+		return Flight{}, fmt.Errorf("create flight: %w", err)
+	}
+	return out.Flight, nil
+}
+
+// UpdateStatus calls the delay or depart endpoint for update.Status. A non-zero
+// IfVersion is sent as If-Match, so a concurrent change fails with
+// ErrVersionMismatch instead of being overwritten.
+func (c *Client) UpdateStatus(ctx context.Context, airlineID, flightID string, update StatusUpdate) (Flight, error) {
+	var action string
+	switch update.Status {
+	case StatusDelayed:
+		action = "/delay"
+	case StatusDeparted:
+		action = "/depart"
+	default:
+		return Flight{}, fmt.Errorf("update status: %w: %q", ErrInvalidStatus, update.Status)
+	}
+	header := http.Header{}
+	if update.IfVersion > 0 {
+		header.Set("If-Match", `"v`+strconv.FormatInt(update.IfVersion, 10)+`"`)
+	}
+	var body any
+	if update.Signature != "" {
+		body = StatusUpdateRequest{UpdatedAt: update.UpdatedAt, Signature: update.Signature}
+	}
+	var out FlightResponse
+	if err := c.doJSON(ctx, http.MethodPost, flightPath(airlineID, flightID)+action, header, body, &out); err != nil {
+		return Flight{}, fmt.Errorf("update status: %w", err)
+	}
+	return out.Flight, nil
+}
+
+// ImportFlights calls POST /flights/import with one NDJSON row per flight.
+func (c *Client) ImportFlights(ctx context.Context, items []Flight) ([]Flight, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, f := range items {
+		row := struct {
+			AirlineID          string `json:"airlineId"`
+			FlightID           string `json:"flightId"`
+			DepartureTimestamp int64  `json:"departureTimestamp"`
+			Status             Status `json:"status,omitempty"`
+			AircraftID         string `json:"aircraftId,omitempty"`
+		}{f.AirlineID, f.FlightID, f.DepartureTimestamp, f.Status, f.AircraftID}
+		if err := enc.Encode(row); err != nil {
+			return nil, fmt.Errorf("import flights: %w", err)
+		}
+	}
+	var out ImportResponse
+	if err := c.do(ctx, http.MethodPost, "/flights/import", nil, "application/x-ndjson", buf.Bytes(), &out); err != nil {
+		return nil, fmt.Errorf("import flights: %w", err)
+	}
+	return out.Flights, nil
+}
+
+// Healthz calls GET /healthz once, bypassing the retry policy and the breaker,
+// so it reports the API as it is right now.
+func (c *Client) Healthz(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL+"/healthz", nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("healthz: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("healthz: unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+func flightsPath(airlineID string) string {
+	return "/airlines/" + url.PathEscape(airlineID) + "/flights"
+}
+
+func flightPath(airlineID, flightID string) string {
+	return flightsPath(airlineID) + "/" + url.PathEscape(flightID)
+}
+
+func (c *Client) doJSON(ctx context.Context, method, path string, header http.Header, in, out any) error {
+	if in == nil {
+		return c.do(ctx, method, path, header, "", nil, out)
+	}
+	body, err := json.Marshal(in)
+	if err != nil {
+		return fmt.Errorf("encode request: %w", err)
+	}
+	return c.do(ctx, method, path, header, "application/json", body, out)
+}
+
+// do sends the request. GETs are retried under c.Retry and revalidated against
+// c.Cache; every request passes through c.Breaker.
+func (c *Client) do(ctx context.Context, method, path string, header http.Header, contentType string, body []byte, out any) error {
+	attempts := 1
+	if method == http.MethodGet && c.Retry.Attempts > 1 {
+		attempts = c.Retry.Attempts
+	}
+	for n := 1; ; n++ {
+		wait, err := c.attempt(ctx, method, path, header, contentType, body, out)
+		if err == nil || n >= attempts || !retryable(err) {
+			return err
+		}
+		timer := time.NewTimer(c.Retry.delay(n, wait))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// attempt makes one request and returns the server's Retry-After on failure.
+func (c *Client) attempt(ctx context.Context, method, path string, header http.Header, contentType string, body []byte, out any) (retryAfterDelay time.Duration, err error) {
+	if c.Breaker != nil {
+		if err := c.Breaker.allow(); err != nil {
+			return 0, err
+		}
+		defer func() { c.Breaker.record(ctx, err) }()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("Accept", "application/json")
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+	cache := c.Cache
+	if method != http.MethodGet {
+		cache = nil
+	}
+	var cached cachedResponse
+	if cache != nil {
+		var ok bool
+		if cached, ok = cache.get(path); ok {
+			req.Header.Set("If-None-Match", cached.etag)
+		}
+	}
+	c.authorize(req, body)
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified && cached.body != nil {
+		if err := json.Unmarshal(cached.body, out); err != nil {
+			return 0, fmt.Errorf("decode cached response: %w", err)
+		}
+		return 0, nil
+	}
+	if resp.StatusCode >= 300 {
+		return retryAfter(resp.Header), decodeProblem(resp)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, fmt.Errorf("read response: %w", err)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return 0, fmt.Errorf("decode response: %w", err)
+	}
+	if cache != nil {
+		cache.put(path, resp.Header.Get("ETag"), data)
+	}
+	return 0, nil
+}
+
+// authorize adds the configured credentials. body must be the exact request body.
+func (c *Client) authorize(req *http.Request, body []byte) {
+	switch {
+	case c.APIKey == "":
+	case c.KeyID != "":
+		SignRequest(req, c.KeyID, []byte(c.APIKey), body, time.Now())
+	default:
+		req.Header.Set("Authorization", "Bearer "+c.APIKey)
+	}
+}
+
+// decodeProblem turns an error response into a *Problem. Responses that are not
+// problem documents, e.g. from a proxy, still yield a Problem carrying the status.
+func decodeProblem(resp *http.Response) error {
+	problem := &Problem{Status: resp.StatusCode, Code: CodeInternal, RequestID: resp.Header.Get("X-Request-Id")}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		problem.Detail = fmt.Sprintf("read error body: %v", err)
+		return problem
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType == ProblemContentType {
+		if err := json.Unmarshal(body, problem); err == nil && problem.Code != "" {
+			return problem
+		}
+	}
+	problem.Detail = strings.TrimSpace(string(body))
+	if resp.StatusCode == http.StatusTooManyRequests {
+		problem.Code = CodeRateLimited
+	}
+	return problem
+}
@@ -0,0 +1,109 @@
+package flights
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClientRetriesReadsAndRevalidatesByETag(t *testing.T) {
+	var calls, revalidated atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch n := calls.Add(1); {
+		case n == 1:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case n == 2:
+			// A truncated body is retried like any other unavailable response.
+			w.Write([]byte(`{"airlines":[`))
+		case r.Header.Get("If-None-Match") == `"a1"`:
+			revalidated.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+		default:
+			w.Header().Set("ETag", `"a1"`)
+			w.Write([]byte(`{"airlines":[{"airlineId":"ALPHA","name":"Alpha Air"}]}`))
+		}
+	}))
+	defer srv.Close()
+	client := NewClient(srv.URL)
+	client.Retry = RetryPolicy{Attempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+	client.Cache = NewResponseCache()
+	ctx := context.Background()
+
+	airlines, err := client.ListAirlines(ctx)
+	if err != nil || len(airlines) != 1 || calls.Load() != 3 {
+		t.Fatalf("expected success on the third attempt, got %v %v after %d calls", airlines, err, calls.Load())
+	}
+	airlines, err = client.ListAirlines(ctx)
+	if err != nil || len(airlines) != 1 || airlines[0].AirlineID != "ALPHA" || revalidated.Load() != 1 {
+		t.Fatalf("expected the cached body on a 304, got %v %v", airlines, err)
+	}
+}
+
+func TestClientDoesNotRetryWritesOrClientErrors(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if r.Method == http.MethodGet {
+			w.Header().Set("Content-Type", ProblemContentType)
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"status":404,"code":"airline_not_found"}`))
+			return
+		}
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+	client := NewClient(srv.URL)
+	client.Retry = RetryPolicy{Attempts: 3, BaseDelay: time.Millisecond}
+	ctx := context.Background()
+
+	if _, err := client.ListFlights(ctx, "NOPE"); !errors.Is(err, ErrAirlineNotFound) || calls.Load() != 1 {
+		t.Fatalf("expected a single attempt for a 404, got %v after %d calls", err, calls.Load())
+	}
+	if _, err := client.CreateFlight(ctx, "ALPHA", CreateFlightRequest{FlightID: "ALPHA-9"}); err == nil || calls.Load() != 2 {
+		t.Fatalf("expected a single attempt for a write, got %v after %d calls", err, calls.Load())
+	}
+}
+
+func TestCircuitBreakerOpensAndProbes(t *testing.T) {
+	var healthy atomic.Bool
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(`{"airlines":[]}`))
+	}))
+	defer srv.Close()
+	now := time.Unix(1_700_000_000, 0)
+	breaker := NewCircuitBreaker(2, time.Minute)
+	breaker.now = func() time.Time { return now }
+	client := NewClient(srv.URL)
+	client.Breaker = breaker
+	ctx := context.Background()
+
+	for range 2 {
+		if _, err := client.ListAirlines(ctx); err == nil {
+			t.Fatal("expected a server error")
+		}
+	}
+	if _, err := client.ListAirlines(ctx); !errors.Is(err, ErrCircuitOpen) || calls.Load() != 2 || breaker.State() != BreakerOpen {
+		t.Fatalf("expected the open breaker to fail fast, got %v after %d calls", err, calls.Load())
+	}
+
+	now = now.Add(time.Minute)
+	if _, err := client.ListAirlines(ctx); err == nil || breaker.State() != BreakerOpen {
+		t.Fatalf("expected a failed probe to reopen the breaker, got %v in %s", err, breaker.State())
+	}
+	now = now.Add(time.Minute)
+	healthy.Store(true)
+	if _, err := client.ListAirlines(ctx); err != nil || breaker.State() != BreakerClosed {
+		t.Fatalf("expected a successful probe to close the breaker, got %v in %s", err, breaker.State())
+	}
+}
@@ -0,0 +1,93 @@
+package flights
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrClockBackwards is returned when a mock clock is asked to move into the past.
+var ErrClockBackwards = errors.New("clock cannot move backwards")
+
+// Clock reports the current time used for flight timestamps.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+// SystemClock returns a Clock backed by the wall clock.
+func SystemClock() Clock {
+	return systemClock{}
+}
+
+// MockClock is a controllable clock. A running mock clock keeps ticking with the
+// wall clock from the last point it was set to, mirroring how anvil treats
+// evm_increaseTime; a stopped one only moves when told to.
+type MockClock struct {
+	mu      sync.RWMutex
+	base    time.Time
+	anchor  time.Time
+	running bool
+}
+
+// NewMockClock creates a mock clock starting at start.
+func NewMockClock(start time.Time, running bool) *MockClock {
+	return &MockClock{base: start, anchor: time.Now(), running: running}
+}
+
+// Now returns the current mock time.
+func (c *MockClock) Now() time.Time {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.nowLocked()
+}
+
+func (c *MockClock) nowLocked() time.Time {
+	if !c.running {
+		return c.base
+	}
+	return c.base.Add(time.Since(c.anchor))
+}
+
+// setDrift is how far behind a running clock Set may be asked to go. A client
+// setting the time it just read, in whole seconds, lands up to a second plus the
+// round trip behind; such a target keeps the clock where it is.
+const setDrift = 2 * time.Second
+
+// Set moves the clock to t, which must not be before the current mock time.
+func (c *MockClock) Set(t time.Time) (time.Time, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.nowLocked()
+	if t.Before(now) {
+		if !c.running || now.Sub(t) > setDrift {
+			return time.Time{}, ErrClockBackwards
+		}
+		t = now
+	}
+	c.base = t
+	c.anchor = time.Now()
+	return t, nil
+}
+
+// Advance moves the clock forward by d.
+func (c *MockClock) Advance(d time.Duration) (time.Time, error) {
+	if d < 0 {
+		return time.Time{}, ErrClockBackwards
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.base = c.nowLocked().Add(d)
+	c.anchor = time.Now()
+	return c.base, nil
+}
+
+// Running reports whether the clock advances with wall time between updates.
+func (c *MockClock) Running() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.running
+}
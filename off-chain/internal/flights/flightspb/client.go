@@ -0,0 +1,116 @@
+package flightspb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"sum/internal/flights"
+)
+
+// Client wraps FlightsServiceClient with the flights package types, mirroring
+// flights.Client for the REST API.
+type Client struct {
+	rpc FlightsServiceClient
+	// APIKey, when set, is sent as bearer token metadata on every call.
+	APIKey string
+}
+
+func NewClient(conn grpc.ClientConnInterface) *Client {
+	return &Client{rpc: NewFlightsServiceClient(conn)}
+}
+
+func (c *Client) outgoing(ctx context.Context) context.Context {
+	if c.APIKey == "" {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+c.APIKey)
+}
+
+func (c *Client) ListAirlines(ctx context.Context) ([]flights.Airline, error) {
+	resp, err := c.rpc.ListAirlines(c.outgoing(ctx), &ListAirlinesRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("list airlines: %w", ToProblem(err))
+	}
+	airlines := make([]flights.Airline, 0, len(resp.GetAirlines()))
+	for _, a := range resp.GetAirlines() {
+		airlines = append(airlines, ToAirline(a))
+	}
+	return airlines, nil
+}
+
+func (c *Client) ListFlights(ctx context.Context, airlineID string) ([]flights.Flight, error) {
+	resp, err := c.rpc.ListFlights(c.outgoing(ctx), &ListFlightsRequest{AirlineId: airlineID})
+	if err != nil {
+		return nil, fmt.Errorf("list flights: %w", ToProblem(err))
+	}
+	items := make([]flights.Flight, 0, len(resp.GetFlights()))
+	for _, f := range resp.GetFlights() {
+		items = append(items, ToFlight(f))
+	}
+	return items, nil
+}
+
+func (c *Client) GetFlight(ctx context.Context, airlineID, flightID string) (flights.Flight, error) {
+	resp, err := c.rpc.GetFlight(c.outgoing(ctx), &GetFlightRequest{AirlineId: airlineID, FlightId: flightID})
+	if err != nil {
+		return flights.Flight{}, fmt.Errorf("get flight: %w", ToProblem(err))
+	}
+	return ToFlight(resp.GetFlight()), nil
+}
+
+func (c *Client) CreateFlight(ctx context.Context, airlineID string, req flights.CreateFlightRequest) (flights.Flight, error) {
+	resp, err := c.rpc.CreateFlight(c.outgoing(ctx), &CreateFlightRequest{
+		AirlineId:          airlineID,
+		FlightId:           req.FlightID,
+		DepartureTimestamp: req.DepartureTimestamp,
+		AircraftId:         req.AircraftID,
+		UpdatedAt:          req.UpdatedAt,
+		Signature:          req.Signature,
+	})
+	if err != nil {
+		return flights.Flight{}, fmt.Errorf("create flight: %w", ToProblem(err))
+	}
+	return ToFlight(resp.GetFlight()), nil
+}
+
+func (c *Client) UpdateStatus(ctx context.Context, airlineID, flightID string, update flights.StatusUpdate) (flights.Flight, error) {
+	resp, err := c.rpc.UpdateStatus(c.outgoing(ctx), &UpdateStatusRequest{
+		AirlineId: airlineID,
+		FlightId:  flightID,
+		Status:    FromStatus(update.Status),
+		UpdatedAt: update.UpdatedAt,
+		Signature: update.Signature,
+		IfVersion: update.IfVersion,
+	})
+	if err != nil {
+		return flights.Flight{}, fmt.Errorf("update status: %w", ToProblem(err))
+	}
+	return ToFlight(resp.GetFlight()), nil
+}
+
+// WatchFlights calls fn for every flight change of airlineID (all airlines when
+// empty) until ctx is done, the stream ends or fn fails. It always returns a
+// non-nil error; after an error callers should list flights again.
+func (c *Client) WatchFlights(ctx context.Context, airlineID string, sendInitial bool, fn func(flights.Flight) error) error {
+	stream, err := c.rpc.WatchFlights(c.outgoing(ctx), &WatchFlightsRequest{AirlineId: airlineID, SendInitial: sendInitial})
+	if err != nil {
+		return fmt.Errorf("watch flights: %w", ToProblem(err))
+	}
+	for {
+		msg, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return fmt.Errorf("watch flights: stream closed by server")
+		}
+		if err != nil {
+			return fmt.Errorf("watch flights: %w", ToProblem(err))
+		}
+		if err := fn(ToFlight(msg.GetFlight())); err != nil {
+			return err
+		}
+	}
+}
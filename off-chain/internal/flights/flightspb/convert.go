@@ -0,0 +1,60 @@
+package flightspb
+
+import "sum/internal/flights"
+
+var statuses = map[flights.Status]FlightStatus{
+	flights.StatusScheduled: FlightStatus_FLIGHT_STATUS_SCHEDULED,
+	flights.StatusDelayed:   FlightStatus_FLIGHT_STATUS_DELAYED,
+	flights.StatusDeparted:  FlightStatus_FLIGHT_STATUS_DEPARTED,
+}
+
+// FromStatus converts a store status; unknown statuses become UNSPECIFIED.
+func FromStatus(status flights.Status) FlightStatus {
+	return statuses[status]
+}
+
+// ToStatus converts a protobuf status; UNSPECIFIED becomes "".
+func ToStatus(status FlightStatus) flights.Status {
+	for s, pb := range statuses {
+		if pb == status {
+			return s
+		}
+	}
+	return ""
+}
+
+func FromAirline(a flights.Airline) *Airline {
+	return &Airline{AirlineId: a.AirlineID, Name: a.Name, Code: a.Code, Signer: a.Signer}
+}
+
+func ToAirline(a *Airline) flights.Airline {
+	return flights.Airline{AirlineID: a.GetAirlineId(), Name: a.GetName(), Code: a.GetCode(), Signer: a.GetSigner()}
+}
+
+func FromFlight(f flights.Flight) *Flight {
+	return &Flight{
+		AirlineId:          f.AirlineID,
+		FlightId:           f.FlightID,
+		DepartureTimestamp: f.DepartureTimestamp,
+		Status:             FromStatus(f.Status),
+		UpdatedAt:          f.UpdatedAt,
+		AircraftId:         f.AircraftID,
+		Signature:          f.Signature,
+		Version:            f.Version,
+		TraceParent:        f.TraceParent,
+	}
+}
+
+func ToFlight(f *Flight) flights.Flight {
+	return flights.Flight{
+		AirlineID:          f.GetAirlineId(),
+		FlightID:           f.GetFlightId(),
+		DepartureTimestamp: f.GetDepartureTimestamp(),
+		Status:             ToStatus(f.GetStatus()),
+		UpdatedAt:          f.GetUpdatedAt(),
+		AircraftID:         f.GetAircraftId(),
+		Signature:          f.GetSignature(),
+		Version:            f.GetVersion(),
+		TraceParent:        f.GetTraceParent(),
+	}
+}
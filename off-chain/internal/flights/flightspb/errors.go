@@ -0,0 +1,91 @@
+package flightspb
+
+import (
+	"net/http"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"sum/internal/flights"
+)
+
+// ErrorDomain is the ErrorInfo domain of FlightsService errors.
+const ErrorDomain = "flights-api"
+
+var grpcCodes = map[flights.ErrorCode]codes.Code{
+	flights.CodeNotFound:                codes.NotFound,
+	flights.CodeAirlineNotFound:         codes.NotFound,
+	flights.CodeFlightNotFound:          codes.NotFound,
+	flights.CodeAirlineExists:           codes.AlreadyExists,
+	flights.CodeFlightExists:            codes.AlreadyExists,
+	flights.CodeVersionMismatch:         codes.FailedPrecondition,
+	flights.CodeInvalidStatusTransition: codes.FailedPrecondition,
+	flights.CodeFeatureDisabled:         codes.FailedPrecondition,
+	flights.CodeUnauthenticated:         codes.Unauthenticated,
+	flights.CodeForbidden:               codes.PermissionDenied,
+	flights.CodeRateLimited:             codes.ResourceExhausted,
+	flights.CodeInternal:                codes.Internal,
+}
+
+// Error returns a gRPC status error for code carrying it as ErrorInfo reason.
+func Error(code flights.ErrorCode, message string) error {
+	grpcCode, ok := grpcCodes[code]
+	if !ok {
+		grpcCode = codes.InvalidArgument
+	}
+	st := status.New(grpcCode, message)
+	if detailed, err := st.WithDetails(&errdetails.ErrorInfo{Reason: string(code), Domain: ErrorDomain}); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+// ToProblem turns a FlightsService status error into a *flights.Problem, so
+// callers match store sentinels with errors.Is as they do with the REST client.
+// Errors that are not gRPC statuses are returned unchanged.
+func ToProblem(err error) error {
+	st, ok := status.FromError(err)
+	if !ok || err == nil {
+		return err
+	}
+	problem := &flights.Problem{Status: httpStatus(st.Code()), Code: flights.CodeInternal, Detail: st.Message()}
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok && info.GetDomain() == ErrorDomain {
+			problem.Code = flights.ErrorCode(info.GetReason())
+		}
+	}
+	if problem.Code == flights.CodeInternal && st.Code() == codes.ResourceExhausted {
+		problem.Code = flights.CodeRateLimited
+	}
+	if problem.Code == flights.CodeInternal && (st.Code() == codes.Canceled || st.Code() == codes.DeadlineExceeded || st.Code() == codes.Unavailable) {
+		// Transport failures are not API problems.
+		return err
+	}
+	return problem
+}
+
+func httpStatus(code codes.Code) int {
+	switch code {
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.FailedPrecondition:
+		return http.StatusPreconditionFailed
+	case codes.InvalidArgument, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
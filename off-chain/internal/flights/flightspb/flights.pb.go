@@ -0,0 +1,1040 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        (unknown)
+// source: flights.proto
+
+package flightspb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type FlightStatus int32
+
+const (
+	FlightStatus_FLIGHT_STATUS_UNSPECIFIED FlightStatus = 0
+	FlightStatus_FLIGHT_STATUS_SCHEDULED   FlightStatus = 1
+	FlightStatus_FLIGHT_STATUS_DELAYED     FlightStatus = 2
+	FlightStatus_FLIGHT_STATUS_DEPARTED    FlightStatus = 3
+)
+
+// Enum value maps for FlightStatus.
+var (
+	FlightStatus_name = map[int32]string{
+		0: "FLIGHT_STATUS_UNSPECIFIED",
+		1: "FLIGHT_STATUS_SCHEDULED",
+		2: "FLIGHT_STATUS_DELAYED",
+		3: "FLIGHT_STATUS_DEPARTED",
+	}
+	FlightStatus_value = map[string]int32{
+		"FLIGHT_STATUS_UNSPECIFIED": 0,
+		"FLIGHT_STATUS_SCHEDULED":   1,
+		"FLIGHT_STATUS_DELAYED":     2,
+		"FLIGHT_STATUS_DEPARTED":    3,
+	}
+)
+
+func (x FlightStatus) Enum() *FlightStatus {
+	p := new(FlightStatus)
+	*p = x
+	return p
+}
+
+func (x FlightStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (FlightStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_flights_proto_enumTypes[0].Descriptor()
+}
+
+func (FlightStatus) Type() protoreflect.EnumType {
+	return &file_flights_proto_enumTypes[0]
+}
+
+func (x FlightStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use FlightStatus.Descriptor instead.
+func (FlightStatus) EnumDescriptor() ([]byte, []int) {
+	return file_flights_proto_rawDescGZIP(), []int{0}
+}
+
+type Airline struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	AirlineId string                 `protobuf:"bytes,1,opt,name=airline_id,json=airlineId,proto3" json:"airline_id,omitempty"`
+	Name      string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Code      string                 `protobuf:"bytes,3,opt,name=code,proto3" json:"code,omitempty"`
+	// Address whose attestations the API accepts for the airline's flights.
+	Signer        string `protobuf:"bytes,4,opt,name=signer,proto3" json:"signer,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Airline) Reset() {
+	*x = Airline{}
+	mi := &file_flights_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Airline) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Airline) ProtoMessage() {}
+
+func (x *Airline) ProtoReflect() protoreflect.Message {
+	mi := &file_flights_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Airline.ProtoReflect.Descriptor instead.
+func (*Airline) Descriptor() ([]byte, []int) {
+	return file_flights_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Airline) GetAirlineId() string {
+	if x != nil {
+		return x.AirlineId
+	}
+	return ""
+}
+
+func (x *Airline) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Airline) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *Airline) GetSigner() string {
+	if x != nil {
+		return x.Signer
+	}
+	return ""
+}
+
+type Flight struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	AirlineId          string                 `protobuf:"bytes,1,opt,name=airline_id,json=airlineId,proto3" json:"airline_id,omitempty"`
+	FlightId           string                 `protobuf:"bytes,2,opt,name=flight_id,json=flightId,proto3" json:"flight_id,omitempty"`
+	DepartureTimestamp int64                  `protobuf:"varint,3,opt,name=departure_timestamp,json=departureTimestamp,proto3" json:"departure_timestamp,omitempty"`
+	Status             FlightStatus           `protobuf:"varint,4,opt,name=status,proto3,enum=flights.v1.FlightStatus" json:"status,omitempty"`
+	UpdatedAt          int64                  `protobuf:"varint,5,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	AircraftId         string                 `protobuf:"bytes,6,opt,name=aircraft_id,json=aircraftId,proto3" json:"aircraft_id,omitempty"`
+	// The airline's attestation of the current state.
+	Signature string `protobuf:"bytes,7,opt,name=signature,proto3" json:"signature,omitempty"`
+	Version   int64  `protobuf:"varint,8,opt,name=version,proto3" json:"version,omitempty"`
+	// W3C traceparent of the span that made the latest status change, if traced.
+	TraceParent   string `protobuf:"bytes,9,opt,name=trace_parent,json=traceParent,proto3" json:"trace_parent,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Flight) Reset() {
+	*x = Flight{}
+	mi := &file_flights_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Flight) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Flight) ProtoMessage() {}
+
+func (x *Flight) ProtoReflect() protoreflect.Message {
+	mi := &file_flights_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Flight.ProtoReflect.Descriptor instead.
+func (*Flight) Descriptor() ([]byte, []int) {
+	return file_flights_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Flight) GetAirlineId() string {
+	if x != nil {
+		return x.AirlineId
+	}
+	return ""
+}
+
+func (x *Flight) GetFlightId() string {
+	if x != nil {
+		return x.FlightId
+	}
+	return ""
+}
+
+func (x *Flight) GetDepartureTimestamp() int64 {
+	if x != nil {
+		return x.DepartureTimestamp
+	}
+	return 0
+}
+
+func (x *Flight) GetStatus() FlightStatus {
+	if x != nil {
+		return x.Status
+	}
+	return FlightStatus_FLIGHT_STATUS_UNSPECIFIED
+}
+
+func (x *Flight) GetUpdatedAt() int64 {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return 0
+}
+
+func (x *Flight) GetAircraftId() string {
+	if x != nil {
+		return x.AircraftId
+	}
+	return ""
+}
+
+func (x *Flight) GetSignature() string {
+	if x != nil {
+		return x.Signature
+	}
+	return ""
+}
+
+func (x *Flight) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *Flight) GetTraceParent() string {
+	if x != nil {
+		return x.TraceParent
+	}
+	return ""
+}
+
+type ListAirlinesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListAirlinesRequest) Reset() {
+	*x = ListAirlinesRequest{}
+	mi := &file_flights_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListAirlinesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAirlinesRequest) ProtoMessage() {}
+
+func (x *ListAirlinesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_flights_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAirlinesRequest.ProtoReflect.Descriptor instead.
+func (*ListAirlinesRequest) Descriptor() ([]byte, []int) {
+	return file_flights_proto_rawDescGZIP(), []int{2}
+}
+
+type ListAirlinesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Airlines      []*Airline             `protobuf:"bytes,1,rep,name=airlines,proto3" json:"airlines,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListAirlinesResponse) Reset() {
+	*x = ListAirlinesResponse{}
+	mi := &file_flights_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListAirlinesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAirlinesResponse) ProtoMessage() {}
+
+func (x *ListAirlinesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_flights_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAirlinesResponse.ProtoReflect.Descriptor instead.
+func (*ListAirlinesResponse) Descriptor() ([]byte, []int) {
+	return file_flights_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ListAirlinesResponse) GetAirlines() []*Airline {
+	if x != nil {
+		return x.Airlines
+	}
+	return nil
+}
+
+type ListFlightsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AirlineId     string                 `protobuf:"bytes,1,opt,name=airline_id,json=airlineId,proto3" json:"airline_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListFlightsRequest) Reset() {
+	*x = ListFlightsRequest{}
+	mi := &file_flights_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListFlightsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListFlightsRequest) ProtoMessage() {}
+
+func (x *ListFlightsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_flights_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListFlightsRequest.ProtoReflect.Descriptor instead.
+func (*ListFlightsRequest) Descriptor() ([]byte, []int) {
+	return file_flights_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ListFlightsRequest) GetAirlineId() string {
+	if x != nil {
+		return x.AirlineId
+	}
+	return ""
+}
+
+type ListFlightsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Flights       []*Flight              `protobuf:"bytes,1,rep,name=flights,proto3" json:"flights,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListFlightsResponse) Reset() {
+	*x = ListFlightsResponse{}
+	mi := &file_flights_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListFlightsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListFlightsResponse) ProtoMessage() {}
+
+func (x *ListFlightsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_flights_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListFlightsResponse.ProtoReflect.Descriptor instead.
+func (*ListFlightsResponse) Descriptor() ([]byte, []int) {
+	return file_flights_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ListFlightsResponse) GetFlights() []*Flight {
+	if x != nil {
+		return x.Flights
+	}
+	return nil
+}
+
+type GetFlightRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AirlineId     string                 `protobuf:"bytes,1,opt,name=airline_id,json=airlineId,proto3" json:"airline_id,omitempty"`
+	FlightId      string                 `protobuf:"bytes,2,opt,name=flight_id,json=flightId,proto3" json:"flight_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetFlightRequest) Reset() {
+	*x = GetFlightRequest{}
+	mi := &file_flights_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetFlightRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetFlightRequest) ProtoMessage() {}
+
+func (x *GetFlightRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_flights_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetFlightRequest.ProtoReflect.Descriptor instead.
+func (*GetFlightRequest) Descriptor() ([]byte, []int) {
+	return file_flights_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *GetFlightRequest) GetAirlineId() string {
+	if x != nil {
+		return x.AirlineId
+	}
+	return ""
+}
+
+func (x *GetFlightRequest) GetFlightId() string {
+	if x != nil {
+		return x.FlightId
+	}
+	return ""
+}
+
+type GetFlightResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Flight        *Flight                `protobuf:"bytes,1,opt,name=flight,proto3" json:"flight,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetFlightResponse) Reset() {
+	*x = GetFlightResponse{}
+	mi := &file_flights_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetFlightResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetFlightResponse) ProtoMessage() {}
+
+func (x *GetFlightResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_flights_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetFlightResponse.ProtoReflect.Descriptor instead.
+func (*GetFlightResponse) Descriptor() ([]byte, []int) {
+	return file_flights_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *GetFlightResponse) GetFlight() *Flight {
+	if x != nil {
+		return x.Flight
+	}
+	return nil
+}
+
+type CreateFlightRequest struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	AirlineId          string                 `protobuf:"bytes,1,opt,name=airline_id,json=airlineId,proto3" json:"airline_id,omitempty"`
+	FlightId           string                 `protobuf:"bytes,2,opt,name=flight_id,json=flightId,proto3" json:"flight_id,omitempty"`
+	DepartureTimestamp int64                  `protobuf:"varint,3,opt,name=departure_timestamp,json=departureTimestamp,proto3" json:"departure_timestamp,omitempty"`
+	AircraftId         string                 `protobuf:"bytes,4,opt,name=aircraft_id,json=aircraftId,proto3" json:"aircraft_id,omitempty"`
+	// The signed timestamp; required with a signature.
+	UpdatedAt     int64  `protobuf:"varint,5,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	Signature     string `protobuf:"bytes,6,opt,name=signature,proto3" json:"signature,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateFlightRequest) Reset() {
+	*x = CreateFlightRequest{}
+	mi := &file_flights_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateFlightRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateFlightRequest) ProtoMessage() {}
+
+func (x *CreateFlightRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_flights_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateFlightRequest.ProtoReflect.Descriptor instead.
+func (*CreateFlightRequest) Descriptor() ([]byte, []int) {
+	return file_flights_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *CreateFlightRequest) GetAirlineId() string {
+	if x != nil {
+		return x.AirlineId
+	}
+	return ""
+}
+
+func (x *CreateFlightRequest) GetFlightId() string {
+	if x != nil {
+		return x.FlightId
+	}
+	return ""
+}
+
+func (x *CreateFlightRequest) GetDepartureTimestamp() int64 {
+	if x != nil {
+		return x.DepartureTimestamp
+	}
+	return 0
+}
+
+func (x *CreateFlightRequest) GetAircraftId() string {
+	if x != nil {
+		return x.AircraftId
+	}
+	return ""
+}
+
+func (x *CreateFlightRequest) GetUpdatedAt() int64 {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return 0
+}
+
+func (x *CreateFlightRequest) GetSignature() string {
+	if x != nil {
+		return x.Signature
+	}
+	return ""
+}
+
+type CreateFlightResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Flight        *Flight                `protobuf:"bytes,1,opt,name=flight,proto3" json:"flight,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateFlightResponse) Reset() {
+	*x = CreateFlightResponse{}
+	mi := &file_flights_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateFlightResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateFlightResponse) ProtoMessage() {}
+
+func (x *CreateFlightResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_flights_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateFlightResponse.ProtoReflect.Descriptor instead.
+func (*CreateFlightResponse) Descriptor() ([]byte, []int) {
+	return file_flights_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *CreateFlightResponse) GetFlight() *Flight {
+	if x != nil {
+		return x.Flight
+	}
+	return nil
+}
+
+type UpdateStatusRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	AirlineId string                 `protobuf:"bytes,1,opt,name=airline_id,json=airlineId,proto3" json:"airline_id,omitempty"`
+	FlightId  string                 `protobuf:"bytes,2,opt,name=flight_id,json=flightId,proto3" json:"flight_id,omitempty"`
+	Status    FlightStatus           `protobuf:"varint,3,opt,name=status,proto3,enum=flights.v1.FlightStatus" json:"status,omitempty"`
+	UpdatedAt int64                  `protobuf:"varint,4,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	Signature string                 `protobuf:"bytes,5,opt,name=signature,proto3" json:"signature,omitempty"`
+	// When non-zero, the update fails with FAILED_PRECONDITION unless the flight
+	// is at this version.
+	IfVersion     int64 `protobuf:"varint,6,opt,name=if_version,json=ifVersion,proto3" json:"if_version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateStatusRequest) Reset() {
+	*x = UpdateStatusRequest{}
+	mi := &file_flights_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateStatusRequest) ProtoMessage() {}
+
+func (x *UpdateStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_flights_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateStatusRequest.ProtoReflect.Descriptor instead.
+func (*UpdateStatusRequest) Descriptor() ([]byte, []int) {
+	return file_flights_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *UpdateStatusRequest) GetAirlineId() string {
+	if x != nil {
+		return x.AirlineId
+	}
+	return ""
+}
+
+func (x *UpdateStatusRequest) GetFlightId() string {
+	if x != nil {
+		return x.FlightId
+	}
+	return ""
+}
+
+func (x *UpdateStatusRequest) GetStatus() FlightStatus {
+	if x != nil {
+		return x.Status
+	}
+	return FlightStatus_FLIGHT_STATUS_UNSPECIFIED
+}
+
+func (x *UpdateStatusRequest) GetUpdatedAt() int64 {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return 0
+}
+
+func (x *UpdateStatusRequest) GetSignature() string {
+	if x != nil {
+		return x.Signature
+	}
+	return ""
+}
+
+func (x *UpdateStatusRequest) GetIfVersion() int64 {
+	if x != nil {
+		return x.IfVersion
+	}
+	return 0
+}
+
+type UpdateStatusResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Flight        *Flight                `protobuf:"bytes,1,opt,name=flight,proto3" json:"flight,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateStatusResponse) Reset() {
+	*x = UpdateStatusResponse{}
+	mi := &file_flights_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateStatusResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateStatusResponse) ProtoMessage() {}
+
+func (x *UpdateStatusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_flights_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateStatusResponse.ProtoReflect.Descriptor instead.
+func (*UpdateStatusResponse) Descriptor() ([]byte, []int) {
+	return file_flights_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *UpdateStatusResponse) GetFlight() *Flight {
+	if x != nil {
+		return x.Flight
+	}
+	return nil
+}
+
+type WatchFlightsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Only stream flights of this airline; empty streams all airlines.
+	AirlineId string `protobuf:"bytes,1,opt,name=airline_id,json=airlineId,proto3" json:"airline_id,omitempty"`
+	// Send every current flight before streaming changes.
+	SendInitial   bool `protobuf:"varint,2,opt,name=send_initial,json=sendInitial,proto3" json:"send_initial,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchFlightsRequest) Reset() {
+	*x = WatchFlightsRequest{}
+	mi := &file_flights_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchFlightsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchFlightsRequest) ProtoMessage() {}
+
+func (x *WatchFlightsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_flights_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchFlightsRequest.ProtoReflect.Descriptor instead.
+func (*WatchFlightsRequest) Descriptor() ([]byte, []int) {
+	return file_flights_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *WatchFlightsRequest) GetAirlineId() string {
+	if x != nil {
+		return x.AirlineId
+	}
+	return ""
+}
+
+func (x *WatchFlightsRequest) GetSendInitial() bool {
+	if x != nil {
+		return x.SendInitial
+	}
+	return false
+}
+
+type WatchFlightsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Flight        *Flight                `protobuf:"bytes,1,opt,name=flight,proto3" json:"flight,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchFlightsResponse) Reset() {
+	*x = WatchFlightsResponse{}
+	mi := &file_flights_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchFlightsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchFlightsResponse) ProtoMessage() {}
+
+func (x *WatchFlightsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_flights_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchFlightsResponse.ProtoReflect.Descriptor instead.
+func (*WatchFlightsResponse) Descriptor() ([]byte, []int) {
+	return file_flights_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *WatchFlightsResponse) GetFlight() *Flight {
+	if x != nil {
+		return x.Flight
+	}
+	return nil
+}
+
+var File_flights_proto protoreflect.FileDescriptor
+
+const file_flights_proto_rawDesc = "" +
+	"\n" +
+	"\rflights.proto\x12\n" +
+	"flights.v1\"h\n" +
+	"\aAirline\x12\x1d\n" +
+	"\n" +
+	"airline_id\x18\x01 \x01(\tR\tairlineId\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x12\n" +
+	"\x04code\x18\x03 \x01(\tR\x04code\x12\x16\n" +
+	"\x06signer\x18\x04 \x01(\tR\x06signer\"\xc2\x02\n" +
+	"\x06Flight\x12\x1d\n" +
+	"\n" +
+	"airline_id\x18\x01 \x01(\tR\tairlineId\x12\x1b\n" +
+	"\tflight_id\x18\x02 \x01(\tR\bflightId\x12/\n" +
+	"\x13departure_timestamp\x18\x03 \x01(\x03R\x12departureTimestamp\x120\n" +
+	"\x06status\x18\x04 \x01(\x0e2\x18.flights.v1.FlightStatusR\x06status\x12\x1d\n" +
+	"\n" +
+	"updated_at\x18\x05 \x01(\x03R\tupdatedAt\x12\x1f\n" +
+	"\vaircraft_id\x18\x06 \x01(\tR\n" +
+	"aircraftId\x12\x1c\n" +
+	"\tsignature\x18\a \x01(\tR\tsignature\x12\x18\n" +
+	"\aversion\x18\b \x01(\x03R\aversion\x12!\n" +
+	"\ftrace_parent\x18\t \x01(\tR\vtraceParent\"\x15\n" +
+	"\x13ListAirlinesRequest\"G\n" +
+	"\x14ListAirlinesResponse\x12/\n" +
+	"\bairlines\x18\x01 \x03(\v2\x13.flights.v1.AirlineR\bairlines\"3\n" +
+	"\x12ListFlightsRequest\x12\x1d\n" +
+	"\n" +
+	"airline_id\x18\x01 \x01(\tR\tairlineId\"C\n" +
+	"\x13ListFlightsResponse\x12,\n" +
+	"\aflights\x18\x01 \x03(\v2\x12.flights.v1.FlightR\aflights\"N\n" +
+	"\x10GetFlightRequest\x12\x1d\n" +
+	"\n" +
+	"airline_id\x18\x01 \x01(\tR\tairlineId\x12\x1b\n" +
+	"\tflight_id\x18\x02 \x01(\tR\bflightId\"?\n" +
+	"\x11GetFlightResponse\x12*\n" +
+	"\x06flight\x18\x01 \x01(\v2\x12.flights.v1.FlightR\x06flight\"\xe0\x01\n" +
+	"\x13CreateFlightRequest\x12\x1d\n" +
+	"\n" +
+	"airline_id\x18\x01 \x01(\tR\tairlineId\x12\x1b\n" +
+	"\tflight_id\x18\x02 \x01(\tR\bflightId\x12/\n" +
+	"\x13departure_timestamp\x18\x03 \x01(\x03R\x12departureTimestamp\x12\x1f\n" +
+	"\vaircraft_id\x18\x04 \x01(\tR\n" +
+	"aircraftId\x12\x1d\n" +
+	"\n" +
+	"updated_at\x18\x05 \x01(\x03R\tupdatedAt\x12\x1c\n" +
+	"\tsignature\x18\x06 \x01(\tR\tsignature\"B\n" +
+	"\x14CreateFlightResponse\x12*\n" +
+	"\x06flight\x18\x01 \x01(\v2\x12.flights.v1.FlightR\x06flight\"\xdf\x01\n" +
+	"\x13UpdateStatusRequest\x12\x1d\n" +
+	"\n" +
+	"airline_id\x18\x01 \x01(\tR\tairlineId\x12\x1b\n" +
+	"\tflight_id\x18\x02 \x01(\tR\bflightId\x120\n" +
+	"\x06status\x18\x03 \x01(\x0e2\x18.flights.v1.FlightStatusR\x06status\x12\x1d\n" +
+	"\n" +
+	"updated_at\x18\x04 \x01(\x03R\tupdatedAt\x12\x1c\n" +
+	"\tsignature\x18\x05 \x01(\tR\tsignature\x12\x1d\n" +
+	"\n" +
+	"if_version\x18\x06 \x01(\x03R\tifVersion\"B\n" +
+	"\x14UpdateStatusResponse\x12*\n" +
+	"\x06flight\x18\x01 \x01(\v2\x12.flights.v1.FlightR\x06flight\"W\n" +
+	"\x13WatchFlightsRequest\x12\x1d\n" +
+	"\n" +
+	"airline_id\x18\x01 \x01(\tR\tairlineId\x12!\n" +
+	"\fsend_initial\x18\x02 \x01(\bR\vsendInitial\"B\n" +
+	"\x14WatchFlightsResponse\x12*\n" +
+	"\x06flight\x18\x01 \x01(\v2\x12.flights.v1.FlightR\x06flight*\x81\x01\n" +
+	"\fFlightStatus\x12\x1d\n" +
+	"\x19FLIGHT_STATUS_UNSPECIFIED\x10\x00\x12\x1b\n" +
+	"\x17FLIGHT_STATUS_SCHEDULED\x10\x01\x12\x19\n" +
+	"\x15FLIGHT_STATUS_DELAYED\x10\x02\x12\x1a\n" +
+	"\x16FLIGHT_STATUS_DEPARTED\x10\x032\xf8\x03\n" +
+	"\x0eFlightsService\x12Q\n" +
+	"\fListAirlines\x12\x1f.flights.v1.ListAirlinesRequest\x1a .flights.v1.ListAirlinesResponse\x12N\n" +
+	"\vListFlights\x12\x1e.flights.v1.ListFlightsRequest\x1a\x1f.flights.v1.ListFlightsResponse\x12H\n" +
+	"\tGetFlight\x12\x1c.flights.v1.GetFlightRequest\x1a\x1d.flights.v1.GetFlightResponse\x12Q\n" +
+	"\fCreateFlight\x12\x1f.flights.v1.CreateFlightRequest\x1a .flights.v1.CreateFlightResponse\x12Q\n" +
+	"\fUpdateStatus\x12\x1f.flights.v1.UpdateStatusRequest\x1a .flights.v1.UpdateStatusResponse\x12S\n" +
+	"\fWatchFlights\x12\x1f.flights.v1.WatchFlightsRequest\x1a .flights.v1.WatchFlightsResponse0\x01B Z\x1esum/internal/flights/flightspbb\x06proto3"
+
+var (
+	file_flights_proto_rawDescOnce sync.Once
+	file_flights_proto_rawDescData []byte
+)
+
+func file_flights_proto_rawDescGZIP() []byte {
+	file_flights_proto_rawDescOnce.Do(func() {
+		file_flights_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_flights_proto_rawDesc), len(file_flights_proto_rawDesc)))
+	})
+	return file_flights_proto_rawDescData
+}
+
+var file_flights_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_flights_proto_msgTypes = make([]protoimpl.MessageInfo, 14)
+var file_flights_proto_goTypes = []any{
+	(FlightStatus)(0),            // 0: flights.v1.FlightStatus
+	(*Airline)(nil),              // 1: flights.v1.Airline
+	(*Flight)(nil),               // 2: flights.v1.Flight
+	(*ListAirlinesRequest)(nil),  // 3: flights.v1.ListAirlinesRequest
+	(*ListAirlinesResponse)(nil), // 4: flights.v1.ListAirlinesResponse
+	(*ListFlightsRequest)(nil),   // 5: flights.v1.ListFlightsRequest
+	(*ListFlightsResponse)(nil),  // 6: flights.v1.ListFlightsResponse
+	(*GetFlightRequest)(nil),     // 7: flights.v1.GetFlightRequest
+	(*GetFlightResponse)(nil),    // 8: flights.v1.GetFlightResponse
+	(*CreateFlightRequest)(nil),  // 9: flights.v1.CreateFlightRequest
+	(*CreateFlightResponse)(nil), // 10: flights.v1.CreateFlightResponse
+	(*UpdateStatusRequest)(nil),  // 11: flights.v1.UpdateStatusRequest
+	(*UpdateStatusResponse)(nil), // 12: flights.v1.UpdateStatusResponse
+	(*WatchFlightsRequest)(nil),  // 13: flights.v1.WatchFlightsRequest
+	(*WatchFlightsResponse)(nil), // 14: flights.v1.WatchFlightsResponse
+}
+var file_flights_proto_depIdxs = []int32{
+	0,  // 0: flights.v1.Flight.status:type_name -> flights.v1.FlightStatus
+	1,  // 1: flights.v1.ListAirlinesResponse.airlines:type_name -> flights.v1.Airline
+	2,  // 2: flights.v1.ListFlightsResponse.flights:type_name -> flights.v1.Flight
+	2,  // 3: flights.v1.GetFlightResponse.flight:type_name -> flights.v1.Flight
+	2,  // 4: flights.v1.CreateFlightResponse.flight:type_name -> flights.v1.Flight
+	0,  // 5: flights.v1.UpdateStatusRequest.status:type_name -> flights.v1.FlightStatus
+	2,  // 6: flights.v1.UpdateStatusResponse.flight:type_name -> flights.v1.Flight
+	2,  // 7: flights.v1.WatchFlightsResponse.flight:type_name -> flights.v1.Flight
+	3,  // 8: flights.v1.FlightsService.ListAirlines:input_type -> flights.v1.ListAirlinesRequest
+	5,  // 9: flights.v1.FlightsService.ListFlights:input_type -> flights.v1.ListFlightsRequest
+	7,  // 10: flights.v1.FlightsService.GetFlight:input_type -> flights.v1.GetFlightRequest
+	9,  // 11: flights.v1.FlightsService.CreateFlight:input_type -> flights.v1.CreateFlightRequest
+	11, // 12: flights.v1.FlightsService.UpdateStatus:input_type -> flights.v1.UpdateStatusRequest
+	13, // 13: flights.v1.FlightsService.WatchFlights:input_type -> flights.v1.WatchFlightsRequest
+	4,  // 14: flights.v1.FlightsService.ListAirlines:output_type -> flights.v1.ListAirlinesResponse
+	6,  // 15: flights.v1.FlightsService.ListFlights:output_type -> flights.v1.ListFlightsResponse
+	8,  // 16: flights.v1.FlightsService.GetFlight:output_type -> flights.v1.GetFlightResponse
+	10, // 17: flights.v1.FlightsService.CreateFlight:output_type -> flights.v1.CreateFlightResponse
+	12, // 18: flights.v1.FlightsService.UpdateStatus:output_type -> flights.v1.UpdateStatusResponse
+	14, // 19: flights.v1.FlightsService.WatchFlights:output_type -> flights.v1.WatchFlightsResponse
+	14, // [14:20] is the sub-list for method output_type
+	8,  // [8:14] is the sub-list for method input_type
+	8,  // [8:8] is the sub-list for extension type_name
+	8,  // [8:8] is the sub-list for extension extendee
+	0,  // [0:8] is the sub-list for field type_name
+}
+
+func init() { file_flights_proto_init() }
+func file_flights_proto_init() {
+	if File_flights_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_flights_proto_rawDesc), len(file_flights_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   14,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_flights_proto_goTypes,
+		DependencyIndexes: file_flights_proto_depIdxs,
+		EnumInfos:         file_flights_proto_enumTypes,
+		MessageInfos:      file_flights_proto_msgTypes,
+	}.Build()
+	File_flights_proto = out.File
+	file_flights_proto_goTypes = nil
+	file_flights_proto_depIdxs = nil
+}
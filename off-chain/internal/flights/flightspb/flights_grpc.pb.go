@@ -0,0 +1,329 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: flights.proto
+
+package flightspb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	FlightsService_ListAirlines_FullMethodName = "/flights.v1.FlightsService/ListAirlines"
+	FlightsService_ListFlights_FullMethodName  = "/flights.v1.FlightsService/ListFlights"
+	FlightsService_GetFlight_FullMethodName    = "/flights.v1.FlightsService/GetFlight"
+	FlightsService_CreateFlight_FullMethodName = "/flights.v1.FlightsService/CreateFlight"
+	FlightsService_UpdateStatus_FullMethodName = "/flights.v1.FlightsService/UpdateStatus"
+	FlightsService_WatchFlights_FullMethodName = "/flights.v1.FlightsService/WatchFlights"
+)
+
+// FlightsServiceClient is the client API for FlightsService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// FlightsService serves the same store as the flights REST API. Errors carry a
+// google.rpc.ErrorInfo whose reason is the REST error code, e.g.
+// "flight_not_found".
+type FlightsServiceClient interface {
+	ListAirlines(ctx context.Context, in *ListAirlinesRequest, opts ...grpc.CallOption) (*ListAirlinesResponse, error)
+	ListFlights(ctx context.Context, in *ListFlightsRequest, opts ...grpc.CallOption) (*ListFlightsResponse, error)
+	GetFlight(ctx context.Context, in *GetFlightRequest, opts ...grpc.CallOption) (*GetFlightResponse, error)
+	CreateFlight(ctx context.Context, in *CreateFlightRequest, opts ...grpc.CallOption) (*CreateFlightResponse, error)
+	UpdateStatus(ctx context.Context, in *UpdateStatusRequest, opts ...grpc.CallOption) (*UpdateStatusResponse, error)
+	// WatchFlights streams every created or updated flight. The stream ends with
+	// ABORTED when the watcher falls behind or the store is restored; clients
+	// should list flights again and resubscribe.
+	WatchFlights(ctx context.Context, in *WatchFlightsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[WatchFlightsResponse], error)
+}
+
+type flightsServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewFlightsServiceClient(cc grpc.ClientConnInterface) FlightsServiceClient {
+	return &flightsServiceClient{cc}
+}
+
+func (c *flightsServiceClient) ListAirlines(ctx context.Context, in *ListAirlinesRequest, opts ...grpc.CallOption) (*ListAirlinesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListAirlinesResponse)
+	err := c.cc.Invoke(ctx, FlightsService_ListAirlines_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *flightsServiceClient) ListFlights(ctx context.Context, in *ListFlightsRequest, opts ...grpc.CallOption) (*ListFlightsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListFlightsResponse)
+	err := c.cc.Invoke(ctx, FlightsService_ListFlights_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *flightsServiceClient) GetFlight(ctx context.Context, in *GetFlightRequest, opts ...grpc.CallOption) (*GetFlightResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetFlightResponse)
+	err := c.cc.Invoke(ctx, FlightsService_GetFlight_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *flightsServiceClient) CreateFlight(ctx context.Context, in *CreateFlightRequest, opts ...grpc.CallOption) (*CreateFlightResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CreateFlightResponse)
+	err := c.cc.Invoke(ctx, FlightsService_CreateFlight_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *flightsServiceClient) UpdateStatus(ctx context.Context, in *UpdateStatusRequest, opts ...grpc.CallOption) (*UpdateStatusResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UpdateStatusResponse)
+	err := c.cc.Invoke(ctx, FlightsService_UpdateStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *flightsServiceClient) WatchFlights(ctx context.Context, in *WatchFlightsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[WatchFlightsResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &FlightsService_ServiceDesc.Streams[0], FlightsService_WatchFlights_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchFlightsRequest, WatchFlightsResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type FlightsService_WatchFlightsClient = grpc.ServerStreamingClient[WatchFlightsResponse]
+
+// FlightsServiceServer is the server API for FlightsService service.
+// All implementations must embed UnimplementedFlightsServiceServer
+// for forward compatibility.
+//
+// FlightsService serves the same store as the flights REST API. Errors carry a
+// google.rpc.ErrorInfo whose reason is the REST error code, e.g.
+// "flight_not_found".
+type FlightsServiceServer interface {
+	ListAirlines(context.Context, *ListAirlinesRequest) (*ListAirlinesResponse, error)
+	ListFlights(context.Context, *ListFlightsRequest) (*ListFlightsResponse, error)
+	GetFlight(context.Context, *GetFlightRequest) (*GetFlightResponse, error)
+	CreateFlight(context.Context, *CreateFlightRequest) (*CreateFlightResponse, error)
+	UpdateStatus(context.Context, *UpdateStatusRequest) (*UpdateStatusResponse, error)
+	// WatchFlights streams every created or updated flight. The stream ends with
+	// ABORTED when the watcher falls behind or the store is restored; clients
+	// should list flights again and resubscribe.
+	WatchFlights(*WatchFlightsRequest, grpc.ServerStreamingServer[WatchFlightsResponse]) error
+	mustEmbedUnimplementedFlightsServiceServer()
+}
+
+// UnimplementedFlightsServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedFlightsServiceServer struct{}
+
+func (UnimplementedFlightsServiceServer) ListAirlines(context.Context, *ListAirlinesRequest) (*ListAirlinesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListAirlines not implemented")
+}
+func (UnimplementedFlightsServiceServer) ListFlights(context.Context, *ListFlightsRequest) (*ListFlightsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListFlights not implemented")
+}
+func (UnimplementedFlightsServiceServer) GetFlight(context.Context, *GetFlightRequest) (*GetFlightResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetFlight not implemented")
+}
+func (UnimplementedFlightsServiceServer) CreateFlight(context.Context, *CreateFlightRequest) (*CreateFlightResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateFlight not implemented")
+}
+func (UnimplementedFlightsServiceServer) UpdateStatus(context.Context, *UpdateStatusRequest) (*UpdateStatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateStatus not implemented")
+}
+func (UnimplementedFlightsServiceServer) WatchFlights(*WatchFlightsRequest, grpc.ServerStreamingServer[WatchFlightsResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchFlights not implemented")
+}
+func (UnimplementedFlightsServiceServer) mustEmbedUnimplementedFlightsServiceServer() {}
+func (UnimplementedFlightsServiceServer) testEmbeddedByValue()                        {}
+
+// UnsafeFlightsServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to FlightsServiceServer will
+// result in compilation errors.
+type UnsafeFlightsServiceServer interface {
+	mustEmbedUnimplementedFlightsServiceServer()
+}
+
+func RegisterFlightsServiceServer(s grpc.ServiceRegistrar, srv FlightsServiceServer) {
+	// If the following call pancis, it indicates UnimplementedFlightsServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&FlightsService_ServiceDesc, srv)
+}
+
+func _FlightsService_ListAirlines_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListAirlinesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FlightsServiceServer).ListAirlines(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FlightsService_ListAirlines_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FlightsServiceServer).ListAirlines(ctx, req.(*ListAirlinesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FlightsService_ListFlights_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListFlightsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FlightsServiceServer).ListFlights(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FlightsService_ListFlights_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FlightsServiceServer).ListFlights(ctx, req.(*ListFlightsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FlightsService_GetFlight_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetFlightRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FlightsServiceServer).GetFlight(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FlightsService_GetFlight_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FlightsServiceServer).GetFlight(ctx, req.(*GetFlightRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FlightsService_CreateFlight_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateFlightRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FlightsServiceServer).CreateFlight(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FlightsService_CreateFlight_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FlightsServiceServer).CreateFlight(ctx, req.(*CreateFlightRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FlightsService_UpdateStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FlightsServiceServer).UpdateStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FlightsService_UpdateStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FlightsServiceServer).UpdateStatus(ctx, req.(*UpdateStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FlightsService_WatchFlights_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchFlightsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(FlightsServiceServer).WatchFlights(m, &grpc.GenericServerStream[WatchFlightsRequest, WatchFlightsResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type FlightsService_WatchFlightsServer = grpc.ServerStreamingServer[WatchFlightsResponse]
+
+// FlightsService_ServiceDesc is the grpc.ServiceDesc for FlightsService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var FlightsService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "flights.v1.FlightsService",
+	HandlerType: (*FlightsServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListAirlines",
+			Handler:    _FlightsService_ListAirlines_Handler,
+		},
+		{
+			MethodName: "ListFlights",
+			Handler:    _FlightsService_ListFlights_Handler,
+		},
+		{
+			MethodName: "GetFlight",
+			Handler:    _FlightsService_GetFlight_Handler,
+		},
+		{
+			MethodName: "CreateFlight",
+			Handler:    _FlightsService_CreateFlight_Handler,
+		},
+		{
+			MethodName: "UpdateStatus",
+			Handler:    _FlightsService_UpdateStatus_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchFlights",
+			Handler:       _FlightsService_WatchFlights_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "flights.proto",
+}
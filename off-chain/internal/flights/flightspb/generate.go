@@ -0,0 +1,5 @@
+// Package flightspb holds the gRPC FlightsService generated from flights.proto
+// and the conversions between its messages and the flights package types.
+package flightspb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative flights.proto
@@ -0,0 +1,131 @@
+package flights
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ProblemContentType is the media type of flights API error responses (RFC 7807).
+const ProblemContentType = "application/problem+json"
+
+// ErrorCode is a stable, machine-readable error identifier. Codes are part of
+// the API contract: add new ones, never rename existing ones.
+type ErrorCode string
+
+const (
+	CodeInvalidRequest          ErrorCode = "invalid_request"
+	CodeValidationFailed        ErrorCode = "validation_failed"
+	CodeRequired                ErrorCode = "required"
+	CodeInvalidValue            ErrorCode = "invalid_value"
+	CodeUnauthenticated         ErrorCode = "unauthenticated"
+	CodeForbidden               ErrorCode = "forbidden"
+	CodeNotFound                ErrorCode = "not_found"
+	CodeMethodNotAllowed        ErrorCode = "method_not_allowed"
+	CodeAirlineExists           ErrorCode = "airline_exists"
+	CodeAirlineNotFound         ErrorCode = "airline_not_found"
+	CodeInvalidAirline          ErrorCode = "invalid_airline"
+	CodeFlightExists            ErrorCode = "flight_exists"
+	CodeFlightNotFound          ErrorCode = "flight_not_found"
+	CodeInvalidFlight           ErrorCode = "invalid_flight"
+	CodeInvalidStatus           ErrorCode = "invalid_status"
+	CodeInvalidStatusTransition ErrorCode = "invalid_status_transition"
+	CodeInvalidSnapshot         ErrorCode = "invalid_snapshot"
+	CodeInvalidAttestation      ErrorCode = "invalid_attestation"
+	CodeMissingAttestation      ErrorCode = "missing_attestation"
+	CodeVersionMismatch         ErrorCode = "version_mismatch"
+	CodeClockBackwards          ErrorCode = "clock_backwards"
+	CodeImportRejected          ErrorCode = "import_rejected"
+	CodeIdempotencyKeyReused    ErrorCode = "idempotency_key_reused"
+	CodeIdempotencyInProgress   ErrorCode = "idempotency_in_progress"
+	CodeFeatureDisabled         ErrorCode = "feature_disabled"
+	CodeRateLimited             ErrorCode = "rate_limited"
+	CodePayloadTooLarge         ErrorCode = "payload_too_large"
+	CodeInternal                ErrorCode = "internal"
+)
+
+// sentinelCodes maps store errors to their codes, and back for clients.
+var sentinelCodes = []struct {
+	err  error
+	code ErrorCode
+}{
+	{ErrAirlineExists, CodeAirlineExists},
+	{ErrAirlineNotFound, CodeAirlineNotFound},
+	{ErrInvalidAirline, CodeInvalidAirline},
+	{ErrFlightExists, CodeFlightExists},
+	{ErrFlightNotFound, CodeFlightNotFound},
+	{ErrInvalidFlight, CodeInvalidFlight},
+	{ErrInvalidStatusTransition, CodeInvalidStatusTransition},
+	{ErrInvalidStatus, CodeInvalidStatus},
+	{ErrInvalidSnapshot, CodeInvalidSnapshot},
+	{ErrVersionMismatch, CodeVersionMismatch},
+	{ErrInvalidAttestation, CodeInvalidAttestation},
+	{ErrMissingAttestation, CodeMissingAttestation},
+	{ErrClockBackwards, CodeClockBackwards},
+}
+
+// CodeOf returns the code of the first sentinel err wraps, or CodeInternal.
+func CodeOf(err error) ErrorCode {
+	var problem *Problem
+	if errors.As(err, &problem) {
+		return problem.Code
+	}
+	for _, sc := range sentinelCodes {
+		if errors.Is(err, sc.err) {
+			return sc.code
+		}
+	}
+	return CodeInternal
+}
+
+// FieldError points at one invalid part of a request.
+type FieldError struct {
+	Field   string    `json:"field"`
+	Code    ErrorCode `json:"code"`
+	Message string    `json:"message"`
+}
+
+// Problem is an RFC 7807 problem document extended with a stable code, the
+// request ID and field-level details. It doubles as the Go error returned by
+// flights API clients.
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	Code      ErrorCode    `json:"code"`
+	RequestID string       `json:"requestId,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+// ProblemType is the type URI identifying a code.
+func ProblemType(code ErrorCode) string {
+	return "urn:flights-api:problem:" + string(code)
+}
+
+func (p *Problem) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "flights api: %d %s", p.Status, p.Code)
+	if p.Detail != "" {
+		b.WriteString(": " + p.Detail)
+	}
+	for _, fe := range p.Errors {
+		fmt.Fprintf(&b, "; %s: %s", fe.Field, fe.Message)
+	}
+	if p.RequestID != "" {
+		b.WriteString(" (request " + p.RequestID + ")")
+	}
+	return b.String()
+}
+
+// Unwrap returns the store sentinel matching the problem's code, so clients can
+// use errors.Is(err, flights.ErrFlightNotFound).
+func (p *Problem) Unwrap() error {
+	for _, sc := range sentinelCodes {
+		if sc.code == p.Code {
+			return sc.err
+		}
+	}
+	return nil
+}
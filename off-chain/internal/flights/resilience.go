@@ -0,0 +1,194 @@
+package flights
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without contacting the API while its breaker is open.
+var ErrCircuitOpen = errors.New("flights api circuit open")
+
+// RetryPolicy retries idempotent requests with jittered exponential backoff.
+// The zero policy makes a single attempt.
+type RetryPolicy struct {
+	// Attempts is the total number of tries, including the first.
+	Attempts  int
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+// delay returns the wait before retry n (1-based): a random duration up to
+// BaseDelay*2^(n-1), capped at MaxDelay ("full jitter"). A server-provided
+// Retry-After raises the wait but never past MaxDelay.
+func (p RetryPolicy) delay(n int, retryAfter time.Duration) time.Duration {
+	backoff := p.BaseDelay << (n - 1)
+	if backoff <= 0 || (p.MaxDelay > 0 && backoff > p.MaxDelay) {
+		backoff = p.MaxDelay
+	}
+	wait := time.Duration(0)
+	if backoff > 0 {
+		wait = time.Duration(rand.Int64N(int64(backoff)) + 1)
+	}
+	if retryAfter > wait {
+		wait = retryAfter
+	}
+	if p.MaxDelay > 0 && wait > p.MaxDelay {
+		wait = p.MaxDelay
+	}
+	return wait
+}
+
+// retryable reports whether err means the API is unavailable rather than that
+// the request was wrong: transport and decode errors, 429 and 5xx.
+func retryable(err error) bool {
+	if errors.Is(err, ErrCircuitOpen) {
+		return false
+	}
+	var problem *Problem
+	if errors.As(err, &problem) {
+		return problem.Status == http.StatusTooManyRequests || problem.Status >= 500
+	}
+	return true
+}
+
+// retryAfter reads a Retry-After header given in seconds.
+func retryAfter(header http.Header) time.Duration {
+	seconds, err := strconv.Atoi(header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// BreakerState is the state of a CircuitBreaker.
+type BreakerState int
+
+const (
+	BreakerClosed BreakerState = iota
+	BreakerOpen
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// CircuitBreaker stops calling an API host after Threshold consecutive
+// failures. Once Cooldown has passed it lets a single probe through and closes
+// again if the probe succeeds.
+type CircuitBreaker struct {
+	Threshold int
+	Cooldown  time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// NewCircuitBreaker returns a closed breaker.
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{Threshold: threshold, Cooldown: cooldown, now: time.Now}
+}
+
+// State returns the current state.
+func (b *CircuitBreaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.stateLocked()
+}
+
+func (b *CircuitBreaker) stateLocked() BreakerState {
+	switch {
+	case b.Threshold <= 0 || b.failures < b.Threshold:
+		return BreakerClosed
+	case b.probing || b.now().Sub(b.openedAt) >= b.Cooldown:
+		return BreakerHalfOpen
+	default:
+		return BreakerOpen
+	}
+}
+
+// allow reserves a request, or returns ErrCircuitOpen.
+func (b *CircuitBreaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.stateLocked() {
+	case BreakerOpen:
+		return fmt.Errorf("%w: retrying after %s", ErrCircuitOpen, b.openedAt.Add(b.Cooldown).Sub(b.now()).Round(time.Millisecond))
+	case BreakerHalfOpen:
+		if b.probing {
+			return fmt.Errorf("%w: probe in flight", ErrCircuitOpen)
+		}
+		b.probing = true
+	}
+	return nil
+}
+
+// record reports the outcome of an allowed request. Only errors that mean the
+// host is unavailable count as failures; requests the caller gave up on do not
+// count either way.
+func (b *CircuitBreaker) record(ctx context.Context, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	if err != nil && ctx.Err() != nil {
+		return
+	}
+	if err == nil || !retryable(err) {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.failures >= b.Threshold {
+		b.openedAt = b.now()
+	}
+}
+
+type cachedResponse struct {
+	etag string
+	body []byte
+}
+
+// ResponseCache keeps the last response of each GET path with its ETag, so
+// that the client revalidates with If-None-Match and reuses the body on a 304.
+type ResponseCache struct {
+	mu      sync.Mutex
+	entries map[string]cachedResponse
+}
+
+// NewResponseCache returns an empty cache.
+func NewResponseCache() *ResponseCache {
+	return &ResponseCache{entries: make(map[string]cachedResponse)}
+}
+
+func (c *ResponseCache) get(path string) (cachedResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[path]
+	return entry, ok
+}
+
+func (c *ResponseCache) put(path, etag string, body []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if etag == "" {
+		delete(c.entries, path)
+		return
+	}
+	c.entries[path] = cachedResponse{etag: etag, body: body}
+}
@@ -0,0 +1,181 @@
+package flights
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// SnapshotVersion is the snapshot format written by Store.Snapshot.
+const SnapshotVersion = 1
+
+// Snapshot is a point-in-time copy of every airline and flight in a store.
+type Snapshot struct {
+	Version  int              `json:"version"`
+	TakenAt  int64            `json:"takenAt"`
+	Airlines []Airline        `json:"airlines"`
+	Flights  []SnapshotFlight `json:"flights"`
+}
+
+// SnapshotFlight is a flight together with its status history.
+type SnapshotFlight struct {
+	Flight
+	History []StatusChange `json:"history,omitempty"`
+}
+
+// RestoreMode selects how Restore combines a snapshot with the current contents.
+type RestoreMode string
+
+const (
+	// RestoreReplace discards everything in the store before loading the snapshot.
+	RestoreReplace RestoreMode = "replace"
+	// RestoreMerge keeps existing entries; snapshot entries win on conflicting IDs.
+	RestoreMerge RestoreMode = "merge"
+)
+
+// Snapshot captures a consistent copy of the store. Only the copy happens under
+// the read lock; sorting and encoding are left to the caller's goroutine so that
+// writers are blocked for as short as possible.
+func (s *Store) Snapshot() Snapshot {
+	s.mu.RLock()
+	snap := Snapshot{
+		Version:  SnapshotVersion,
+		TakenAt:  s.clock.Now().Unix(),
+		Airlines: make([]Airline, 0, len(s.airlines)),
+	}
+	for _, airline := range s.airlines {
+		snap.Airlines = append(snap.Airlines, airline)
+	}
+	for _, flightMap := range s.flights {
+		for _, flight := range flightMap {
+			// History is append-only and shared with the store; clipping the
+			// capacity keeps callers from appending into the store's array.
+			history := s.history[flightKey(flight.AirlineID, flight.FlightID)]
+			snap.Flights = append(snap.Flights, SnapshotFlight{
+				Flight:  *flight,
+				History: history[:len(history):len(history)],
+			})
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(snap.Airlines, func(i, j int) bool { return snap.Airlines[i].AirlineID < snap.Airlines[j].AirlineID })
+	sort.Slice(snap.Flights, func(i, j int) bool {
+		a, b := snap.Flights[i], snap.Flights[j]
+		if a.AirlineID != b.AirlineID {
+			return a.AirlineID < b.AirlineID
+		}
+		return a.FlightID < b.FlightID
+	})
+	return snap
+}
+
+// ReadSnapshot decodes a JSON snapshot and checks its version.
+func ReadSnapshot(r io.Reader) (Snapshot, error) {
+	var snap Snapshot
+	if err := json.NewDecoder(r).Decode(&snap); err != nil {
+		return Snapshot{}, fmt.Errorf("%w: %v", ErrInvalidSnapshot, err)
+	}
+	if snap.Version != SnapshotVersion {
+		return Snapshot{}, fmt.Errorf("%w: unsupported version %d (want %d)", ErrInvalidSnapshot, snap.Version, SnapshotVersion)
+	}
+	return snap, nil
+}
+
+// Restore loads a snapshot into the store. The snapshot is validated as a whole
+// first; on error the store is left untouched.
+func (s *Store) Restore(snap Snapshot, mode RestoreMode) error {
+	if mode != RestoreReplace && mode != RestoreMerge {
+		return fmt.Errorf("%w: unknown restore mode %q", ErrInvalidSnapshot, mode)
+	}
+	if snap.Version != SnapshotVersion {
+		return fmt.Errorf("%w: unsupported version %d (want %d)", ErrInvalidSnapshot, snap.Version, SnapshotVersion)
+	}
+	airlines := make(map[string]Airline, len(snap.Airlines))
+	for _, airline := range snap.Airlines {
+		if strings.TrimSpace(airline.AirlineID) == "" {
+			return fmt.Errorf("%w: airline without airlineId", ErrInvalidSnapshot)
+		}
+		if _, dup := airlines[airline.AirlineID]; dup {
+			return fmt.Errorf("%w: duplicate airline %s", ErrInvalidSnapshot, airline.AirlineID)
+		}
+		airlines[airline.AirlineID] = airline
+	}
+	flightMaps := make(map[string]map[string]*Flight)
+	history := make(map[string][]StatusChange, len(snap.Flights))
+	for _, f := range snap.Flights {
+		if err := validateSnapshotFlight(f); err != nil {
+			return err
+		}
+		if flightMaps[f.AirlineID] == nil {
+			flightMaps[f.AirlineID] = make(map[string]*Flight)
+		}
+		if _, dup := flightMaps[f.AirlineID][f.FlightID]; dup {
+			return fmt.Errorf("%w: duplicate flight %s/%s", ErrInvalidSnapshot, f.AirlineID, f.FlightID)
+		}
+		flight := f.Flight
+		if flight.Version == 0 {
+			// Snapshots taken before versions existed.
+			flight.Version = int64(max(len(f.History), 1))
+		}
+		flightMaps[f.AirlineID][f.FlightID] = &flight
+		history[flightKey(f.AirlineID, f.FlightID)] = append([]StatusChange(nil), f.History...)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for airlineID := range flightMaps {
+		_, inSnapshot := airlines[airlineID]
+		_, inStore := s.airlines[airlineID]
+		if !inSnapshot && (mode == RestoreReplace || !inStore) {
+			return fmt.Errorf("%w: flights reference unknown airline %s", ErrInvalidSnapshot, airlineID)
+		}
+	}
+	if mode == RestoreReplace {
+		s.airlines, s.flights, s.history = airlines, flightMaps, history
+		s.closeWatchersLocked()
+		return nil
+	}
+	for airlineID, airline := range airlines {
+		s.airlines[airlineID] = airline
+	}
+	for airlineID, flightMap := range flightMaps {
+		if s.flights[airlineID] == nil {
+			s.flights[airlineID] = make(map[string]*Flight, len(flightMap))
+		}
+		for flightID, flight := range flightMap {
+			s.flights[airlineID][flightID] = flight
+		}
+	}
+	for key, changes := range history {
+		s.history[key] = changes
+	}
+	s.closeWatchersLocked()
+	return nil
+}
+
+func validateSnapshotFlight(f SnapshotFlight) error {
+	if strings.TrimSpace(f.AirlineID) == "" || strings.TrimSpace(f.FlightID) == "" {
+		return fmt.Errorf("%w: flight without airlineId or flightId", ErrInvalidSnapshot)
+	}
+	if !validStatus(f.Status) {
+		return fmt.Errorf("%w: flight %s/%s has invalid status %q", ErrInvalidSnapshot, f.AirlineID, f.FlightID, f.Status)
+	}
+	if f.Version < 0 {
+		return fmt.Errorf("%w: flight %s/%s has negative version", ErrInvalidSnapshot, f.AirlineID, f.FlightID)
+	}
+	for i, change := range f.History {
+		if !validStatus(change.Status) {
+			return fmt.Errorf("%w: flight %s/%s history has invalid status %q", ErrInvalidSnapshot, f.AirlineID, f.FlightID, change.Status)
+		}
+		if i > 0 && (change.At < f.History[i-1].At || !isValidTransition(f.History[i-1].Status, change.Status)) {
+			return fmt.Errorf("%w: flight %s/%s history is out of order at entry %d", ErrInvalidSnapshot, f.AirlineID, f.FlightID, i)
+		}
+	}
+	if n := len(f.History); n > 0 && f.History[n-1].Status != f.Status {
+		return fmt.Errorf("%w: flight %s/%s status %s does not match its history", ErrInvalidSnapshot, f.AirlineID, f.FlightID, f.Status)
+	}
+	return nil
+}
@@ -1,24 +1,50 @@
 package flights
 
 import (
+	"fmt"
 	"sort"
 	"strings"
 	"sync"
-	"time"
+
+	"github.com/ethereum/go-ethereum/common"
 )
 
 // Store keeps airlines and flights in memory for the mock API.
 type Store struct {
 	mu       sync.RWMutex
+	clock    Clock
 	airlines map[string]Airline
 	flights  map[string]map[string]*Flight // airlineID -> flightID -> Flight
+	history  map[string][]StatusChange     // flightKey -> status changes, oldest first
+	attester Attester
+	watchers map[chan Flight]struct{}
+}
+
+// Attester signs flight attestations on behalf of airlines; it returns "" for
+// airlines it holds no key for.
+type Attester interface {
+	Sign(Flight) (string, error)
+}
+
+// SetAttester makes the store sign every unsigned flight change it records.
+func (s *Store) SetAttester(attester Attester) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attester = attester
 }
 
 // NewStore creates an in-memory store seeded with the provided airlines and flights.
 func NewStore(initialAirlines []Airline, initialFlights []Flight) *Store {
+	return NewStoreWithClock(SystemClock(), initialAirlines, initialFlights)
+}
+
+// NewStoreWithClock creates a seeded store that stamps updates using clock.
+func NewStoreWithClock(clock Clock, initialAirlines []Airline, initialFlights []Flight) *Store {
 	s := &Store{
+		clock:    clock,
 		airlines: make(map[string]Airline),
 		flights:  make(map[string]map[string]*Flight),
+		history:  make(map[string][]StatusChange),
 	}
 	for _, airline := range initialAirlines {
 		_ = s.AddAirline(airline)
@@ -34,6 +60,11 @@ func NewStore(initialAirlines []Airline, initialFlights []Flight) *Store {
 	return s
 }
 
+// Clock returns the clock used to stamp flight updates.
+func (s *Store) Clock() Clock {
+	return s.clock
+}
+
 // ListAirlines returns airlines sorted alphabetically by code then name.
 func (s *Store) ListAirlines() []Airline {
 	s.mu.RLock()
@@ -56,6 +87,9 @@ func (s *Store) AddAirline(airline Airline) error {
 	if strings.TrimSpace(airline.AirlineID) == "" {
 		return ErrInvalidAirline
 	}
+	if airline.Signer != "" && !common.IsHexAddress(airline.Signer) {
+		return fmt.Errorf("%w: signer must be an address", ErrInvalidAirline)
+	}
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	if _, ok := s.airlines[airline.AirlineID]; ok {
@@ -111,13 +145,67 @@ func (s *Store) CreateFlight(airlineID string, flight Flight) (Flight, error) {
 	return *s.flights[airlineID][flight.FlightID], nil
 }
 
-func (s *Store) createFlightLocked(airlineID string, flight Flight) error {
+// ImportFlights creates all flights or none of them. Every flight goes through the
+// same validation as CreateFlight; when any of them fails an *ImportError listing
+// the offending rows is returned and the store is left untouched.
+func (s *Store) ImportFlights(items []Flight) ([]Flight, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.checkImportLocked(items); err != nil {
+		return nil, err
+	}
+	created := make([]Flight, 0, len(items))
+	for _, flight := range items {
+		flight = normalizeFlight(flight.AirlineID, flight)
+		if err := s.createFlightLocked(flight.AirlineID, flight); err != nil {
+			// Unreachable after validation under the same lock.
+			return nil, err
+		}
+		created = append(created, *s.flights[flight.AirlineID][flight.FlightID])
+	}
+	return created, nil
+}
+
+// CheckImport reports the rows ImportFlights would reject without storing anything.
+func (s *Store) CheckImport(items []Flight) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.checkImportLocked(items)
+}
+
+func (s *Store) checkImportLocked(items []Flight) error {
+	var rowErrors []ImportRowError
+	seen := make(map[string]int, len(items))
+	for i, flight := range items {
+		flight = normalizeFlight(flight.AirlineID, flight)
+		err := s.validateFlightLocked(flight.AirlineID, flight)
+		key := flight.AirlineID + "|" + flight.FlightID
+		if first, dup := seen[key]; err == nil && dup {
+			err = fmt.Errorf("%w: duplicates row %d", ErrFlightExists, first+1)
+		}
+		if err != nil {
+			rowErrors = append(rowErrors, ImportRowError{Row: i + 1, AirlineID: flight.AirlineID, FlightID: flight.FlightID, Err: err})
+			continue
+		}
+		seen[key] = i
+	}
+	if len(rowErrors) > 0 {
+		return &ImportError{Rows: rowErrors}
+	}
+	return nil
+}
+
+func normalizeFlight(airlineID string, flight Flight) Flight {
 	if flight.AirlineID == "" {
 		flight.AirlineID = airlineID
 	}
 	if flight.Status == "" {
 		flight.Status = StatusScheduled
 	}
+	return flight
+}
+
+func (s *Store) validateFlightLocked(airlineID string, flight Flight) error {
 	if strings.TrimSpace(flight.FlightID) == "" {
 		return ErrInvalidFlight
 	}
@@ -127,20 +215,120 @@ func (s *Store) createFlightLocked(airlineID string, flight Flight) error {
 	if _, ok := s.airlines[airlineID]; !ok {
 		return ErrAirlineNotFound
 	}
+	if _, exists := s.flights[airlineID][flight.FlightID]; exists {
+		return ErrFlightExists
+	}
+	if flight.Signature != "" {
+		return s.verifyAttestationLocked(flight)
+	}
+	return nil
+}
+
+// attestLocked verifies a caller-provided signature or, for unsigned changes,
+// signs the flight with the store's attester.
+func (s *Store) attestLocked(flight *Flight) error {
+	if flight.Signature != "" {
+		return s.verifyAttestationLocked(*flight)
+	}
+	if s.attester == nil {
+		return nil
+	}
+	signature, err := s.attester.Sign(*flight)
+	if err != nil {
+		return fmt.Errorf("attest flight %s: %w", flight.FlightID, err)
+	}
+	flight.Signature = signature
+	return nil
+}
+
+func (s *Store) verifyAttestationLocked(flight Flight) error {
+	airline := s.airlines[flight.AirlineID]
+	if airline.Signer == "" || !common.IsHexAddress(airline.Signer) {
+		return fmt.Errorf("%w: airline %s has no registered signer", ErrInvalidAttestation, flight.AirlineID)
+	}
+	if flight.UpdatedAt <= 0 {
+		return fmt.Errorf("%w: signed flights need updatedAt", ErrInvalidAttestation)
+	}
+	return VerifyAttestation(flight, common.HexToAddress(airline.Signer))
+}
+
+func (s *Store) createFlightLocked(airlineID string, flight Flight) error {
+	flight = normalizeFlight(airlineID, flight)
+	if err := s.validateFlightLocked(airlineID, flight); err != nil {
+		return err
+	}
 	if s.flights[airlineID] == nil {
 		s.flights[airlineID] = make(map[string]*Flight)
 	}
-	if _, exists := s.flights[airlineID][flight.FlightID]; exists {
-		return ErrFlightExists
+	if flight.Signature == "" {
+		flight.UpdatedAt = s.clock.Now().Unix()
+	}
+	flight.Version = 1
+	if err := s.attestLocked(&flight); err != nil {
+		return err
 	}
-	flight.UpdatedAt = time.Now().Unix()
 	copy := flight
 	s.flights[airlineID][flight.FlightID] = &copy
+	s.recordLocked(copy)
+	s.notifyLocked(copy)
 	return nil
 }
 
+func flightKey(airlineID, flightID string) string {
+	return airlineID + "|" + flightID
+}
+
+// recordLocked appends the flight's current status to its history. History slices
+// are append-only, so snapshots may share them without copying.
+func (s *Store) recordLocked(flight Flight) {
+	key := flightKey(flight.AirlineID, flight.FlightID)
+	s.history[key] = append(s.history[key], StatusChange{Status: flight.Status, At: flight.UpdatedAt})
+}
+
+// FlightHistory returns the statuses a flight went through, oldest first.
+func (s *Store) FlightHistory(airlineID, flightID string) ([]StatusChange, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if _, ok := s.flights[airlineID][flightID]; !ok {
+		if _, ok := s.airlines[airlineID]; !ok {
+			return nil, ErrAirlineNotFound
+		}
+		return nil, ErrFlightNotFound
+	}
+	history := s.history[flightKey(airlineID, flightID)]
+	return append([]StatusChange(nil), history...), nil
+}
+
 // UpdateStatus updates the status for a flight with basic validation.
 func (s *Store) UpdateStatus(airlineID, flightID string, status Status) (Flight, error) {
+	return s.ApplyStatusUpdate(airlineID, flightID, StatusUpdate{Status: status})
+}
+
+// UpdateStatusAttested applies a status change signed by the airline. The
+// signature covers updatedAt, which becomes the flight's UpdatedAt and may not
+// go back in time.
+func (s *Store) UpdateStatusAttested(airlineID, flightID string, status Status, updatedAt int64, signature string) (Flight, error) {
+	if signature == "" {
+		return Flight{}, ErrMissingAttestation
+	}
+	return s.ApplyStatusUpdate(airlineID, flightID, StatusUpdate{Status: status, UpdatedAt: updatedAt, Signature: signature})
+}
+
+// StatusUpdate describes a status change. UpdatedAt is only used together with
+// an airline Signature; IfVersion, when non-zero, makes the update conditional
+// on the flight's current Version.
+type StatusUpdate struct {
+	Status    Status
+	UpdatedAt int64
+	Signature string
+	IfVersion int64
+	// TraceParent replaces the flight's TraceParent.
+	TraceParent string
+}
+
+// ApplyStatusUpdate changes a flight's status and bumps its version.
+func (s *Store) ApplyStatusUpdate(airlineID, flightID string, update StatusUpdate) (Flight, error) {
+	status, updatedAt, signature := update.Status, update.UpdatedAt, update.Signature
 	if !validStatus(status) {
 		return Flight{}, ErrInvalidStatus
 	}
@@ -154,11 +342,33 @@ func (s *Store) UpdateStatus(airlineID, flightID string, status Status) (Flight,
 	if !ok {
 		return Flight{}, ErrFlightNotFound
 	}
+	if update.IfVersion != 0 && update.IfVersion != flight.Version {
+		return Flight{}, fmt.Errorf("%w: flight is at version %d", ErrVersionMismatch, flight.Version)
+	}
 	if !isValidTransition(flight.Status, status) {
 		return Flight{}, ErrInvalidStatusTransition
 	}
-	flight.Status = status
-	flight.UpdatedAt = time.Now().Unix()
+	next := *flight
+	next.Version++
+	next.Status = status
+	next.UpdatedAt = s.clock.Now().Unix()
+	next.Signature = signature
+	next.TraceParent = update.TraceParent
+	if signature != "" {
+		if updatedAt < flight.UpdatedAt {
+			return Flight{}, fmt.Errorf("%w: updatedAt %d is before the last update %d", ErrInvalidAttestation, updatedAt, flight.UpdatedAt)
+		}
+		next.UpdatedAt = updatedAt
+	}
+	if err := s.attestLocked(&next); err != nil {
+		return Flight{}, err
+	}
+	changed := flight.Status != status
+	*flight = next
+	if changed {
+		s.recordLocked(*flight)
+	}
+	s.notifyLocked(*flight)
 	return *flight, nil
 }
 
@@ -1,8 +1,13 @@
 package flights
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"testing"
 	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
 )
 
 func TestUpdateStatusAllowsDelayedToDeparted(t *testing.T) {
@@ -36,3 +41,205 @@ func TestUpdateStatusRejectsInvalidTransitions(t *testing.T) {
 		t.Fatalf("expected depart->delayed transition to fail")
 	}
 }
+
+func TestStoreStampsUpdatesWithClock(t *testing.T) {
+	start := time.Unix(1_700_000_000, 0)
+	clock := NewMockClock(start, false)
+	store := NewStoreWithClock(clock,
+		[]Airline{{AirlineID: "GAMMA", Name: "Gamma Connect"}},
+		[]Flight{{AirlineID: "GAMMA", FlightID: "GAMMA-1", DepartureTimestamp: start.Add(time.Hour).Unix()}},
+	)
+
+	if _, err := clock.Advance(90 * time.Second); err != nil {
+		t.Fatalf("advance clock: %v", err)
+	}
+	updated, err := store.UpdateStatus("GAMMA", "GAMMA-1", StatusDelayed)
+	if err != nil {
+		t.Fatalf("update status: %v", err)
+	}
+	if updated.UpdatedAt != start.Unix()+90 {
+		t.Fatalf("expected updatedAt %d, got %d", start.Unix()+90, updated.UpdatedAt)
+	}
+	if _, err := clock.Set(start); err == nil {
+		t.Fatalf("expected moving the clock backwards to fail")
+	}
+}
+
+func TestMockClockSetAcceptsTheTimeJustRead(t *testing.T) {
+	start := time.Unix(1_700_000_000, 0)
+	clock := NewMockClock(start, true)
+	time.Sleep(10 * time.Millisecond)
+
+	// A client reads the clock in whole seconds and sets it back to that value.
+	read := time.Unix(clock.Now().Unix(), 0)
+	time.Sleep(10 * time.Millisecond)
+	now, err := clock.Set(read)
+	if err != nil {
+		t.Fatalf("expected setting the time just read to succeed, got %v", err)
+	}
+	if now.Before(start.Add(20*time.Millisecond)) || clock.Now().Before(now) {
+		t.Fatalf("expected the clock to stay where it was, got %s", now)
+	}
+	if _, err := clock.Set(now.Add(-time.Minute)); !errors.Is(err, ErrClockBackwards) {
+		t.Fatalf("expected a minute back to be rejected, got %v", err)
+	}
+
+	stopped := NewMockClock(start, false)
+	if _, err := stopped.Set(start); err != nil {
+		t.Fatalf("expected setting a stopped clock to its own time to succeed, got %v", err)
+	}
+	if _, err := stopped.Set(start.Add(-time.Second)); !errors.Is(err, ErrClockBackwards) {
+		t.Fatalf("expected a stopped clock to refuse any step back, got %v", err)
+	}
+}
+
+func TestImportFlightsIsAllOrNothing(t *testing.T) {
+	departure := time.Now().Add(time.Hour).Unix()
+	store := NewStore(
+		[]Airline{{AirlineID: "ALPHA", Name: "Alpha Air"}, {AirlineID: "BETA", Name: "Beta Wings"}},
+		[]Flight{{AirlineID: "ALPHA", FlightID: "ALPHA-1", DepartureTimestamp: departure}},
+	)
+
+	_, err := store.ImportFlights([]Flight{
+		{AirlineID: "ALPHA", FlightID: "ALPHA-2", DepartureTimestamp: departure},
+		{AirlineID: "ALPHA", FlightID: "ALPHA-1", DepartureTimestamp: departure},
+		{AirlineID: "GAMMA", FlightID: "GAMMA-1", DepartureTimestamp: departure},
+		{AirlineID: "BETA", FlightID: "BETA-1", DepartureTimestamp: departure},
+		{AirlineID: "BETA", FlightID: "BETA-1", DepartureTimestamp: departure},
+	})
+	var importErr *ImportError
+	if !errors.As(err, &importErr) {
+		t.Fatalf("expected import error, got %v", err)
+	}
+	if len(importErr.Rows) != 3 || importErr.Rows[0].Row != 2 || importErr.Rows[1].Row != 3 || importErr.Rows[2].Row != 5 {
+		t.Fatalf("unexpected row errors %+v", importErr.Rows)
+	}
+	if !errors.Is(importErr.Rows[1], ErrAirlineNotFound) || !errors.Is(importErr.Rows[2], ErrFlightExists) {
+		t.Fatalf("unexpected row causes %+v", importErr.Rows)
+	}
+	if _, err := store.GetFlight("ALPHA", "ALPHA-2"); !errors.Is(err, ErrFlightNotFound) {
+		t.Fatalf("expected rejected import to leave the store untouched, got %v", err)
+	}
+
+	created, err := store.ImportFlights([]Flight{
+		{AirlineID: "ALPHA", FlightID: "ALPHA-2", DepartureTimestamp: departure},
+		{AirlineID: "BETA", FlightID: "BETA-1", DepartureTimestamp: departure, Status: StatusDelayed},
+	})
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if len(created) != 2 || created[0].Status != StatusScheduled || created[1].Status != StatusDelayed {
+		t.Fatalf("unexpected imported flights %+v", created)
+	}
+}
+
+func TestSnapshotRestoreRoundTrip(t *testing.T) {
+	start := time.Unix(1_700_000_000, 0)
+	clock := NewMockClock(start, false)
+	store := NewStoreWithClock(clock,
+		[]Airline{{AirlineID: "ALPHA", Name: "Alpha Air", Code: "AA"}},
+		[]Flight{{AirlineID: "ALPHA", FlightID: "ALPHA-1", DepartureTimestamp: start.Add(time.Hour).Unix()}},
+	)
+	if _, err := clock.Advance(time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.UpdateStatus("ALPHA", "ALPHA-1", StatusDelayed); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(store.Snapshot()); err != nil {
+		t.Fatal(err)
+	}
+	snap, err := ReadSnapshot(&buf)
+	if err != nil {
+		t.Fatalf("read snapshot: %v", err)
+	}
+
+	restored := NewStoreWithClock(clock, []Airline{{AirlineID: "BETA", Name: "Beta Wings"}}, nil)
+	if err := restored.Restore(snap, RestoreMerge); err != nil {
+		t.Fatalf("merge: %v", err)
+	}
+	if len(restored.ListAirlines()) != 2 {
+		t.Fatalf("expected merge to keep BETA, got %+v", restored.ListAirlines())
+	}
+	original, _ := store.GetFlight("ALPHA", "ALPHA-1")
+	if got, _ := restored.GetFlight("ALPHA", "ALPHA-1"); got != original {
+		t.Fatalf("expected %+v, got %+v", original, got)
+	}
+	history, err := restored.FlightHistory("ALPHA", "ALPHA-1")
+	if err != nil || len(history) != 2 || history[1] != (StatusChange{Status: StatusDelayed, At: start.Unix() + 60}) {
+		t.Fatalf("unexpected history %+v (%v)", history, err)
+	}
+
+	if err := restored.Restore(snap, RestoreReplace); err != nil {
+		t.Fatalf("replace: %v", err)
+	}
+	if len(restored.ListAirlines()) != 1 {
+		t.Fatalf("expected replace to drop BETA, got %+v", restored.ListAirlines())
+	}
+
+	snap.Flights[0].Status = StatusScheduled
+	if err := restored.Restore(snap, RestoreReplace); !errors.Is(err, ErrInvalidSnapshot) {
+		t.Fatalf("expected history mismatch to be rejected, got %v", err)
+	}
+	snap.Flights[0].Status, snap.Flights[0].AirlineID = StatusDelayed, "GAMMA"
+	if err := restored.Restore(snap, RestoreMerge); !errors.Is(err, ErrInvalidSnapshot) {
+		t.Fatalf("expected unknown airline to be rejected, got %v", err)
+	}
+	if _, err := restored.GetFlight("ALPHA", "ALPHA-1"); err != nil {
+		t.Fatalf("expected rejected restore to leave the store untouched, got %v", err)
+	}
+}
+
+func TestAttestationsAreSignedAndVerified(t *testing.T) {
+	alphaKey, _ := crypto.GenerateKey()
+	betaKey, _ := crypto.GenerateKey()
+	alphaSigner := crypto.PubkeyToAddress(alphaKey.PublicKey)
+	betaSigner := crypto.PubkeyToAddress(betaKey.PublicKey)
+	start := time.Unix(1_700_000_000, 0)
+	clock := NewMockClock(start, false)
+	store := NewStoreWithClock(clock, []Airline{
+		{AirlineID: "ALPHA", Name: "Alpha Air", Signer: alphaSigner.Hex()},
+		{AirlineID: "BETA", Name: "Beta Wings", Signer: betaSigner.Hex()},
+	}, nil)
+	store.SetAttester(KeyRing{"ALPHA": alphaKey})
+
+	created, err := store.CreateFlight("ALPHA", Flight{FlightID: "ALPHA-1", DepartureTimestamp: start.Add(time.Hour).Unix()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyAttestation(created, alphaSigner); err != nil {
+		t.Fatalf("expected store-signed attestation, got %v", err)
+	}
+	forged := created
+	forged.Status = StatusDelayed
+	if err := VerifyAttestation(forged, alphaSigner); !errors.Is(err, ErrInvalidAttestation) {
+		t.Fatalf("expected altered status to fail verification, got %v", err)
+	}
+
+	// BETA signs its own changes; the store only checks them.
+	beta := Flight{AirlineID: "BETA", FlightID: "BETA-1", DepartureTimestamp: start.Add(time.Hour).Unix(), Status: StatusScheduled, UpdatedAt: start.Unix()}
+	if _, err := store.CreateFlight("BETA", beta); err != nil {
+		t.Fatalf("create unsigned BETA flight: %v", err)
+	}
+	delayed := beta
+	delayed.Status, delayed.UpdatedAt = StatusDelayed, start.Unix()+30
+	signature, err := SignAttestation(delayed, alphaKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.UpdateStatusAttested("BETA", "BETA-1", StatusDelayed, delayed.UpdatedAt, signature); !errors.Is(err, ErrInvalidAttestation) {
+		t.Fatalf("expected signature by another airline to be rejected, got %v", err)
+	}
+	if signature, err = SignAttestation(delayed, betaKey); err != nil {
+		t.Fatal(err)
+	}
+	updated, err := store.UpdateStatusAttested("BETA", "BETA-1", StatusDelayed, delayed.UpdatedAt, signature)
+	if err != nil {
+		t.Fatalf("attested update: %v", err)
+	}
+	if updated.UpdatedAt != delayed.UpdatedAt || VerifyAttestation(updated, betaSigner) != nil {
+		t.Fatalf("unexpected attested flight %+v", updated)
+	}
+}
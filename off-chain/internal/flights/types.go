@@ -1,6 +1,10 @@
 package flights
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
 
 // Status describes the lifecycle state of a flight tracked by the mock API.
 type Status string
@@ -11,20 +15,37 @@ const (
 	StatusDeparted  Status = "DEPARTED"
 )
 
-// Airline represents a carrier that can have flights scheduled on-chain.
+// Airline represents a carrier that can have flights scheduled on-chain. Signer
+// is the address whose attestations the API accepts for the airline's flights.
 type Airline struct {
 	AirlineID string `json:"airlineId"`
 	Name      string `json:"name"`
 	Code      string `json:"code"`
+	Signer    string `json:"signer,omitempty"`
 }
 
-// Flight is a single tracked flight instance owned by an airline.
+// Flight is a single tracked flight instance owned by an airline, optionally
+// operated by a specific aircraft (tail). Signature is the airline's attestation
+// of the current state, see AttestationHash.
 type Flight struct {
 	AirlineID          string `json:"airlineId"`
 	FlightID           string `json:"flightId"`
 	DepartureTimestamp int64  `json:"departureTimestamp"`
 	Status             Status `json:"status"`
 	UpdatedAt          int64  `json:"updatedAt"`
+	AircraftID         string `json:"aircraftId,omitempty"`
+	Signature          string `json:"signature,omitempty"`
+	// Version starts at 1 and grows with every change; it backs HTTP ETags.
+	Version int64 `json:"version"`
+	// TraceParent is the W3C traceparent of the span that made the latest
+	// status change, so oracle nodes can link their work to it.
+	TraceParent string `json:"traceParent,omitempty"`
+}
+
+// StatusChange records a status a flight entered and when.
+type StatusChange struct {
+	Status Status `json:"status"`
+	At     int64  `json:"at"`
 }
 
 var (
@@ -36,8 +57,38 @@ var (
 	ErrInvalidFlight           = errors.New("invalid flight id")
 	ErrInvalidStatusTransition = errors.New("invalid flight status transition")
 	ErrInvalidStatus           = errors.New("invalid flight status")
+	ErrInvalidSnapshot         = errors.New("invalid snapshot")
+	ErrVersionMismatch         = errors.New("flight version mismatch")
 )
 
+// ImportRowError describes why a single row of a bulk import was rejected. Rows
+// are numbered from 1 in input order.
+type ImportRowError struct {
+	Row       int
+	AirlineID string
+	FlightID  string
+	Err       error
+}
+
+func (e ImportRowError) Error() string {
+	return fmt.Sprintf("row %d (%s/%s): %v", e.Row, e.AirlineID, e.FlightID, e.Err)
+}
+
+func (e ImportRowError) Unwrap() error { return e.Err }
+
+// ImportError is returned when a bulk import is rejected as a whole.
+type ImportError struct {
+	Rows []ImportRowError
+}
+
+func (e *ImportError) Error() string {
+	msgs := make([]string, 0, len(e.Rows))
+	for _, row := range e.Rows {
+		msgs = append(msgs, row.Error())
+	}
+	return fmt.Sprintf("import rejected: %s", strings.Join(msgs, "; "))
+}
+
 // validStatus reports whether the provided status is recognised by the API.
 func validStatus(status Status) bool {
 	switch status {
@@ -0,0 +1,42 @@
+package flights
+
+// Watch subscribes to flight changes: every created or updated flight is sent on
+// the returned channel. A watcher that falls more than buffer changes behind,
+// and every watcher after a Restore, has its channel closed and should list the
+// flights again before watching anew. cancel releases the subscription.
+func (s *Store) Watch(buffer int) (changes <-chan Flight, cancel func()) {
+	ch := make(chan Flight, buffer)
+	s.mu.Lock()
+	if s.watchers == nil {
+		s.watchers = make(map[chan Flight]struct{})
+	}
+	s.watchers[ch] = struct{}{}
+	s.mu.Unlock()
+	return ch, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if _, ok := s.watchers[ch]; ok {
+			delete(s.watchers, ch)
+			close(ch)
+		}
+	}
+}
+
+// notifyLocked never blocks writers on a slow watcher; it drops the watcher instead.
+func (s *Store) notifyLocked(flight Flight) {
+	for ch := range s.watchers {
+		select {
+		case ch <- flight:
+		default:
+			delete(s.watchers, ch)
+			close(ch)
+		}
+	}
+}
+
+func (s *Store) closeWatchersLocked() {
+	for ch := range s.watchers {
+		delete(s.watchers, ch)
+		close(ch)
+	}
+}
@@ -0,0 +1,451 @@
+// Package settings layers the settings of the off-chain commands: a YAML or TOML
+// config file, then environment variables, then command-line flags. Every
+// setting is a flag; the file uses flag names as keys (nested tables join
+// their keys with "-") and the environment uses them upper-cased with "_",
+// behind the command's prefix.
+package settings
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+// Sources of a setting, lowest precedence first.
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceEnv     = "env"
+	SourceFlag    = "flag"
+)
+
+const redacted = "<redacted>"
+
+// Loader applies the config file and environment to a flag set after the
+// command line is parsed.
+type Loader struct {
+	fs        *pflag.FlagSet
+	envPrefix string
+	path      string
+	print     bool
+
+	required   []string
+	secrets    map[string]bool
+	secretURLs map[string]bool
+	reloadable map[string]bool
+
+	// Validate, when set, checks the settings after every Load and Reload. A
+	// failed Reload is rolled back.
+	Validate func() error
+
+	// fromArgs are the flags set on the command line, which always win.
+	fromArgs map[string]bool
+	sources  map[string]string
+}
+
+// New adds --config and --print-config to fs. Environment variables are named
+// envPrefix_FLAG_NAME, e.g. FLIGHT_NODE_POLL_INTERVAL.
+func New(fs *pflag.FlagSet, envPrefix string) *Loader {
+	l := &Loader{
+		fs:         fs,
+		envPrefix:  envPrefix,
+		secrets:    map[string]bool{},
+		secretURLs: map[string]bool{},
+		reloadable: map[string]bool{},
+	}
+	fs.StringVar(&l.path, "config", "", "YAML or TOML config file keyed by flag name; environment variables ("+envPrefix+"_<FLAG>) and flags override it")
+	fs.BoolVar(&l.print, "print-config", false, "Print the effective configuration with secrets redacted and exit")
+	return l
+}
+
+// Require makes Load fail unless every named setting is set from some source.
+func (l *Loader) Require(names ...string) { l.required = append(l.required, names...) }
+
+// Secret redacts the named settings when printed.
+func (l *Loader) Secret(names ...string) {
+	for _, name := range names {
+		l.secrets[name] = true
+	}
+}
+
+// SecretURL prints only the scheme and host of the named URL settings, whose
+// paths and credentials often hold provider API keys.
+func (l *Loader) SecretURL(names ...string) {
+	for _, name := range names {
+		l.secretURLs[name] = true
+	}
+}
+
+// Reloadable marks settings that Reload may change while the command runs.
+func (l *Loader) Reloadable(names ...string) {
+	for _, name := range names {
+		l.reloadable[name] = true
+	}
+}
+
+// PrintRequested reports whether --print-config was given.
+func (l *Loader) PrintRequested() bool { return l.print }
+
+// Source returns where the named setting came from.
+func (l *Loader) Source(name string) string {
+	if source, ok := l.sources[name]; ok {
+		return source
+	}
+	return SourceDefault
+}
+
+// Load applies the config file and the environment to every flag not set on
+// the command line, then validates the result.
+func (l *Loader) Load() error {
+	l.fromArgs = map[string]bool{}
+	l.sources = map[string]string{}
+	// Cobra parses into a merged flag set, so only the flags know they changed.
+	l.fs.VisitAll(func(f *pflag.Flag) {
+		if f.Changed {
+			l.fromArgs[f.Name] = true
+			l.sources[f.Name] = SourceFlag
+		}
+	})
+	if !l.fromArgs["config"] {
+		if path, ok := os.LookupEnv(l.envName("config")); ok {
+			l.path = path
+		}
+	}
+
+	values, err := l.layers()
+	if err != nil {
+		return err
+	}
+	for name, v := range values {
+		if err := set(l.fs.Lookup(name), v.value); err != nil {
+			return fmt.Errorf("%s %s: %w", v.source, l.describe(name, v.source), err)
+		}
+		l.sources[name] = v.source
+	}
+
+	var missing []string
+	for _, name := range l.required {
+		if _, ok := l.sources[name]; !ok {
+			missing = append(missing, fmt.Sprintf("--%s (%s or %q in the config file)", name, l.envName(name), name))
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing required settings: %s", strings.Join(missing, ", "))
+	}
+	if l.Validate != nil {
+		return l.Validate()
+	}
+	return nil
+}
+
+// Reload re-reads the config file and the environment and applies changes to
+// reloadable settings. Changes to other settings are logged and ignored until
+// a restart. It returns the names of the changed settings.
+func (l *Loader) Reload() ([]string, error) {
+	values, err := l.layers()
+	if err != nil {
+		return nil, err
+	}
+
+	type undo struct {
+		flag   *pflag.Flag
+		value  string
+		source string
+	}
+	var (
+		changed []string
+		undos   []undo
+	)
+	rollback := func() {
+		for _, u := range slices.Backward(undos) {
+			_ = set(u.flag, u.value)
+			if u.source == SourceDefault {
+				delete(l.sources, u.flag.Name)
+			} else {
+				l.sources[u.flag.Name] = u.source
+			}
+		}
+	}
+	var failed error
+	l.fs.VisitAll(func(f *pflag.Flag) {
+		if failed != nil || l.fromArgs[f.Name] || f.Name == "config" || f.Name == "print-config" {
+			return
+		}
+		next, ok := values[f.Name]
+		if !ok {
+			next = layered{value: f.DefValue, source: SourceDefault}
+			if _, isSlice := f.Value.(pflag.SliceValue); isSlice {
+				next.value = strings.Trim(f.DefValue, "[]")
+			}
+		}
+		current := valueString(f)
+		if next.value == current {
+			return
+		}
+		if !l.reloadable[f.Name] {
+			slog.Warn("Setting changed; restart to apply", "setting", f.Name, "source", next.source)
+			return
+		}
+		undos = append(undos, undo{flag: f, value: current, source: l.Source(f.Name)})
+		if err := set(f, next.value); err != nil {
+			failed = fmt.Errorf("%s %s: %w", next.source, l.describe(f.Name, next.source), err)
+			return
+		}
+		if next.source == SourceDefault {
+			delete(l.sources, f.Name)
+		} else {
+			l.sources[f.Name] = next.source
+		}
+		changed = append(changed, f.Name)
+	})
+	if failed == nil && l.Validate != nil {
+		failed = l.Validate()
+	}
+	if failed != nil {
+		rollback()
+		return nil, failed
+	}
+	return changed, nil
+}
+
+// Print writes the effective settings as a config file, annotated with their
+// sources and with secrets redacted.
+func (l *Loader) Print(w io.Writer) error {
+	var lines []string
+	l.fs.VisitAll(func(f *pflag.Flag) {
+		if f.Name == "config" || f.Name == "print-config" {
+			return
+		}
+		lines = append(lines, fmt.Sprintf("%s: %s # %s", f.Name, l.printValue(f), l.Source(f.Name)))
+	})
+	if l.path != "" {
+		lines = append([]string{"# config file: " + l.path}, lines...)
+	}
+	_, err := io.WriteString(w, strings.Join(lines, "\n")+"\n")
+	return err
+}
+
+func (l *Loader) printValue(f *pflag.Flag) string {
+	var items []string
+	slice, isSlice := f.Value.(pflag.SliceValue)
+	if isSlice {
+		items = slice.GetSlice()
+	} else {
+		items = []string{f.Value.String()}
+	}
+	for i, item := range items {
+		switch {
+		case item == "":
+		case l.secrets[f.Name]:
+			item = redacted
+		case l.secretURLs[f.Name]:
+			item = redactURL(item)
+		}
+		if f.Value.Type() == "string" || isSlice {
+			item = quote(item)
+		}
+		items[i] = item
+	}
+	if isSlice {
+		return "[" + strings.Join(items, ", ") + "]"
+	}
+	return items[0]
+}
+
+// redactURL keeps the scheme and host of rawURL.
+func redactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return redacted
+	}
+	if u.User == nil && (u.Path == "" || u.Path == "/") && u.RawQuery == "" {
+		return rawURL
+	}
+	return u.Scheme + "://" + u.Host + "/" + redacted
+}
+
+// quote renders s as a YAML scalar, quoted only when needed.
+func quote(s string) string {
+	out, err := yaml.Marshal(s)
+	if err != nil {
+		return strconv.Quote(s)
+	}
+	return strings.TrimSuffix(string(out), "\n")
+}
+
+// layered is a setting value read from the file or the environment.
+type layered struct {
+	value  string
+	source string
+}
+
+// layers reads the config file and the environment for every flag not set on
+// the command line, the environment winning.
+func (l *Loader) layers() (map[string]layered, error) {
+	file, err := l.readFile()
+	if err != nil {
+		return nil, err
+	}
+	values := map[string]layered{}
+	for name, value := range file {
+		f := l.fs.Lookup(name)
+		if f == nil || name == "config" || name == "print-config" {
+			return nil, fmt.Errorf("config file %s: unknown setting %q", l.path, name)
+		}
+		if !l.fromArgs[name] {
+			values[name] = layered{value: value, source: SourceFile}
+		}
+	}
+	l.fs.VisitAll(func(f *pflag.Flag) {
+		if l.fromArgs[f.Name] || f.Name == "config" || f.Name == "print-config" {
+			return
+		}
+		if value, ok := os.LookupEnv(l.envName(f.Name)); ok {
+			values[f.Name] = layered{value: value, source: SourceEnv}
+		}
+	})
+	return values, nil
+}
+
+func (l *Loader) describe(name, source string) string {
+	if source == SourceEnv {
+		return l.envName(name)
+	}
+	return fmt.Sprintf("%s %q", l.path, name)
+}
+
+func (l *Loader) envName(name string) string {
+	return l.envPrefix + "_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// readFile returns the settings of the config file as flag values.
+func (l *Loader) readFile() (map[string]string, error) {
+	if l.path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(l.path)
+	if err != nil {
+		return nil, fmt.Errorf("read config file: %w", err)
+	}
+	raw := map[string]any{}
+	switch strings.ToLower(filepath.Ext(l.path)) {
+	case ".toml":
+		err = toml.Unmarshal(data, &raw)
+	case ".yaml", ".yml", ".json":
+		err = yaml.Unmarshal(data, &raw)
+	default:
+		return nil, fmt.Errorf("config file %s: want a .yaml, .yml, .json or .toml extension", l.path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse config file %s: %w", l.path, err)
+	}
+	settings := map[string]string{}
+	if err := flatten("", raw, settings); err != nil {
+		return nil, fmt.Errorf("config file %s: %w", l.path, err)
+	}
+	return settings, nil
+}
+
+// flatten turns nested tables into dash-joined keys and values into the text
+// their flags parse; lists become comma-separated.
+func flatten(prefix string, raw map[string]any, out map[string]string) error {
+	for key, value := range raw {
+		name := key
+		if prefix != "" {
+			name = prefix + "-" + key
+		}
+		switch v := value.(type) {
+		case map[string]any:
+			if err := flatten(name, v, out); err != nil {
+				return err
+			}
+		case []any:
+			items := make([]string, len(v))
+			for i, item := range v {
+				s, err := scalar(item)
+				if err != nil {
+					return fmt.Errorf("%q: %w", name, err)
+				}
+				items[i] = s
+			}
+			out[name] = strings.Join(items, ",")
+		default:
+			s, err := scalar(v)
+			if err != nil {
+				return fmt.Errorf("%q: %w", name, err)
+			}
+			out[name] = s
+		}
+	}
+	return nil
+}
+
+func scalar(value any) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case uint64:
+		return strconv.FormatUint(v, 10), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case nil:
+		return "", nil
+	default:
+		return "", fmt.Errorf("unsupported value %v (%T)", v, v)
+	}
+}
+
+// set replaces the flag's value and marks it changed, like a value given on
+// the command line; list flags take comma-separated items.
+func set(f *pflag.Flag, value string) error {
+	f.Changed = true
+	if slice, ok := f.Value.(pflag.SliceValue); ok {
+		var items []string
+		if value != "" {
+			items = strings.Split(value, ",")
+		}
+		return slice.Replace(items)
+	}
+	return f.Value.Set(value)
+}
+
+// valueString renders the flag's value like set takes it.
+func valueString(f *pflag.Flag) string {
+	if slice, ok := f.Value.(pflag.SliceValue); ok {
+		return strings.Join(slice.GetSlice(), ",")
+	}
+	return f.Value.String()
+}
+
+// ParseLogLevel parses one of debug, info, warn or error.
+func ParseLogLevel(level string) (slog.Level, error) {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info":
+		return slog.LevelInfo, nil
+	case "warn":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return 0, errors.New("log level must be debug, info, warn or error")
+	}
+}
@@ -0,0 +1,152 @@
+// Package tracing sets up OpenTelemetry tracing for the off-chain services and
+// carries flight-scoped trace context between them.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/pflag"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// Exporters accepted by Options.Exporter.
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterFile   = "file"
+	ExporterOTLP   = "otlp"
+)
+
+// Options selects where spans go.
+type Options struct {
+	// Exporter is one of none, stdout, file or otlp.
+	Exporter string
+	// File receives OTLP/JSON, one export request per line, with the file
+	// exporter; the OpenTelemetry Collector's otlpjsonfile receiver reads it.
+	File string
+	// Endpoint is the OTLP/HTTP traces URL, e.g. http://localhost:4318/v1/traces.
+	Endpoint string
+	// SampleRatio is the fraction of new traces recorded; child spans follow
+	// their parent.
+	SampleRatio float64
+}
+
+// RegisterFlags adds the --trace-* flags for o with o's current values as defaults.
+func (o *Options) RegisterFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.Exporter, "trace-exporter", ExporterNone, "Where to export OpenTelemetry spans: none, stdout, file or otlp")
+	fs.StringVar(&o.File, "trace-file", "traces.jsonl", "File the file trace exporter appends OTLP/JSON lines to")
+	fs.StringVar(&o.Endpoint, "trace-otlp-endpoint", "http://localhost:4318/v1/traces", "OTLP/HTTP traces URL of the otlp trace exporter")
+	fs.Float64Var(&o.SampleRatio, "trace-sample-ratio", 1, "Fraction of new traces to record (0-1)")
+}
+
+// Setup installs a global tracer provider for service and the W3C trace
+// context propagator. The returned function flushes and stops the exporter.
+// With the none exporter only the propagator is installed, so trace context
+// still flows through the service.
+func Setup(ctx context.Context, service string, opts Options) (shutdown func(context.Context) error, err error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	if opts.SampleRatio < 0 || opts.SampleRatio > 1 {
+		return nil, fmt.Errorf("trace sample ratio %v is not between 0 and 1", opts.SampleRatio)
+	}
+	var exporter sdktrace.SpanExporter
+	switch strings.ToLower(opts.Exporter) {
+	case "", ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case ExporterFile:
+		var f *os.File
+		if f, err = os.OpenFile(opts.File, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644); err != nil {
+			return nil, fmt.Errorf("open trace file: %w", err)
+		}
+		exporter, err = otlptrace.New(ctx, &jsonLinesClient{w: f})
+	case ExporterOTLP:
+		exporter, err = otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(opts.Endpoint), otlptracehttp.WithTimeout(10*time.Second))
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", opts.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("create %s trace exporter: %w", opts.Exporter, err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(service))),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// TraceParent returns the W3C traceparent of the span in ctx, or "" when ctx
+// carries no sampled span.
+func TraceParent(ctx context.Context) string {
+	if !trace.SpanContextFromContext(ctx).IsSampled() {
+		return ""
+	}
+	carrier := propagation.MapCarrier{}
+	propagation.TraceContext{}.Inject(ctx, carrier)
+	return carrier["traceparent"]
+}
+
+// Link returns a link to the span traceParent names. It reports false when
+// traceParent is empty or malformed.
+func Link(traceParent string) (trace.Link, bool) {
+	if traceParent == "" {
+		return trace.Link{}, false
+	}
+	ctx := propagation.TraceContext{}.Extract(context.Background(), propagation.MapCarrier{"traceparent": traceParent})
+	spanCtx := trace.SpanContextFromContext(ctx)
+	return trace.Link{SpanContext: spanCtx}, spanCtx.IsValid()
+}
+
+// jsonLinesClient backs the file exporter, which has no upstream equivalent. It
+// writes every export as one OTLP/JSON ExportTraceServiceRequest line.
+type jsonLinesClient struct {
+	mu sync.Mutex
+	w  io.WriteCloser
+}
+
+func (c *jsonLinesClient) Start(context.Context) error { return nil }
+
+func (c *jsonLinesClient) Stop(context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.w.Close()
+}
+
+func (c *jsonLinesClient) UploadTraces(_ context.Context, spans []*tracepb.ResourceSpans) error {
+	line := []byte(`{"resourceSpans":[`)
+	for i, rs := range spans {
+		encoded, err := protojson.Marshal(rs)
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			line = append(line, ',')
+		}
+		line = append(line, encoded...)
+	}
+	line = append(line, "]}\n"...)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, err := c.w.Write(line)
+	return err
+}
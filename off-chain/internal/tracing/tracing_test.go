@@ -0,0 +1,122 @@
+package tracing
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace/noop"
+	collectorpb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestFileExporterWritesOTLPJSONLines(t *testing.T) {
+	t.Cleanup(func() {
+		otel.SetTracerProvider(noop.NewTracerProvider())
+		otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+	})
+	path := filepath.Join(t.TempDir(), "traces.jsonl")
+	ctx := context.Background()
+	shutdown, err := Setup(ctx, "flights-api", Options{Exporter: ExporterFile, File: path, SampleRatio: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spanCtx, span := otel.Tracer("test").Start(ctx, "flight.status_change")
+	traceParent := TraceParent(spanCtx)
+	span.End()
+	if err := shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	link, ok := Link(traceParent)
+	if !ok || link.SpanContext.SpanID() != span.SpanContext().SpanID() {
+		t.Fatalf("expected %q to link back to the span", traceParent)
+	}
+	if _, ok := Link("00-garbage"); ok {
+		t.Fatal("expected a malformed traceparent not to link")
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	if !scanner.Scan() {
+		t.Fatal("expected a line in the trace file")
+	}
+	// Every line is an ExportTraceServiceRequest, whose only field is resourceSpans.
+	var request struct {
+		ResourceSpans []json.RawMessage `json:"resourceSpans"`
+	}
+	if err := json.Unmarshal(scanner.Bytes(), &request); err != nil || len(request.ResourceSpans) != 1 {
+		t.Fatalf("expected one resourceSpans entry, got %s (%v)", scanner.Text(), err)
+	}
+	var rs tracepb.ResourceSpans
+	if err := protojson.Unmarshal(request.ResourceSpans[0], &rs); err != nil {
+		t.Fatal(err)
+	}
+	service := rs.GetResource().GetAttributes()[0]
+	exported := rs.GetScopeSpans()[0].GetSpans()[0]
+	if service.GetKey() != "service.name" || service.GetValue().GetStringValue() != "flights-api" || exported.GetName() != "flight.status_change" {
+		t.Fatalf("unexpected export %s", scanner.Text())
+	}
+}
+
+func TestSetupRejectsUnknownExporter(t *testing.T) {
+	t.Cleanup(func() { otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator()) })
+	if _, err := Setup(context.Background(), "flight-node", Options{Exporter: "jaeger", SampleRatio: 1}); err == nil {
+		t.Fatal("expected an unknown exporter to be rejected")
+	}
+	if _, err := Setup(context.Background(), "flight-node", Options{Exporter: ExporterStdout, SampleRatio: 2}); err == nil {
+		t.Fatal("expected a sample ratio above 1 to be rejected")
+	}
+}
+
+func TestOTLPExporterPostsToTheEndpoint(t *testing.T) {
+	t.Cleanup(func() {
+		otel.SetTracerProvider(noop.NewTracerProvider())
+		otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+	})
+	requests := make(chan *collectorpb.ExportTraceServiceRequest, 1)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var request collectorpb.ExportTraceServiceRequest
+		if r.URL.Path != "/v1/traces" || r.Header.Get("Content-Type") != "application/x-protobuf" || proto.Unmarshal(body, &request) != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		requests <- &request
+	}))
+	defer collector.Close()
+	ctx := context.Background()
+	shutdown, err := Setup(ctx, "flight-node", Options{Exporter: ExporterOTLP, Endpoint: collector.URL + "/v1/traces", SampleRatio: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, span := otel.Tracer("test").Start(ctx, "flight.signature_request")
+	span.End()
+	if err := shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case request := <-requests:
+		if got := request.GetResourceSpans()[0].GetScopeSpans()[0].GetSpans()[0].GetName(); got != "flight.signature_request" {
+			t.Fatalf("expected the span to be exported, got %q", got)
+		}
+	default:
+		t.Fatal("expected the collector to receive an export")
+	}
+}
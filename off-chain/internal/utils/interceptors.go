@@ -0,0 +1,166 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/retry"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const tracerName = "sum/internal/utils"
+
+// GRPCClientMetrics are the Prometheus collectors recorded by the client
+// interceptors. Every attempt of a retried call is observed on its own.
+type GRPCClientMetrics struct {
+	duration *prometheus.HistogramVec
+	retries  *prometheus.CounterVec
+}
+
+// NewGRPCClientMetrics registers the client metrics with reg, named with
+// prefix (e.g. "flight_node").
+func NewGRPCClientMetrics(reg prometheus.Registerer, prefix string) *GRPCClientMetrics {
+	m := &GRPCClientMetrics{
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    prefix + "_grpc_client_call_duration_seconds",
+			Help:    "Duration of gRPC client call attempts; for streams, until the stream ends.",
+			Buckets: []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+		}, []string{"target", "method", "code"}),
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: prefix + "_grpc_client_retries_total",
+			Help: "gRPC client call attempts after the first.",
+		}, []string{"target", "method"}),
+	}
+	reg.MustRegister(m.duration, m.retries)
+	return m
+}
+
+// ObserveUnaryClient traces every unary call attempt in a client span, logs it
+// at debug level, records it in m when m is not nil and propagates the trace
+// context in the outgoing metadata.
+func ObserveUnaryClient(m *GRPCClientMetrics) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx, attempt := startAttempt(ctx, m, cc.Target(), method)
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		m.observe(ctx, cc.Target(), method, attempt, start, err)
+		return err
+	}
+}
+
+// ObserveStreamClient is ObserveUnaryClient for streams, which are observed
+// when they end.
+func ObserveStreamClient(m *GRPCClientMetrics) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		ctx, attempt := startAttempt(ctx, m, cc.Target(), method)
+		start := time.Now()
+		stream, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			m.observe(ctx, cc.Target(), method, attempt, start, err)
+			return nil, err
+		}
+		return &observedStream{ClientStream: stream, done: func(err error) {
+			m.observe(ctx, cc.Target(), method, attempt, start, err)
+		}}, nil
+	}
+}
+
+// startAttempt starts the client span of an attempt, propagates its trace
+// context and returns the retry attempt set by the retry interceptor, 0 for the
+// first one.
+func startAttempt(ctx context.Context, m *GRPCClientMetrics, target, method string) (context.Context, int) {
+	md, _ := metadata.FromOutgoingContext(ctx)
+	md = md.Copy()
+	attempt := 0
+	if values := md.Get(grpc_retry.AttemptMetadataKey); len(values) > 0 {
+		attempt, _ = strconv.Atoi(values[0])
+	}
+	if attempt > 0 && m != nil {
+		m.retries.WithLabelValues(target, method).Inc()
+	}
+	service, name, _ := strings.Cut(strings.TrimPrefix(method, "/"), "/")
+	ctx, _ = otel.Tracer(tracerName).Start(ctx, strings.TrimPrefix(method, "/"),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.RPCSystemGRPC,
+			semconv.RPCService(service),
+			semconv.RPCMethod(name),
+			semconv.ServerAddress(target),
+			attribute.Int("rpc.grpc.retry_attempt", attempt),
+		))
+	otel.GetTextMapPropagator().Inject(ctx, metadataCarrier(md))
+	return metadata.NewOutgoingContext(ctx, md), attempt
+}
+
+// observe ends the attempt started by startAttempt.
+func (m *GRPCClientMetrics) observe(ctx context.Context, target, method string, attempt int, start time.Time, err error) {
+	elapsed := time.Since(start)
+	code := status.Code(err)
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(semconv.RPCGRPCStatusCodeKey.Int(int(code)))
+	if err != nil {
+		span.SetStatus(otelcodes.Error, err.Error())
+	}
+	span.End()
+	if m != nil {
+		m.duration.WithLabelValues(target, method, code.String()).Observe(elapsed.Seconds())
+	}
+	attrs := []any{"target", target, "method", method, "code", code.String(), "attempt", attempt, "elapsed", elapsed}
+	if err != nil {
+		attrs = append(attrs, "error", err)
+	}
+	slog.DebugContext(ctx, "gRPC call", attrs...)
+}
+
+// observedStream reports the end of a client stream once.
+type observedStream struct {
+	grpc.ClientStream
+	once sync.Once
+	done func(error)
+}
+
+func (s *observedStream) RecvMsg(msg any) error {
+	err := s.ClientStream.RecvMsg(msg)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			s.once.Do(func() { s.done(nil) })
+		} else {
+			s.once.Do(func() { s.done(err) })
+		}
+	}
+	return err
+}
+
+// metadataCarrier adapts gRPC metadata to a propagation.TextMapCarrier.
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	if values := metadata.MD(c).Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func (c metadataCarrier) Set(key, value string) { metadata.MD(c).Set(key, value) }
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
@@ -1,27 +1,192 @@
 package utils
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"strings"
 	"time"
 
 	grpcmiddleware "github.com/grpc-ecosystem/go-grpc-middleware"
 	"github.com/grpc-ecosystem/go-grpc-middleware/retry"
+	"github.com/spf13/pflag"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
 )
 
-func GetGRPCConnection(address string) (*grpc.ClientConn, error) {
+// GRPCOptions configures GetGRPCConnection.
+type GRPCOptions struct {
+	// TLS enables transport security. CAFile replaces the system roots; CertFile
+	// and KeyFile present a client certificate (mTLS); ServerName overrides the
+	// name checked against the server certificate.
+	TLS        bool
+	CAFile     string
+	CertFile   string
+	KeyFile    string
+	ServerName string
+
+	// KeepaliveTime pings an idle connection after this long; zero disables
+	// pings. KeepaliveTimeout is how long to wait for the ping ack.
+	KeepaliveTime    time.Duration
+	KeepaliveTimeout time.Duration
+
+	// Retries is the number of attempts per call, including the first. Waits
+	// grow exponentially from RetryBackoff up to RetryMaxBackoff, each spread by
+	// ±RetryJitter (a fraction).
+	Retries         uint
+	RetryBackoff    time.Duration
+	RetryMaxBackoff time.Duration
+	RetryJitter     float64
+	// RetryCodes are the status codes retried, by name, e.g. "unavailable".
+	RetryCodes []string
+
+	// CallTimeout is the deadline of unary calls made without one, covering
+	// every retry; zero leaves calls unbounded. Streams are not affected.
+	CallTimeout time.Duration
+	// MaxMessageSize caps sent and received messages in bytes.
+	MaxMessageSize int
+
+	// Metrics, when set, records every call attempt. Calls are logged at debug
+	// level and carry the trace context either way.
+	Metrics *GRPCClientMetrics
+}
+
+// DefaultGRPCOptions returns plaintext options that retry unavailable and
+// exhausted calls three times.
+func DefaultGRPCOptions() GRPCOptions {
+	return GRPCOptions{
+		KeepaliveTimeout: 20 * time.Second,
+		Retries:          3,
+		RetryBackoff:     time.Second,
+		RetryMaxBackoff:  10 * time.Second,
+		RetryJitter:      0.2,
+		RetryCodes:       []string{"unavailable", "resource_exhausted"},
+		MaxMessageSize:   100 * 1024 * 1024,
+	}
+}
+
+// RegisterFlags adds flags for o, named after prefix (e.g. "relay-"), with
+// o's current values as defaults.
+func (o *GRPCOptions) RegisterFlags(fs *pflag.FlagSet, prefix, service string) {
+	fs.BoolVar(&o.TLS, prefix+"tls", o.TLS, "Connect to the "+service+" over TLS")
+	fs.StringVar(&o.CAFile, prefix+"tls-ca", o.CAFile, "PEM CA bundle to verify the "+service+" with instead of the system roots (implies --"+prefix+"tls)")
+	fs.StringVar(&o.CertFile, prefix+"tls-cert", o.CertFile, "PEM client certificate for mTLS with the "+service+" (implies --"+prefix+"tls)")
+	fs.StringVar(&o.KeyFile, prefix+"tls-key", o.KeyFile, "PEM private key of --"+prefix+"tls-cert")
+	fs.StringVar(&o.ServerName, prefix+"tls-server-name", o.ServerName, "Server name to verify the "+service+" certificate against")
+	fs.DurationVar(&o.KeepaliveTime, prefix+"keepalive-time", o.KeepaliveTime, "Ping the "+service+" after this much inactivity (0 disables)")
+	fs.DurationVar(&o.KeepaliveTimeout, prefix+"keepalive-timeout", o.KeepaliveTimeout, "Close the "+service+" connection when a keepalive ping is not acknowledged within this time")
+	fs.UintVar(&o.Retries, prefix+"retries", o.Retries, "Attempts per "+service+" call, including the first")
+	fs.DurationVar(&o.RetryBackoff, prefix+"retry-backoff", o.RetryBackoff, "Initial wait between "+service+" call attempts; doubles per attempt")
+	fs.DurationVar(&o.RetryMaxBackoff, prefix+"retry-max-backoff", o.RetryMaxBackoff, "Maximum wait between "+service+" call attempts")
+	fs.Float64Var(&o.RetryJitter, prefix+"retry-jitter", o.RetryJitter, "Random spread of "+service+" retry waits as a fraction (0-1)")
+	fs.StringSliceVar(&o.RetryCodes, prefix+"retry-codes", o.RetryCodes, "gRPC status codes of "+service+" calls to retry, e.g. unavailable,deadline_exceeded")
+	fs.DurationVar(&o.CallTimeout, prefix+"call-timeout", o.CallTimeout, "Deadline of "+service+" calls, including retries (0 is none)")
+	fs.IntVar(&o.MaxMessageSize, prefix+"max-message-size", o.MaxMessageSize, "Maximum "+service+" message size in bytes")
+}
+
+// GetGRPCConnection returns a client connection to address configured by opts.
+func GetGRPCConnection(address string, opts GRPCOptions) (*grpc.ClientConn, error) {
+	retryCodes, err := parseCodes(opts.RetryCodes)
+	if err != nil {
+		return nil, err
+	}
 	retryOpts := []grpc_retry.CallOption{
-		grpc_retry.WithMax(3),
-		grpc_retry.WithBackoff(grpc_retry.BackoffLinear(time.Second)),
+		grpc_retry.WithMax(opts.Retries),
+		grpc_retry.WithBackoff(exponentialBackoff(opts.RetryBackoff, opts.RetryMaxBackoff, opts.RetryJitter)),
+		grpc_retry.WithCodes(retryCodes...),
 	}
-	unaryInterceptors := []grpc.UnaryClientInterceptor{grpc_retry.UnaryClientInterceptor(retryOpts...)}
-	opts := []grpc.DialOption{
-		grpc.WithStreamInterceptor(grpc_retry.StreamClientInterceptor(retryOpts...)),
+	unaryInterceptors := []grpc.UnaryClientInterceptor{grpc_retry.UnaryClientInterceptor(retryOpts...), ObserveUnaryClient(opts.Metrics)}
+	if opts.CallTimeout > 0 {
+		unaryInterceptors = append([]grpc.UnaryClientInterceptor{callTimeout(opts.CallTimeout)}, unaryInterceptors...)
+	}
+	creds, err := transportCredentials(opts)
+	if err != nil {
+		return nil, err
+	}
+	dialOpts := []grpc.DialOption{
+		grpc.WithStreamInterceptor(grpcmiddleware.ChainStreamClient(grpc_retry.StreamClientInterceptor(retryOpts...), ObserveStreamClient(opts.Metrics))),
 		grpc.WithUnaryInterceptor(grpcmiddleware.ChainUnaryClient(unaryInterceptors...)),
-		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(100*1024*1024), grpc.MaxCallSendMsgSize(100*1024*1024)),
+		grpc.WithTransportCredentials(creds),
+	}
+	if opts.MaxMessageSize > 0 {
+		dialOpts = append(dialOpts, grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(opts.MaxMessageSize), grpc.MaxCallSendMsgSize(opts.MaxMessageSize)))
 	}
+	if opts.KeepaliveTime > 0 {
+		dialOpts = append(dialOpts, grpc.WithKeepaliveParams(keepalive.ClientParameters{Time: opts.KeepaliveTime, Timeout: opts.KeepaliveTimeout}))
+	}
+
+	return grpc.NewClient(address, dialOpts...)
+}
+
+func transportCredentials(opts GRPCOptions) (credentials.TransportCredentials, error) {
+	if !opts.TLS && opts.CAFile == "" && opts.CertFile == "" {
+		return insecure.NewCredentials(), nil
+	}
+	config := &tls.Config{MinVersion: tls.VersionTLS12, ServerName: opts.ServerName}
+	if opts.CAFile != "" {
+		pem, err := os.ReadFile(opts.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read CA: %w", err)
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("CA %s: no certificates found", opts.CAFile)
+		}
+	}
+	if opts.CertFile != "" || opts.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(opts.CertFile, opts.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return credentials.NewTLS(config), nil
+}
 
-	opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+func parseCodes(names []string) ([]codes.Code, error) {
+	parsed := make([]codes.Code, 0, len(names))
+	for _, name := range names {
+		var c codes.Code
+		if err := c.UnmarshalJSON([]byte(`"` + strings.ToUpper(strings.TrimSpace(name)) + `"`)); err != nil {
+			return nil, fmt.Errorf("unknown retry code %q", name)
+		}
+		parsed = append(parsed, c)
+	}
+	return parsed, nil
+}
 
-	return grpc.NewClient(address, opts...)
+// exponentialBackoff waits base*2^(attempt-1), capped at maxWait and spread
+// by ±jitter.
+func exponentialBackoff(base, maxWait time.Duration, jitter float64) grpc_retry.BackoffFunc {
+	return func(attempt uint) time.Duration {
+		if attempt == 0 {
+			return 0
+		}
+		wait := base << min(attempt-1, 30)
+		if wait <= 0 || (maxWait > 0 && wait > maxWait) {
+			wait = maxWait
+		}
+		if jitter > 0 {
+			wait = time.Duration(float64(wait) * (1 + jitter*(2*rand.Float64()-1)))
+		}
+		return wait
+	}
+}
+
+// callTimeout gives unary calls without a deadline one of d.
+func callTimeout(d time.Duration) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if _, ok := ctx.Deadline(); !ok {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, d)
+			defer cancel()
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
 }
@@ -0,0 +1,311 @@
+package utils
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	dir  string
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	ca := &testCA{cert: cert, key: key, dir: t.TempDir()}
+	ca.write(t, "ca.pem", "CERTIFICATE", der)
+	return ca
+}
+
+func (ca *testCA) write(t *testing.T, name, blockType string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(ca.dir, name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// issue signs a certificate for name and returns its key pair and PEM files.
+func (ca *testCA) issue(t *testing.T, name string, usage x509.ExtKeyUsage) (tls.Certificate, string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile := ca.write(t, name+".pem", "CERTIFICATE", der)
+	keyFile := ca.write(t, name+"-key.pem", "EC PRIVATE KEY", keyDER)
+	pair, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pair, certFile, keyFile
+}
+
+// serveHealth starts a health server on a local port and returns its address.
+func serveHealth(t *testing.T, opts ...grpc.ServerOption) string {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer(opts...)
+	healthpb.RegisterHealthServer(server, health.NewServer())
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+	return lis.Addr().String()
+}
+
+func checkHealth(t *testing.T, address string, opts GRPCOptions) error {
+	t.Helper()
+	conn, err := GetGRPCConnection(address, opts)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err = healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	return err
+}
+
+func TestGetGRPCConnectionMutualTLS(t *testing.T) {
+	ca := newTestCA(t)
+	serverCert, _, _ := ca.issue(t, "relay.internal", x509.ExtKeyUsageServerAuth)
+	_, clientCert, clientKey := ca.issue(t, "flight-node", x509.ExtKeyUsageClientAuth)
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	address := serveHealth(t, grpc.Creds(credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	})))
+
+	opts := DefaultGRPCOptions()
+	opts.Retries = 1
+	opts.CAFile = filepath.Join(ca.dir, "ca.pem")
+	opts.ServerName = "relay.internal"
+	opts.CertFile, opts.KeyFile = clientCert, clientKey
+	if err := checkHealth(t, address, opts); err != nil {
+		t.Fatalf("mTLS call: %v", err)
+	}
+
+	noClientCert := opts
+	noClientCert.CertFile, noClientCert.KeyFile = "", ""
+	if err := checkHealth(t, address, noClientCert); status.Code(err) != codes.Unavailable {
+		t.Fatalf("expected the server to refuse a client without a certificate, got %v", err)
+	}
+	wrongName := opts
+	wrongName.ServerName = ""
+	if err := checkHealth(t, address, wrongName); status.Code(err) != codes.Unavailable {
+		t.Fatalf("expected 127.0.0.1 not to match the certificate, got %v", err)
+	}
+	plaintext := DefaultGRPCOptions()
+	plaintext.Retries = 1
+	if err := checkHealth(t, address, plaintext); err == nil {
+		t.Fatal("expected a plaintext client to fail against a TLS server")
+	}
+	if _, err := GetGRPCConnection(address, GRPCOptions{CAFile: filepath.Join(ca.dir, "missing.pem")}); err == nil {
+		t.Fatal("expected a missing CA file to be rejected")
+	}
+}
+
+func TestGetGRPCConnectionRetriesSelectedCodes(t *testing.T) {
+	var calls atomic.Int32
+	address := serveHealth(t, grpc.UnaryInterceptor(func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		switch calls.Add(1) {
+		case 1, 2:
+			return nil, status.Error(codes.Unavailable, "restarting")
+		case 4:
+			time.Sleep(time.Second)
+		}
+		return handler(ctx, req)
+	}))
+
+	opts := DefaultGRPCOptions()
+	opts.RetryBackoff, opts.RetryMaxBackoff = time.Millisecond, 5*time.Millisecond
+	if err := checkHealth(t, address, opts); err != nil || calls.Load() != 3 {
+		t.Fatalf("expected success on the third attempt, got %v after %d calls", err, calls.Load())
+	}
+
+	calls.Store(0)
+	opts.RetryCodes = []string{"resource_exhausted"}
+	if err := checkHealth(t, address, opts); status.Code(err) != codes.Unavailable || calls.Load() != 1 {
+		t.Fatalf("expected unavailable not to be retried, got %v after %d calls", err, calls.Load())
+	}
+
+	calls.Store(3)
+	opts.CallTimeout = 50 * time.Millisecond
+	if err := checkHealth(t, address, opts); status.Code(err) == codes.DeadlineExceeded {
+		t.Fatalf("an explicit deadline must win over the call timeout, got %v", err)
+	}
+	calls.Store(3)
+	conn, err := GetGRPCConnection(address, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{}); status.Code(err) != codes.DeadlineExceeded {
+		t.Fatalf("expected the call timeout to apply, got %v", err)
+	}
+
+	if _, err := GetGRPCConnection(address, GRPCOptions{RetryCodes: []string{"sometimes"}}); err == nil {
+		t.Fatal("expected an unknown retry code to be rejected")
+	}
+}
+
+func TestObserveClientInterceptors(t *testing.T) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator()) })
+
+	var calls atomic.Int32
+	traceparents := make(chan string, 4)
+	address := serveHealth(t, grpc.UnaryInterceptor(func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		traceparents <- strings.Join(md.Get("traceparent"), ",")
+		if calls.Add(1) == 1 {
+			return nil, status.Error(codes.Unavailable, "restarting")
+		}
+		return handler(ctx, req)
+	}))
+
+	registry := prometheus.NewRegistry()
+	opts := DefaultGRPCOptions()
+	opts.RetryBackoff = time.Millisecond
+	opts.Metrics = NewGRPCClientMetrics(registry, "test")
+	conn, err := GetGRPCConnection(address, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	client := healthpb.NewHealthClient(conn)
+
+	spanCtx := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1},
+		SpanID:     trace.SpanID{2},
+		TraceFlags: trace.FlagsSampled,
+	})
+	ctx := trace.ContextWithSpanContext(context.Background(), spanCtx)
+	if _, err := client.Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	for range 2 {
+		if got := <-traceparents; !strings.Contains(got, spanCtx.TraceID().String()) {
+			t.Fatalf("expected every attempt to carry the trace context, got %q", got)
+		}
+	}
+
+	method := healthpb.Health_Check_FullMethodName
+	if got := testutil.ToFloat64(opts.Metrics.retries.WithLabelValues(address, method)); got != 1 {
+		t.Fatalf("expected one retry, got %v", got)
+	}
+	for _, code := range []string{"Unavailable", "OK"} {
+		if got := observations(t, registry, method, code); got != 1 {
+			t.Fatalf("expected one %s attempt, got %d", code, got)
+		}
+	}
+
+	// A stream is observed when it ends.
+	streamCtx, cancel := context.WithCancel(ctx)
+	stream, err := client.Watch(streamCtx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stream.Recv(); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	if _, err := stream.Recv(); status.Code(err) != codes.Canceled {
+		t.Fatalf("expected a cancelled stream, got %v", err)
+	}
+	if got := observations(t, registry, healthpb.Health_Watch_FullMethodName, "Canceled"); got != 1 {
+		t.Fatalf("expected the ended stream to be observed, got %d", got)
+	}
+}
+
+// observations returns the number of calls to method recorded with code.
+func observations(t *testing.T, registry *prometheus.Registry, method, code string) uint64 {
+	t.Helper()
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, family := range families {
+		if family.GetName() != "test_grpc_client_call_duration_seconds" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			if labels["method"] == method && labels["code"] == code {
+				return metric.GetHistogram().GetSampleCount()
+			}
+		}
+	}
+	return 0
+}
@@ -4,6 +4,7 @@ export interface AirlineDTO {
   airlineId: string;
   name: string;
   code: string;
+  signer?: string;
 }
 
 export interface FlightDTO {
@@ -11,6 +12,9 @@ export interface FlightDTO {
   flightId: string;
   departureTimestamp: number;
   status: FlightAPIStatus;
+  aircraftId?: string;
+  signature?: string;
+  version: number;
 }
 
 export interface AirlineWithFlights extends AirlineDTO {